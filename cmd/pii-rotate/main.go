@@ -0,0 +1,164 @@
+// Команда pii-rotate перешифровывает персональные данные активным ключом из связки pii_params.keyring_file
+// и заполняет слепой индекс паспорта. Сервер делает то же самое лениво при чтении профиля и заявок KYC;
+// команду запускают после включения шифрования и перед удалением старого ключа из связки.
+package main
+
+import (
+	"WalletX/config"
+	"WalletX/internal/db"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/pii"
+	"database/sql"
+	"fmt"
+)
+
+const batchSize = 500
+
+// table — таблица с зашифрованными полями; последнее поле — номер паспорта. owner — колонка с id
+// пользователя, к которому вместе с именем колонки привязан шифртекст (pii.AAD).
+type table struct {
+	name   string
+	owner  string
+	fields []string
+}
+
+var tables = []table{
+	{name: "users", owner: "id", fields: []string{"first_name", "last_name", "middle_name", "passport_number"}},
+	{name: "kyc_applications", owner: "user_id", fields: []string{"first_name", "last_name", "middle_name", "passport_number"}},
+}
+
+func main() {
+	if err := config.ReadSettings(); err != nil {
+		logger.Error.Fatalf("Failed to read configuration settings: %s", err)
+	}
+	if err := logger.Init(); err != nil {
+		logger.Error.Fatalf("Error initializing logger: %v", err)
+	}
+
+	cipher, err := pii.LoadKeyring(config.AppSettings.PIIParams.KeyringFile)
+	if err != nil {
+		logger.Error.Fatalf("Failed to load PII keyring: %v", err)
+	}
+
+	if err := db.ConnectDB(); err != nil {
+		logger.Error.Fatalf("Error connecting to DB: %v", err)
+	}
+	defer db.CloseDB()
+	conn := db.GetDBConnection()
+
+	for _, t := range tables {
+		updated, failed, err := rotate(conn, cipher, t)
+		if err != nil {
+			logger.Error.Fatalf("[pii-rotate] %s: %v", t.name, err)
+		}
+		logger.Info.Printf("[pii-rotate] %s: %d rows re-encrypted, %d failed", t.name, updated, failed)
+		fmt.Printf("%s: %d rows re-encrypted, %d failed\n", t.name, updated, failed)
+	}
+}
+
+func rotate(conn *sql.DB, cipher *pii.Cipher, t table) (updated, failed int, err error) {
+	query := fmt.Sprintf(`SELECT id, %s, %s, %s, %s, %s, passport_index FROM %s WHERE id > $1 ORDER BY id LIMIT $2`,
+		t.owner, t.fields[0], t.fields[1], t.fields[2], t.fields[3], t.name)
+	// строка, измененная после чтения, не перезаписывается: ее перешифрует следующий запуск
+	update := fmt.Sprintf(`UPDATE %s SET %s = $2, %s = $3, %s = $4, %s = $5, passport_index = $6
+		WHERE id = $1 AND %s IS NOT DISTINCT FROM $7 AND %s IS NOT DISTINCT FROM $8
+		  AND %s IS NOT DISTINCT FROM $9 AND %s IS NOT DISTINCT FROM $10 AND passport_index IS NOT DISTINCT FROM $11`,
+		t.name, t.fields[0], t.fields[1], t.fields[2], t.fields[3],
+		t.fields[0], t.fields[1], t.fields[2], t.fields[3])
+
+	lastID := 0
+	for {
+		rows, err := conn.Query(query, lastID, batchSize)
+		if err != nil {
+			return updated, failed, err
+		}
+
+		type row struct {
+			id     int
+			owner  int
+			values [4]sql.NullString
+			index  sql.NullString
+		}
+		var batch []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.owner, &r.values[0], &r.values[1], &r.values[2], &r.values[3], &r.index); err != nil {
+				rows.Close()
+				return updated, failed, err
+			}
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, failed, err
+		}
+		if len(batch) == 0 {
+			return updated, failed, nil
+		}
+
+		for _, r := range batch {
+			lastID = r.id
+
+			passport := r.values[3]
+			stale := passport.Valid && passport.String != "" && !r.index.Valid
+			for _, v := range r.values {
+				stale = stale || (v.Valid && cipher.Stale(v.String))
+			}
+			if !stale {
+				continue
+			}
+
+			aad := make([][]byte, len(t.fields))
+			for i, field := range t.fields {
+				aad[i] = pii.AAD(t.name+"."+field, r.owner)
+			}
+			changed, err := reseal(conn, cipher, update, r.id, aad, r.values, r.index)
+			if err != nil {
+				logger.Error.Printf("[pii-rotate] %s id=%d: %v", t.name, r.id, err)
+				failed++
+				continue
+			}
+			if !changed {
+				logger.Warn.Printf("[pii-rotate] %s id=%d changed while re-encrypting, skipped", t.name, r.id)
+				continue
+			}
+			updated++
+		}
+	}
+}
+
+// reseal перешифровывает строку; false — строку изменили после чтения, и она не обновлена
+func reseal(conn *sql.DB, cipher *pii.Cipher, update string, id int, aad [][]byte, values [4]sql.NullString, oldIndex sql.NullString) (bool, error) {
+	args := []interface{}{id}
+	for i, v := range values {
+		if v.Valid && v.String != "" {
+			rewrapped, err := cipher.Rewrap(v.String, aad[i])
+			if err != nil {
+				return false, err
+			}
+			v.String = rewrapped
+		}
+		args = append(args, v)
+	}
+
+	var index sql.NullString
+	if passport := values[3]; passport.Valid && passport.String != "" {
+		plaintext, err := cipher.Decrypt(passport.String, aad[3])
+		if err != nil {
+			return false, err
+		}
+		index = sql.NullString{String: cipher.BlindIndex(plaintext), Valid: true}
+	}
+	args = append(args, index)
+	for _, v := range values {
+		args = append(args, v)
+	}
+	args = append(args, oldIndex)
+
+	res, err := conn.Exec(update, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
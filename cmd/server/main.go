@@ -4,14 +4,29 @@ import (
 	"WalletX/config"
 	"WalletX/internal/db"
 	"WalletX/internal/handlers"
+	"WalletX/internal/handlers/middleware"
 	"WalletX/internal/handlers/transaction"
 	"WalletX/internal/repository"
 	"WalletX/internal/service"
+	"WalletX/pkg/health"
 	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"WalletX/pkg/notify"
+	"WalletX/pkg/pii"
 	redisPkg "WalletX/pkg/redis"
+	"WalletX/pkg/storage"
+	"WalletX/pkg/tracing"
+	"WalletX/pkg/utils"
+	"context"
+	"errors"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 
 	_ "WalletX/docs"
 )
@@ -32,10 +47,33 @@ func main() {
 		logger.Error.Fatalf("Error initializing logger: %v", err)
 	}
 
+	if err := utils.InitKeys(config.AppSettings.AuthParams); err != nil {
+		logger.Error.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	if err := utils.InitTrustedProxies(config.AppSettings.AppParams.TrustedProxies); err != nil {
+		logger.Error.Fatalf("Invalid trusted proxies: %v", err)
+	}
+
+	appParams := config.AppSettings.AppParams
+	shutdownTracing, err := tracing.Init(context.Background(), config.AppSettings.TracingParams, appParams.ServerName, appParams.AppVersion)
+	if err != nil {
+		logger.Error.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	piiCipher, err := pii.LoadKeyring(config.AppSettings.PIIParams.KeyringFile)
+	if err != nil {
+		logger.Error.Fatalf("Failed to load PII keyring: %v", err)
+	}
+
 	rdb := redisPkg.InitRedis()
 	if rdb == nil {
 		logger.Error.Fatalf("Failed to connect to Redis")
 	}
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		logger.Error.Fatalf("Failed to instrument Redis tracing: %v", err)
+	}
 
 	if err := db.ConnectDB(); err != nil {
 		logger.Error.Fatalf("Error connecting to DB: %v", err)
@@ -44,31 +82,156 @@ func main() {
 
 	conn := db.GetDBConnection()
 
+	if err := metrics.RegisterDB(conn, config.AppSettings.PostgresParams.Database); err != nil {
+		logger.Error.Fatalf("Failed to register database metrics: %v", err)
+	}
+	if err := metrics.RegisterRedis(rdb); err != nil {
+		logger.Error.Fatalf("Failed to register Redis metrics: %v", err)
+	}
+
 	userRepo := repository.NewPostgresUserRepo(conn)
 	accountRepo := repository.NewAccountRepository(conn)
 	servicesRepo := repository.NewServicesRepo(conn)
 	transactionRepo := repository.NewTransactionRepository(conn)
-	profileRepo := repository.NewUserProfileRepository(conn)
+	profileRepo := repository.NewUserProfileRepository(conn, piiCipher)
+	templateRepo := repository.NewTemplateRepository(conn)
+	receiptRepo := repository.NewReceiptRepository(conn)
+	statementRepo := repository.NewStatementRepository(conn)
+	analyticsRepo := repository.NewAnalyticsRepository(conn)
+	tokenRepo := repository.NewTokenRepository(rdb)
+	deviceRepo := repository.NewDeviceRepository(conn)
+	twoFactorRepo := repository.NewTwoFactorRepository(conn)
+	otpRepo := repository.NewOTPRepository(rdb)
+	nonceRepo := repository.NewNonceRepository(rdb)
+	adminRepo := repository.NewAdminRepository(conn)
+	oauthRepo := repository.NewOAuthRepository(conn)
+	oauthCodeRepo := repository.NewOAuthCodeRepository(rdb)
+	kycRepo := repository.NewKYCRepository(conn, piiCipher)
 	transactionManager := transaction.NewTransactionManager(conn)
 
-	userService := service.NewUserService(userRepo)
+	kycStore, err := storage.NewLocalStore(config.AppSettings.KYCParams.StorageDir)
+	if err != nil {
+		logger.Error.Fatalf("Failed to initialize KYC file storage: %v", err)
+	}
+
+	notifier := notify.NewLogSender()
+	otpService := service.NewOTPService(otpRepo, notifier)
+	tokenService := service.NewTokenService(tokenRepo, deviceRepo, userRepo)
+	stepUpService := service.NewStepUpService(userRepo, transactionRepo, otpService, tokenService)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, userRepo, otpRepo)
+	deviceService := service.NewDeviceService(deviceRepo, userRepo, tokenService, notifier, stepUpService)
+	userService := service.NewUserService(userRepo, otpService, tokenService, stepUpService, twoFactorService, deviceService)
 	accountService := service.NewAccountService(accountRepo)
 	servicesService := service.NewServicesService(servicesRepo)
 	userProfileService := service.NewUserProfileService(profileRepo)
-	transferService := service.NewTransferService(accountRepo, transactionRepo, transactionManager)
-	paymentService := service.NewPaymentService(accountRepo, transactionRepo, servicesRepo, transactionManager)
+	transferService := service.NewTransferService(accountRepo, transactionRepo, receiptRepo, transactionManager, stepUpService)
+	paymentService := service.NewPaymentService(accountRepo, transactionRepo, servicesRepo, receiptRepo, transactionManager, stepUpService)
+	templateService := service.NewTemplateService(templateRepo, transferService, paymentService)
+	receiptService := service.NewReceiptService(receiptRepo)
+	statementService := service.NewStatementService(statementRepo, accountRepo)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, accountRepo)
+	adminService := service.NewAdminService(adminRepo, userRepo, tokenService)
+	oauthService := service.NewOAuthService(oauthRepo, oauthCodeRepo, tokenService)
+	kycService := service.NewKYCService(kycRepo, kycStore)
 
-	userHandler := handlers.NewUserHandler(userService, accountService, rdb)
+	userHandler := handlers.NewUserHandler(userService, accountService, tokenService, deviceService, rdb)
 	servicesHandler := handlers.NewServicesHandler(servicesService)
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
 	userProfileHandler := handlers.NewUserProfileHandler(userProfileService)
 	transferHandler := handlers.NewTransferHandler(transferService)
+	templateHandler := handlers.NewTemplateHandler(templateService)
+	receiptHandler := handlers.NewReceiptHandler(receiptService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	tokenHandler := handlers.NewTokenHandler(tokenService)
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	adminHandler := handlers.NewAdminHandler(userService, adminService)
+	securityHandler := handlers.NewSecurityHandler(stepUpService, twoFactorService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+	kycHandler := handlers.NewKYCHandler(kycService)
+
+	healthParams := config.AppSettings.HealthParams
+	expectedMigration, err := health.LatestMigration(healthParams.MigrationsDir)
+	if err != nil {
+		logger.Warn.Printf("Cannot determine expected migration version, only dirty state will be checked: %v", err)
+	}
+	checkTimeout := func(name string) time.Duration {
+		return time.Duration(healthParams.TimeoutsMs[name]) * time.Millisecond
+	}
+	checker := health.NewChecker()
+	checker.Add("postgres", checkTimeout("postgres"), health.Postgres(conn, expectedMigration))
+	checker.Add("redis", checkTimeout("redis"), health.Redis(rdb))
+	healthHandler := handlers.NewHealthHandler(checker)
+	authMiddleware := middleware.NewAuth(tokenService)
+	deviceKeys := config.AppSettings.AuthParams.DeviceKeys
+	signatureMiddleware := middleware.NewRequestSignature(deviceService, nonceRepo,
+		deviceKeys.RequireSignedRequests, time.Duration(deviceKeys.MaxClockSkewSeconds)*time.Second)
 
 	r := mux.NewRouter()
-	handlers.RegisterRoutes(r, userHandler, servicesHandler, paymentHandler, userProfileHandler, transferHandler)
+	handlers.RegisterRoutes(r, handlers.Handlers{
+		User:        userHandler,
+		Services:    servicesHandler,
+		Account:     paymentHandler,
+		UserProfile: userProfileHandler,
+		Transfer:    transferHandler,
+		Template:    templateHandler,
+		Receipt:     receiptHandler,
+		Statement:   statementHandler,
+		Analytics:   analyticsHandler,
+		Token:       tokenHandler,
+		Device:      deviceHandler,
+		Admin:       adminHandler,
+		Security:    securityHandler,
+		OAuth:       oauthHandler,
+		KYC:         kycHandler,
+		Health:      healthHandler,
+		Auth:        authMiddleware,
+		Signature:   signatureMiddleware,
+	})
+
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	logger.Info.Println("Server running on :8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		logger.Error.Fatalf("Server error: %v", err)
+	go func() {
+		logger.Info.Println("Server running on :8080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	// /metrics не публикуется на API-роутере: он слушает отдельный внутренний адрес
+	var metricsSrv *http.Server
+	if appParams.MetricsAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{Addr: appParams.MetricsAddr, Handler: metricsMux}
+		go func() {
+			logger.Info.Printf("Metrics server running on %s", appParams.MetricsAddr)
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error.Fatalf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+
+	// Сначала /readyz начинает отвечать 503 и балансировщик снимает сервис с трафика,
+	// затем сервер перестает принимать соединения и дожидается текущих запросов
+	checker.SetShuttingDown()
+	delay := time.Duration(healthParams.ShutdownDelaySeconds) * time.Second
+	logger.Info.Printf("Shutdown signal received, readiness is failing; closing server in %s", delay)
+	time.Sleep(delay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(healthParams.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error.Printf("Graceful shutdown failed: %v", err)
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error.Printf("Metrics server shutdown failed: %v", err)
+		}
 	}
+	logger.Info.Println("Server stopped")
 }
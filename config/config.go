@@ -46,5 +46,10 @@ func ReadSettings() error {
 		return errors.New(fmt.Sprintf("Couldn't decode json config: %s", err.Error()))
 	}
 
+	// секрет подписи JWT можно не хранить в config.json, а передать через окружение, как DB_PASSWORD
+	if AppSettings.AuthParams.JwtSecretKey == "" {
+		AppSettings.AuthParams.JwtSecretKey = os.Getenv("JWT_SECRET_KEY")
+	}
+
 	return nil
 }
@@ -5,8 +5,11 @@ import (
 	"WalletX/pkg/logger"
 	"database/sql"
 	"fmt"
-	_ "github.com/lib/pq"
 	"os"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var db *sql.DB
@@ -24,7 +27,11 @@ func ConnectDB() error {
 	)
 
 	var err error
-	db, err = sql.Open("postgres", dsn)
+	// otelsql оборачивает драйвер: каждый запрос с контекстом запроса становится спаном трассы
+	db, err = otelsql.Open("postgres", dsn,
+		otelsql.WithAttributes(attribute.String("db.system.name", "postgresql")),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
 	if err != nil {
 		logger.Error.Printf("[db] ConnectDB():error during conect to postgres:%s ", err.Error())
 		return err
@@ -4,9 +4,11 @@ import (
 	"WalletX/internal/handlers/middleware"
 	"WalletX/internal/service"
 	"WalletX/models"
+	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
 	"WalletX/pkg/respond"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -26,50 +28,61 @@ func NewPaymentHandler(paymentService *service.PaymentService) *AccountHandler {
 // @Produce json
 // @Security BearerAuth
 // @Param request body models.PayRequest true "Payment request"
+// @Param X-Step-Up-Token header string false "Step-up confirmation token for action payment"
 // @Success 200 {object} map[string]string "payment completed"
 // @Failure 400 {object} models.ErrorResponse "bad request"
 // @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 403 {object} models.ErrorResponse "step-up confirmation required or account is frozen"
 // @Failure 500 {object} models.ErrorResponse "internal error"
 // @Router /api/pay [post]
 func (h *AccountHandler) PayForService(w http.ResponseWriter, r *http.Request) {
-	logger.Info.Printf("[PayForService] Incoming request: %s %s", r.Method, r.URL.Path)
+	log := logger.FromContext(r.Context())
+	log.Info("[PayForService] Incoming request")
 
 	var req models.PayRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error.Printf("[PayForService] Failed to decode request: %v", err)
+		log.Error("[PayForService] Failed to decode request", "error", err)
 		respond.Error(w, http.StatusBadRequest, "invalid request body", err)
 		return
 	}
 
 	userIDRaw := r.Context().Value(middleware.UserIDCtx)
 	if userIDRaw == nil {
+		log.Warn("[PayForService] User not authenticated")
 		respond.Error(w, http.StatusUnauthorized, "user not authenticated", nil)
 		return
 	}
 
 	fromID, ok := userIDRaw.(int)
 	if !ok {
+		log.Error("[PayForService] Invalid user id in context", "user_id", userIDRaw)
 		respond.Error(w, http.StatusInternalServerError, "invalid userID type", nil)
 		return
 	}
 
 	toID, err := h.Payment.ServiceRepo.GetServiceIDByType(req.ServiceType)
 	if err != nil {
-		logger.Warn.Printf("[PayForService] Invalid service type: %s, error: %v", req.ServiceType, err)
+		log.Warn("[PayForService] Invalid service type", "service_type", req.ServiceType, "error", err)
 		respond.Error(w, http.StatusBadRequest, "invalid service type", err)
 		return
 	}
 
-	err = h.Payment.Pay(r.Context(), fromID, toID, req.Amount, req.ServiceType)
+	receipt, err := h.Payment.Pay(stepUpContext(r), fromID, toID, req.Amount, req.ServiceType, req.Account)
 	if err != nil {
-		logger.Error.Printf("[PayForService] Payment failed from=%d to=%d amount=%.2f: %v", fromID, toID, req.Amount, err)
+		if errors.Is(err, errs.ErrStepUpRequired) || errors.Is(err, errs.ErrAccountFrozen) {
+			respond.HandleError(w, err)
+			return
+		}
+		log.Error("[PayForService] Payment failed", "service_id", toID, "amount", req.Amount, "error", err)
 		respond.Error(w, http.StatusBadRequest, "payment failed", err)
 		return
 	}
 
-	logger.Info.Printf("[PayForService] Payment success from=%d to=%d amount=%.2f type=%s", fromID, toID, req.Amount, req.ServiceType)
+	log.Info("[PayForService] Payment success",
+		"service_id", toID, "service_type", req.ServiceType, "amount", req.Amount, "receipt_number", receipt.ReceiptNumber)
 	respond.JSON(w, http.StatusOK, map[string]string{
-		"status":  "success",
-		"message": "payment completed",
+		"status":         "success",
+		"message":        "payment completed",
+		"receipt_number": receipt.ReceiptNumber,
 	})
 }
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type AdminHandler struct {
+	Users *service.UserService
+	Admin *service.AdminService
+}
+
+func NewAdminHandler(users *service.UserService, admin *service.AdminService) *AdminHandler {
+	return &AdminHandler{Users: users, Admin: admin}
+}
+
+func pathID(r *http.Request) (int, error) {
+	return strconv.Atoi(mux.Vars(r)["id"])
+}
+
+// FindUsers godoc
+// @Summary      Find users
+// @Description  Looks up users by exact phone number or by phone prefix ending with "*" (at least 4 characters). Requires users:read.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        phone query string true "Phone number or prefix" example(+992931*)
+// @Success      200 {array} models.AdminUser
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Router       /api/admin/users [get]
+func (h *AdminHandler) FindUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.Admin.FindUsers(r.Context(), r.URL.Query().Get("phone"))
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary      Get user
+// @Description  Returns the user card with role, lock state and accounts. Requires users:read.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "User ID"
+// @Success      200 {object} models.AdminUser
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	user, err := h.Admin.GetUser(r.Context(), userID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, user)
+}
+
+// UnblockUser godoc
+// @Summary      Unblock user
+// @Description  Removes a temporary lockout or a manual block and resets the lockout history. Requires users:unblock.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "User ID"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/users/{id}/unblock [post]
+func (h *AdminHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	if err := h.Users.Unblock(userID); err != nil {
+		logger.Warn.Printf("[AdminHandler] Failed to unblock userID=%d: %v", userID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "user unblocked"})
+}
+
+// SetRole godoc
+// @Summary      Change user role
+// @Description  Sets the role (user, support, admin, auditor) and ends all sessions of the user so the change applies immediately. Requires roles:manage.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "User ID"
+// @Param        request body models.SetRoleRequest true "New role"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/users/{id}/role [put]
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	userID, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	var req models.SetRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Admin.SetRole(r.Context(), actorID, userID, req.Role); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "role updated"})
+}
+
+// FreezeAccount godoc
+// @Summary      Freeze account
+// @Description  Rejects all debits from the account until it is unfrozen; incoming transfers are still accepted. Requires accounts:freeze.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Account ID"
+// @Param        request body models.FreezeAccountRequest true "Reason"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/accounts/{id}/freeze [post]
+func (h *AdminHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	accountID, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid account id", err)
+		return
+	}
+
+	var req models.FreezeAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Admin.FreezeAccount(r.Context(), actorID, accountID, req.Reason); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "account frozen"})
+}
+
+// UnfreezeAccount godoc
+// @Summary      Unfreeze account
+// @Description  Allows debits from the account again. Requires accounts:freeze.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Account ID"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/accounts/{id}/unfreeze [post]
+func (h *AdminHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	accountID, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid account id", err)
+		return
+	}
+
+	if err := h.Admin.UnfreezeAccount(r.Context(), actorID, accountID); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "account unfrozen"})
+}
+
+// SearchTransactions godoc
+// @Summary      Search transactions
+// @Description  Searches transactions across all accounts, newest first, with cursor pagination. counterparty matches the phone of either side; direction requires account_id. Requires transactions:read.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        account_id query int false "Account ID"
+// @Param        start query string false "Start date (YYYY-MM-DD)" example(2025-11-02)
+// @Param        end query string false "End date (YYYY-MM-DD)" example(2025-12-15)
+// @Param        direction query string false "Direction relative to account_id" Enums(in, out)
+// @Param        type query string false "Transaction types, comma separated" example(transfer,internet)
+// @Param        min_amount query number false "Minimum amount"
+// @Param        max_amount query number false "Maximum amount"
+// @Param        counterparty query string false "Phone of the sender or the recipient" example(+992931753756)
+// @Param        limit query int false "Page size (default 20, max 100)"
+// @Param        cursor query string false "next_cursor from the previous page"
+// @Success      200 {object} models.AdminTransactionPage
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Router       /api/admin/transactions [get]
+func (h *AdminHandler) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	history, err := parseHistoryFilter(r)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+	filter := models.TransactionSearchFilter{HistoryFilter: history}
+
+	if raw := r.URL.Query().Get("account_id"); raw != "" {
+		if filter.AccountID, err = strconv.Atoi(raw); err != nil || filter.AccountID <= 0 {
+			respond.HandleError(w, fmt.Errorf("%w: invalid account_id", errs.ErrValidationFailed))
+			return
+		}
+	}
+
+	page, err := h.Admin.SearchTransactions(r.Context(), filter)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/utils"
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAdminRoutesRequirePermission(t *testing.T) {
+	env := newAuthTestEnv(2, 3)
+	env.users.SetRole(3, models.RoleAuditor)
+
+	userTokens, err := env.tokens.IssuePair(context.Background(), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := env.do(t, http.MethodPost, "/api/admin/users/3/unblock", userTokens.Token, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("user role: status = %d, want 403", rec.Code)
+	}
+
+	auditorTokens, err := env.tokens.IssuePair(context.Background(), 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseToken(auditorTokens.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Role != models.RoleAuditor {
+		t.Fatalf("token role = %q, want %q", claims.Role, models.RoleAuditor)
+	}
+	rec = env.do(t, http.MethodPost, "/api/admin/users/2/unblock", auditorTokens.Token, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("auditor unblock: status = %d, want 403", rec.Code)
+	}
+
+	env.users.SetRole(3, models.RoleSupport)
+	supportTokens, err := env.tokens.IssuePair(context.Background(), 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = env.do(t, http.MethodPost, "/api/admin/users/2/unblock", supportTokens.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("support unblock: status = %d, want 200, body=%s", rec.Code, rec.Body.String())
+	}
+}
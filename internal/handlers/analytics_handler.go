@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"errors"
+	"net/http"
+)
+
+type AnalyticsHandler struct {
+	Service *service.AnalyticsService
+}
+
+func NewAnalyticsHandler(s *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{Service: s}
+}
+
+// GetSpendingAnalytics godoc
+// @Summary Spending analytics
+// @Description Aggregates incoming and outgoing money by period, category and counterparty and compares totals with the previous period of the same length. Defaults to the last 30 days.
+// @Tags analytics
+// @Produce json
+// @Security BearerAuth
+// @Param start query string false "Start date (YYYY-MM-DD)" example(2025-11-01)
+// @Param end query string false "End date (YYYY-MM-DD)" example(2025-11-30)
+// @Param period query string false "Grouping period" Enums(day, week, month)
+// @Success 200 {object} models.SpendingAnalytics
+// @Failure 400 {object} models.ErrorResponse "bad request"
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 500 {object} models.ErrorResponse "internal error"
+// @Router /api/analytics/spending [get]
+func (h *AnalyticsHandler) GetSpendingAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	start, end := parseDateRange(r)
+	if r.URL.Query().Get("start") == "" {
+		start = end.AddDate(0, 0, -30)
+	}
+
+	analytics, err := h.Service.GetSpending(r.Context(), userID, start, end, r.URL.Query().Get("period"))
+	if err != nil {
+		logger.Warn.Printf("[AnalyticsHandler] Failed to build analytics for userID=%d: %v", userID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, analytics)
+}
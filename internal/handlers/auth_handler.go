@@ -5,6 +5,7 @@ import (
 	"WalletX/models"
 	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
 	"WalletX/pkg/respond"
 	"WalletX/pkg/utils"
 	"context"
@@ -42,16 +43,34 @@ type UserHandler struct {
 	Service        *service.UserService
 	Redis          *redis.Client
 	AccountService *service.AccountService
+	Tokens         *service.TokenService
+	Devices        *service.DeviceService
 }
 
-func NewUserHandler(s *service.UserService, accountSvc *service.AccountService, rdb *redis.Client) *UserHandler {
+func NewUserHandler(s *service.UserService, accountSvc *service.AccountService, tokens *service.TokenService, devices *service.DeviceService, rdb *redis.Client) *UserHandler {
 	return &UserHandler{
 		Service:        s,
 		AccountService: accountSvc,
+		Tokens:         tokens,
+		Devices:        devices,
 		Redis:          rdb,
 	}
 }
 
+// startSession регистрирует устройство и выдает пару токенов, привязанную к нему
+func (h *UserHandler) startSession(r *http.Request, userID int, info models.DeviceInfo) (models.TokenPair, models.Device, error) {
+	device, err := h.Devices.Register(r.Context(), userID, info, utils.ClientIP(r))
+	if err != nil {
+		return models.TokenPair{}, models.Device{}, err
+	}
+
+	pair, err := h.Tokens.IssuePair(r.Context(), userID, device.ID)
+	if err != nil {
+		return models.TokenPair{}, models.Device{}, err
+	}
+	return pair, device, nil
+}
+
 // SignUp godoc
 // @Summary Register user
 // @Description Register user in two steps: 1) send SMS code 2) complete registration with code
@@ -102,7 +121,9 @@ func (h *UserHandler) sendVerificationCode(w http.ResponseWriter, ctx context.Co
 		return
 	}
 
-	logger.Info.Printf("SMS verification code sent to %s: %s", phone, code)
+	// SMS-шлюз еще не подключен: сам код в лог не пишется ни при каких настройках маскирования
+	logger.Info.Printf("SMS verification code issued for %s", phone)
+	metrics.SMSCodesSent.WithLabelValues("registration").Inc()
 	respond.JSON(w, http.StatusCreated, map[string]string{
 		"message": "registration code sent",
 	})
@@ -142,22 +163,29 @@ func (h *UserHandler) registerUser(w http.ResponseWriter, ctx context.Context, p
 		return
 	}
 	h.Redis.Del(ctx, "verify:"+phone)
+
+	resp, err := h.Service.IssueRegistrationToken(ctx, user.ID)
+	if err != nil {
+		logger.Error.Printf("Failed to issue registration token for userID=%d: %v", user.ID, err)
+		respond.Error(w, http.StatusInternalServerError, "failed to issue registration token", err)
+		return
+	}
+	resp.Message = "user registered"
+
 	logger.Info.Printf("User registered successfully: %d", user.ID)
-	respond.JSON(w, http.StatusCreated, map[string]interface{}{
-		"message": "user registered",
-		"user_id": user.ID,
-	})
+	respond.JSON(w, http.StatusCreated, resp)
 }
 
 // SetPassword godoc
 // @Summary      Set user password
-// @Description  Sets password and returns JWT token
+// @Description  Completes registration: sets the password using the registration token from signUp and returns JWT token bound to the device from the request
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        request body models.SetPasswordRequest true "Set password request"
 // @Success      201 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string "Invalid or expired registration token"
 // @Router       /api/users/set-password [post]
 func (h *UserHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
 	var req models.SetPasswordRequest
@@ -166,38 +194,44 @@ func (h *UserHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Service.SetPassword(req.UserID, req.Password)
+	userID, err := h.Service.SetPassword(r.Context(), req.RegistrationToken, req.Password)
 	if err != nil {
-		logger.Error.Printf("Failed to set password for user %d: %v", req.UserID, err)
+		logger.Warn.Printf("Failed to set password: %v", err)
 		respond.HandleError(w, err)
 		return
 	}
 
-	logger.Info.Printf("Password set successfully for user %d", req.UserID)
+	logger.Info.Printf("Password set successfully for user %d", userID)
 
-	token, err := utils.GenerateToken(req.UserID, "")
+	pair, device, err := h.startSession(r, userID, req.DeviceInfo)
 	if err != nil {
-		logger.Error.Printf("Failed to generate token for user %d: %v", req.UserID, err)
+		logger.Error.Printf("Failed to generate token for user %d: %v", userID, err)
 		respond.Error(w, http.StatusInternalServerError, "failed to generate token", err)
 		return
 	}
 
 	respond.JSON(w, http.StatusCreated, map[string]interface{}{
-		"message": "password set successfully",
-		"token":   token,
+		"message":       "password set successfully",
+		"token":         pair.Token,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"device_id":     device.DeviceID,
 	})
 }
 
 // Login godoc
 // @Summary      User login
-// @Description  Login using phone and password
+// @Description  Login using phone and password. The device is registered and tokens are bound to it; if device_id is omitted a new one is generated and returned. When two-factor authentication is enabled no tokens are issued; an mfa_token is returned instead.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        request body models.LoginRequest true "Login request"
 // @Success      200 {object} map[string]interface{}
+// @Success      202 {object} models.TwoFactorChallenge "Two-factor authentication is enabled, finish login at /api/users/login/2fa"
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string "Blocked by administrator"
+// @Failure      423 {object} map[string]string "Temporarily locked, see locked_until and Retry-After"
 // @Router       /api/users/login [post]
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
@@ -213,50 +247,184 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, "")
+	h.finishLogin(w, r, user.ID, req.DeviceInfo)
+}
+
+// finishLogin выдает токены после первого фактора или, если включена 2FA, mfa_token для второго шага
+func (h *UserHandler) finishLogin(w http.ResponseWriter, r *http.Request, userID int, info models.DeviceInfo) {
+	twoFactor, err := h.Service.TwoFactor.IsEnabled(r.Context(), userID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+	if twoFactor {
+		challenge, err := h.Service.StartTwoFactorLogin(r.Context(), userID)
+		if err != nil {
+			respond.HandleError(w, err)
+			return
+		}
+		respond.JSON(w, http.StatusAccepted, challenge)
+		return
+	}
+
+	h.respondLoggedIn(w, r, userID, info)
+}
+
+// DeviceLoginChallenge godoc
+// @Summary      Get device login challenge
+// @Description  Returns a challenge for passwordless login with the device key. Sign "walletx-login:" + challenge with the device's Ed25519 key and send it to /api/users/login/device.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.DeviceChallengeRequest true "Phone and device_id"
+// @Success      200 {object} models.DeviceChallengeResponse
+// @Failure      400 {object} map[string]string
+// @Router       /api/users/login/device/challenge [post]
+func (h *UserHandler) DeviceLoginChallenge(w http.ResponseWriter, r *http.Request) {
+	var req models.DeviceChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	resp, err := h.Devices.IssueLoginChallenge(r.Context(), req.Phone, req.DeviceID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// LoginWithDeviceKey godoc
+// @Summary      Login with device key
+// @Description  Passwordless login: the challenge signed with the Ed25519 key registered for the device. A wrong signature counts towards the lockout. With two-factor authentication enabled an mfa_token is returned instead of tokens.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.DeviceLoginRequest true "Signed challenge"
+// @Success      200 {object} map[string]interface{}
+// @Success      202 {object} models.TwoFactorChallenge "Two-factor authentication is enabled, finish login at /api/users/login/2fa"
+// @Failure      401 {object} map[string]string "Invalid signature or challenge"
+// @Failure      423 {object} map[string]string "Temporarily locked, see locked_until and Retry-After"
+// @Router       /api/users/login/device [post]
+func (h *UserHandler) LoginWithDeviceKey(w http.ResponseWriter, r *http.Request) {
+	var req models.DeviceLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	userID, err := h.Service.LoginWithDeviceKey(r.Context(), req)
+	if err != nil {
+		logger.Warn.Printf("Failed device key login for phone %s: %v", req.Phone, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	h.finishLogin(w, r, userID, req.DeviceInfo)
+}
+
+// LoginTwoFactor godoc
+// @Summary      Finish login with second factor
+// @Description  Exchanges the mfa_token from /api/users/login and a code from the authenticator app (or a recovery code) for tokens. The mfa_token is single-use: after a wrong code login starts over and the attempt counts towards the lockout.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TwoFactorLoginRequest true "MFA token and code"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string "Invalid code"
+// @Failure      401 {object} map[string]string "Invalid or expired mfa_token"
+// @Failure      423 {object} map[string]string "Temporarily locked, see locked_until and Retry-After"
+// @Failure      429 {object} map[string]string "Too many attempts"
+// @Router       /api/users/login/2fa [post]
+func (h *UserHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	userID, err := h.Service.CompleteTwoFactorLogin(r.Context(), req.MFAToken, req.Code, req.RecoveryCode)
 	if err != nil {
-		logger.Error.Printf("Failed to generate token for user %d: %v", user.ID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	h.respondLoggedIn(w, r, userID, req.DeviceInfo)
+}
+
+// respondLoggedIn начинает сессию на устройстве и отвечает выданными токенами
+func (h *UserHandler) respondLoggedIn(w http.ResponseWriter, r *http.Request, userID int, info models.DeviceInfo) {
+	pair, device, err := h.startSession(r, userID, info)
+	if err != nil {
+		logger.Error.Printf("Failed to generate token for user %d: %v", userID, err)
 		respond.Error(w, http.StatusInternalServerError, "failed to generate token", err)
 		return
 	}
 
-	logger.Info.Printf("User %d logged in successfully", user.ID)
+	logger.Info.Printf("User %d logged in successfully from device %d", userID, device.ID)
 
 	respond.JSON(w, http.StatusOK, map[string]interface{}{
-		"message": "logged in",
-		"token":   token,
+		"message":       "logged in",
+		"token":         pair.Token,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"device_id":     device.DeviceID,
 	})
 }
 
-// VerifyIdentity godoc
-// @Summary      Verify user identity
-// @Description  Verify user passport and personal data
-// @Tags         User
-// @Security     BearerAuth
+// RequestUnlock godoc
+// @Summary      Request unlock code
+// @Description  Sends an SMS code that removes a temporary lockout after too many wrong passwords. The response is the same whether or not the account is locked. A code can be requested once a minute and up to 5 times an hour.
+// @Tags         Auth
 // @Accept       json
 // @Produce      json
-// @Param        request body models.VerifyIdentityRequest true "Identity data"
-// @Success      201 {object} map[string]string
+// @Param        request body models.UnlockRequest true "Phone"
+// @Success      200 {object} map[string]string
 // @Failure      400 {object} map[string]string
-// @Failure      401 {object} map[string]string
-// @Router       /api/users/verify [post]
-func (h *UserHandler) VerifyIdentity(w http.ResponseWriter, r *http.Request) {
-	var req models.VerifyIdentityRequest
+// @Failure      429 {object} map[string]string
+// @Router       /api/users/unlock [post]
+func (h *UserHandler) RequestUnlock(w http.ResponseWriter, r *http.Request) {
+	var req models.UnlockRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respond.Error(w, http.StatusBadRequest, "invalid request", err)
 		return
 	}
 
-	err := h.Service.VerifyUser(req.UserID, req.FirstName, req.LastName, req.MiddleName, req.PassportNumber)
-	if err != nil {
-		logger.Error.Printf("Failed to verify identity for user %d: %v", req.UserID, err)
+	if err := h.Service.RequestUnlock(r.Context(), req.Phone); err != nil {
+		logger.Error.Printf("Failed to send unlock code to %s: %v", req.Phone, err)
 		respond.HandleError(w, err)
 		return
 	}
 
-	logger.Info.Printf("User %d verified identity successfully", req.UserID)
-
-	respond.JSON(w, http.StatusCreated, map[string]string{
-		"message": "identity verified successfully",
+	respond.JSON(w, http.StatusOK, map[string]string{
+		"message": "if the account is locked, an unlock code has been sent",
 	})
 }
+
+// ConfirmUnlock godoc
+// @Summary      Unlock account
+// @Description  Removes a temporary lockout using the SMS code
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.UnlockConfirmRequest true "Phone and SMS code"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /api/users/unlock/confirm [post]
+func (h *UserHandler) ConfirmUnlock(w http.ResponseWriter, r *http.Request) {
+	var req models.UnlockConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Service.ConfirmUnlock(r.Context(), req.Phone, req.Code); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "account unlocked"})
+}
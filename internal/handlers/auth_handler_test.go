@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"WalletX/config"
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/notify"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	discard := log.New(io.Discard, "", 0)
+	logger.Info, logger.Warn, logger.Error, logger.Debug = discard, discard, discard, discard
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	config.AppSettings.AuthParams = models.AuthParams{JwtSecretKey: "test-secret", JwtTtlMinutes: 15}
+	os.Exit(m.Run())
+}
+
+type fakeUserRepo struct {
+	users     map[int]models.User
+	passwords map[int]string
+	failures  map[int]int
+	pins      map[int]string
+}
+
+func newFakeUserRepo(ids ...int) *fakeUserRepo {
+	repo := &fakeUserRepo{users: map[int]models.User{}, passwords: map[int]string{}, failures: map[int]int{}, pins: map[int]string{}}
+	for _, id := range ids {
+		repo.users[id] = models.User{ID: id, Phone: fmt.Sprintf("+9929300000%02d", id)}
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) CreateUser(user models.User) (models.User, error) { return user, nil }
+func (r *fakeUserRepo) GetByPhone(phone string) (models.User, error) {
+	for _, u := range r.users {
+		if u.Phone == phone {
+			return u, nil
+		}
+	}
+	return models.User{}, errs.ErrUserNotFound
+}
+func (r *fakeUserRepo) UpdatePassword(userID int, hashedPassword string, keepHistory int) error {
+	r.passwords[userID] = hashedPassword
+	return nil
+}
+func (r *fakeUserRepo) GetPasswordHistory(userID, limit int) ([]string, error) {
+	if hash, ok := r.passwords[userID]; ok {
+		return []string{hash}, nil
+	}
+	return nil, nil
+}
+func (r *fakeUserRepo) GetTransactionPIN(userID int) (string, error) { return r.pins[userID], nil }
+func (r *fakeUserRepo) SetTransactionPIN(userID int, hash string) error {
+	r.pins[userID] = hash
+	return nil
+}
+func (r *fakeUserRepo) GetByID(userID int) (models.User, error) {
+	u, ok := r.users[userID]
+	if !ok {
+		return models.User{}, errs.ErrUserNotFound
+	}
+	return u, nil
+}
+func (r *fakeUserRepo) RegisterFailedLogin(userID int, window time.Duration) (int, error) {
+	r.failures[userID]++
+	return r.failures[userID], nil
+}
+func (r *fakeUserRepo) ResetLoginFailures(userID int) error {
+	delete(r.failures, userID)
+	return nil
+}
+func (r *fakeUserRepo) LockUser(userID int, until time.Time) error {
+	u := r.users[userID]
+	u.LockedUntil = &until
+	r.users[userID] = u
+	delete(r.failures, userID)
+	return nil
+}
+func (r *fakeUserRepo) UnlockUser(userID int) error {
+	user := r.users[userID]
+	user.LockedUntil = nil
+	r.users[userID] = user
+	delete(r.failures, userID)
+	return nil
+}
+func (r *fakeUserRepo) UnblockUser(userID int) error { return nil }
+func (r *fakeUserRepo) SetRole(userID int, role string) error {
+	u := r.users[userID]
+	u.Role = role
+	r.users[userID] = u
+	return nil
+}
+
+type fakeTokenRepo struct {
+	refresh map[string]models.RefreshSession
+	oneTime map[string]int
+	revoked map[string]bool
+	cutoffs map[string]time.Time
+	used    map[string]bool
+}
+
+func newFakeTokenRepo() *fakeTokenRepo {
+	return &fakeTokenRepo{
+		refresh: map[string]models.RefreshSession{},
+		oneTime: map[string]int{},
+		revoked: map[string]bool{},
+		cutoffs: map[string]time.Time{},
+		used:    map[string]bool{},
+	}
+}
+
+func (r *fakeTokenRepo) SaveRefresh(ctx context.Context, tokenHash string, session models.RefreshSession, ttl time.Duration) error {
+	r.refresh[tokenHash] = session
+	return nil
+}
+func (r *fakeTokenRepo) GetRefresh(ctx context.Context, tokenHash string) (models.RefreshSession, error) {
+	s, ok := r.refresh[tokenHash]
+	if !ok {
+		return models.RefreshSession{}, errs.ErrInvalidRefreshToken
+	}
+	return s, nil
+}
+func (r *fakeTokenRepo) MarkRefreshUsed(ctx context.Context, tokenHash string, ttl time.Duration) (bool, error) {
+	first := !r.used[tokenHash]
+	r.used[tokenHash] = true
+	return first, nil
+}
+func (r *fakeTokenRepo) RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	r.revoked["family:"+familyID] = true
+	return nil
+}
+func (r *fakeTokenRepo) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	return r.revoked["family:"+familyID], nil
+}
+func (r *fakeTokenRepo) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	r.revoked["jti:"+jti] = true
+	return nil
+}
+func (r *fakeTokenRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.revoked["jti:"+jti], nil
+}
+func (r *fakeTokenRepo) RevokeUserTokens(ctx context.Context, userID int, before time.Time, ttl time.Duration) error {
+	r.cutoffs[fmt.Sprintf("user:%d", userID)] = before
+	return nil
+}
+func (r *fakeTokenRepo) GetUserRevokedBefore(ctx context.Context, userID int) (time.Time, error) {
+	return r.cutoffs[fmt.Sprintf("user:%d", userID)], nil
+}
+func (r *fakeTokenRepo) RevokeDeviceTokens(ctx context.Context, deviceID int, before time.Time, ttl time.Duration) error {
+	r.cutoffs[fmt.Sprintf("device:%d", deviceID)] = before
+	return nil
+}
+func (r *fakeTokenRepo) GetDeviceRevokedBefore(ctx context.Context, deviceID int) (time.Time, error) {
+	return r.cutoffs[fmt.Sprintf("device:%d", deviceID)], nil
+}
+func (r *fakeTokenRepo) RevokeConsentTokens(ctx context.Context, userID int, clientID string, before time.Time, ttl time.Duration) error {
+	r.cutoffs[fmt.Sprintf("consent:%d:%s", userID, clientID)] = before
+	return nil
+}
+func (r *fakeTokenRepo) GetConsentRevokedBefore(ctx context.Context, userID int, clientID string) (time.Time, error) {
+	return r.cutoffs[fmt.Sprintf("consent:%d:%s", userID, clientID)], nil
+}
+func (r *fakeTokenRepo) SaveOneTimeToken(ctx context.Context, purpose, tokenHash string, userID int, ttl time.Duration) error {
+	r.oneTime[purpose+":"+tokenHash] = userID
+	return nil
+}
+func (r *fakeTokenRepo) ConsumeOneTimeToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	userID, ok := r.oneTime[purpose+":"+tokenHash]
+	if !ok {
+		return 0, errs.ErrInvalidOneTimeToken
+	}
+	delete(r.oneTime, purpose+":"+tokenHash)
+	return userID, nil
+}
+
+// fakeOTPRepo хранит коды и счетчики в памяти; паузу между отправками тест снимает через cooldown
+type fakeOTPRepo struct {
+	codes    map[string]string
+	attempts map[string]int
+	cooldown map[string]bool
+	sends    map[string]int
+}
+
+func newFakeOTPRepo() *fakeOTPRepo {
+	return &fakeOTPRepo{codes: map[string]string{}, attempts: map[string]int{}, cooldown: map[string]bool{}, sends: map[string]int{}}
+}
+
+func (r *fakeOTPRepo) Save(ctx context.Context, purpose, subject, codeHash string, ttl time.Duration) error {
+	r.codes[purpose+":"+subject] = codeHash
+	return nil
+}
+func (r *fakeOTPRepo) ReserveSend(ctx context.Context, purpose, subject string, cooldown, window time.Duration) (bool, int, error) {
+	key := purpose + ":" + subject
+	if r.cooldown[key] {
+		return false, 0, nil
+	}
+	r.cooldown[key] = true
+	r.sends[key]++
+	return true, r.sends[key], nil
+}
+func (r *fakeOTPRepo) Get(ctx context.Context, purpose, subject string) (string, error) {
+	codeHash, ok := r.codes[purpose+":"+subject]
+	if !ok {
+		return "", errs.ErrCodeExpired
+	}
+	return codeHash, nil
+}
+func (r *fakeOTPRepo) IncrementAttempts(ctx context.Context, purpose, subject string, ttl time.Duration) (int, error) {
+	r.attempts[purpose+":"+subject]++
+	return r.attempts[purpose+":"+subject], nil
+}
+func (r *fakeOTPRepo) Delete(ctx context.Context, purpose, subject string) error {
+	delete(r.codes, purpose+":"+subject)
+	delete(r.attempts, purpose+":"+subject)
+	return nil
+}
+
+// fakeSender запоминает последнее SMS для каждого номера
+type fakeSender struct {
+	messages map[string]string
+}
+
+func (s *fakeSender) Send(ctx context.Context, phone, purpose, message string) error {
+	s.messages[phone] = message
+	return nil
+}
+
+// lastCode достает код из последнего SMS на phone
+func (s *fakeSender) lastCode(t *testing.T, phone string) string {
+	t.Helper()
+	fields := strings.Fields(s.messages[phone])
+	for _, f := range fields {
+		f = strings.TrimSuffix(f, ".")
+		if _, err := strconv.Atoi(f); err == nil && len(f) == 6 {
+			return f
+		}
+	}
+	t.Fatalf("no code in SMS to %s: %q", phone, s.messages[phone])
+	return ""
+}
+
+type fakeDeviceRepo struct {
+	nextID  int
+	devices map[int]models.Device
+}
+
+func (r *fakeDeviceRepo) Upsert(ctx context.Context, device models.Device) (models.Device, bool, error) {
+	for id, d := range r.devices {
+		if d.UserID == device.UserID && d.DeviceID == device.DeviceID {
+			device.ID, device.PublicKey = id, d.PublicKey
+			r.devices[id] = device
+			return device, false, nil
+		}
+	}
+	r.nextID++
+	device.ID = r.nextID
+	r.devices[device.ID] = device
+	return device, true, nil
+}
+func (r *fakeDeviceRepo) GetActiveByUserID(ctx context.Context, userID int) ([]models.Device, error) {
+	return nil, nil
+}
+func (r *fakeDeviceRepo) Touch(ctx context.Context, id int, ip string) error { return nil }
+func (r *fakeDeviceRepo) Revoke(ctx context.Context, userID, id int) error   { return nil }
+func (r *fakeDeviceRepo) GetByID(ctx context.Context, id int) (models.Device, error) {
+	d, ok := r.devices[id]
+	if !ok {
+		return models.Device{}, errs.ErrDeviceNotFound
+	}
+	return d, nil
+}
+func (r *fakeDeviceRepo) GetByDeviceID(ctx context.Context, userID int, deviceID string) (models.Device, error) {
+	for _, d := range r.devices {
+		if d.UserID == userID && d.DeviceID == deviceID {
+			return d, nil
+		}
+	}
+	return models.Device{}, errs.ErrDeviceNotFound
+}
+func (r *fakeDeviceRepo) SetPublicKey(ctx context.Context, userID, id int, publicKey string) error {
+	d, ok := r.devices[id]
+	if !ok || d.UserID != userID {
+		return errs.ErrDeviceNotFound
+	}
+	d.PublicKey = publicKey
+	r.devices[id] = d
+	return nil
+}
+
+type authTestEnv struct {
+	router    *mux.Router
+	users     *fakeUserRepo
+	devices   *fakeDeviceRepo
+	twoFactor *fakeTwoFactorRepo
+	userSv    *service.UserService
+	tokens    *service.TokenService
+	stepUp    *service.StepUpService
+	oauth     *service.OAuthService
+	kyc       *fakeKYCRepo
+	files     *memoryStore
+	otp       *fakeOTPRepo
+	sms       *fakeSender
+}
+
+func newAuthTestEnv(userIDs ...int) *authTestEnv {
+	users := newFakeUserRepo(userIDs...)
+	devices := &fakeDeviceRepo{devices: map[int]models.Device{}}
+	tokens := service.NewTokenService(newFakeTokenRepo(), devices, users)
+	otpRepo := newFakeOTPRepo()
+	sms := &fakeSender{messages: map[string]string{}}
+	otp := service.NewOTPService(otpRepo, sms)
+	stepUp := service.NewStepUpService(users, nil, otp, tokens)
+	twoFactorRepo := &fakeTwoFactorRepo{states: map[int]models.TwoFactorState{}}
+	twoFactor := service.NewTwoFactorService(twoFactorRepo, users, newFakeOTPRepo())
+	deviceService := service.NewDeviceService(devices, users, tokens, notify.NewLogSender(), stepUp)
+	userService := service.NewUserService(users, otp, tokens, stepUp, twoFactor, deviceService)
+	oauthRepo := &fakeOAuthRepo{clients: map[string]models.OAuthClient{}, consents: map[string][]string{}}
+	oauthService := service.NewOAuthService(oauthRepo, &fakeOAuthCodeRepo{codes: map[string]models.OAuthAuthorizationCode{}}, tokens)
+	kycRepo := &fakeKYCRepo{tiers: map[int]int{}}
+	files := &memoryStore{files: map[string][]byte{}}
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, Handlers{
+		User:     NewUserHandler(userService, nil, tokens, deviceService, nil),
+		Token:    NewTokenHandler(tokens),
+		Admin:    NewAdminHandler(userService, nil),
+		Security: NewSecurityHandler(stepUp, twoFactor),
+		OAuth:    NewOAuthHandler(oauthService),
+		KYC:      NewKYCHandler(service.NewKYCService(kycRepo, files)),
+		Auth:     middleware.NewAuth(tokens),
+	})
+
+	return &authTestEnv{router: r, users: users, devices: devices, twoFactor: twoFactorRepo, userSv: userService, tokens: tokens, stepUp: stepUp, oauth: oauthService,
+		kyc: kycRepo, files: files, otp: otpRepo, sms: sms}
+}
+
+func (e *authTestEnv) do(t *testing.T, method, path, bearer string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSetPasswordIgnoresUserIDWithoutRegistrationToken(t *testing.T) {
+	env := newAuthTestEnv(2)
+
+	rec := env.do(t, http.MethodPost, "/api/users/set-password", "", map[string]interface{}{
+		"user_id":  2,
+		"password": "12345678",
+	})
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+	if len(env.users.passwords) != 0 {
+		t.Fatalf("password was changed without a registration token: %v", env.users.passwords)
+	}
+}
+
+func TestSetPasswordRejectsUnknownToken(t *testing.T) {
+	env := newAuthTestEnv(2)
+
+	rec := env.do(t, http.MethodPost, "/api/users/set-password", "", map[string]interface{}{
+		"registration_token": strings.Repeat("ab", 32),
+		"password":           "12345678",
+	})
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+	if len(env.users.passwords) != 0 {
+		t.Fatalf("password was changed with an unknown token: %v", env.users.passwords)
+	}
+}
+
+func TestSetPasswordUsesUserFromRegistrationToken(t *testing.T) {
+	env := newAuthTestEnv(2, 7)
+
+	reg, err := env.userSv.IssueRegistrationToken(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := map[string]interface{}{
+		"user_id":            2,
+		"registration_token": reg.RegistrationToken,
+		"password":           "12345678",
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/users/set-password", "", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if _, ok := env.users.passwords[7]; !ok {
+		t.Fatal("password of the token owner was not set")
+	}
+	if _, ok := env.users.passwords[2]; ok {
+		t.Fatal("password of the user from the request body was changed")
+	}
+
+	delete(env.users.passwords, 7)
+	rec = env.do(t, http.MethodPost, "/api/users/set-password", "", body)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("reused token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if len(env.users.passwords) != 0 {
+		t.Fatalf("registration token worked twice: %v", env.users.passwords)
+	}
+}
+
+// setPassword задает пользователю пароль для тестов, где он проверяется
+func (e *authTestEnv) setPassword(t *testing.T, userID int, password string) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := e.users.users[userID]
+	user.Password = string(hash)
+	e.users.users[userID] = user
+}
+
+func TestChangePasswordWrongPasswordLocksAccount(t *testing.T) {
+	env := newAuthTestEnv(7)
+	env.setPassword(t, 7, "walletx2025")
+	tokens, err := env.tokens.IssuePair(context.Background(), 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		rec := env.do(t, http.MethodPost, "/api/users/password/change", tokens.Token,
+			map[string]string{"old_password": "guess", "new_password": "walletx2026"})
+		want := http.StatusUnauthorized
+		if attempt == 3 {
+			want = http.StatusLocked
+		}
+		if rec.Code != want {
+			t.Fatalf("attempt %d: status = %d, want %d: %s", attempt, rec.Code, want, rec.Body)
+		}
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/users/password/change", tokens.Token,
+		map[string]string{"old_password": "walletx2025", "new_password": "walletx2026"})
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("after lockout: status = %d, want %d: %s", rec.Code, http.StatusLocked, rec.Body)
+	}
+	if len(env.users.passwords) != 0 {
+		t.Fatal("password was changed on a locked account")
+	}
+}
+
+func TestPasswordResetResendKeepsAttemptBudget(t *testing.T) {
+	env := newAuthTestEnv(7)
+	phone := env.users.users[7].Phone
+	resetKey := service.OTPPurposePasswordReset + ":" + phone
+	forgot := func() int {
+		return env.do(t, http.MethodPost, "/api/users/password/forgot", "", map[string]string{"phone": phone}).Code
+	}
+	verify := func(code string) int {
+		return env.do(t, http.MethodPost, "/api/users/password/verify", "", map[string]string{"phone": phone, "code": code}).Code
+	}
+
+	if code := forgot(); code != http.StatusOK {
+		t.Fatalf("forgot: status = %d, want 200", code)
+	}
+	if code := forgot(); code != http.StatusTooManyRequests {
+		t.Fatalf("resend within cooldown: status = %d, want 429", code)
+	}
+	if got := env.sms.lastCode(t, phone); len(got) != 6 {
+		t.Fatalf("code %q is not 6 digits", got)
+	}
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if code := verify("000000"); code != http.StatusBadRequest {
+			t.Fatalf("wrong code %d: status = %d, want 400", attempt, code)
+		}
+	}
+
+	// новый код после паузы не дает новых попыток
+	delete(env.otp.cooldown, resetKey)
+	if code := forgot(); code != http.StatusOK {
+		t.Fatalf("resend after cooldown: status = %d, want 200", code)
+	}
+	if code := verify(env.sms.lastCode(t, phone)); code != http.StatusTooManyRequests {
+		t.Fatalf("correct code after exhausted budget: status = %d, want 429", code)
+	}
+
+	// за час уходит не больше пяти кодов
+	for sent := 2; sent < 5; sent++ {
+		delete(env.otp.cooldown, resetKey)
+		if code := forgot(); code != http.StatusOK {
+			t.Fatalf("send %d: status = %d, want 200", sent+1, code)
+		}
+	}
+	delete(env.otp.cooldown, resetKey)
+	if code := forgot(); code != http.StatusTooManyRequests {
+		t.Fatalf("send over hourly cap: status = %d, want 429", code)
+	}
+}
+
+func TestPasswordResetLimitsUnknownPhone(t *testing.T) {
+	env := newAuthTestEnv(7)
+	body := map[string]string{"phone": "+992930000099"}
+
+	if rec := env.do(t, http.MethodPost, "/api/users/password/forgot", "", body); rec.Code != http.StatusOK {
+		t.Fatalf("forgot: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := env.do(t, http.MethodPost, "/api/users/password/forgot", "", body); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("resend: status = %d, want 429 as for a registered phone: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestUnlockResendKeepsAttemptBudget(t *testing.T) {
+	env := newAuthTestEnv(7)
+	user := env.users.users[7]
+	until := time.Now().Add(time.Hour)
+	user.LockedUntil = &until
+	env.users.users[7] = user
+	unlockKey := service.OTPPurposeUnlock + ":" + user.Phone
+
+	request := func() int {
+		return env.do(t, http.MethodPost, "/api/users/unlock", "", map[string]string{"phone": user.Phone}).Code
+	}
+	confirm := func(code string) int {
+		return env.do(t, http.MethodPost, "/api/users/unlock/confirm", "", map[string]string{"phone": user.Phone, "code": code}).Code
+	}
+
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("unlock: status = %d, want 200", code)
+	}
+	if code := request(); code != http.StatusTooManyRequests {
+		t.Fatalf("resend within cooldown: status = %d, want 429", code)
+	}
+	for attempt := 1; attempt <= 5; attempt++ {
+		if code := confirm("000000"); code != http.StatusBadRequest {
+			t.Fatalf("wrong code %d: status = %d, want 400", attempt, code)
+		}
+	}
+
+	delete(env.otp.cooldown, unlockKey)
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("resend after cooldown: status = %d, want 200", code)
+	}
+	if code := confirm(env.sms.lastCode(t, user.Phone)); code != http.StatusTooManyRequests {
+		t.Fatalf("correct code after exhausted budget: status = %d, want 429", code)
+	}
+	if locked := env.users.users[7].LockedUntil; locked == nil {
+		t.Fatal("lockout was removed after the attempt budget was exhausted")
+	}
+}
+
+func TestUnlockLimitsAccountThatIsNotLocked(t *testing.T) {
+	env := newAuthTestEnv(7)
+	body := map[string]string{"phone": env.users.users[7].Phone}
+
+	if rec := env.do(t, http.MethodPost, "/api/users/unlock", "", body); rec.Code != http.StatusOK {
+		t.Fatalf("unlock: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := env.do(t, http.MethodPost, "/api/users/unlock", "", body); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("resend: status = %d, want 429 as for a locked account: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"WalletX/pkg/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type DeviceHandler struct {
+	Service *service.DeviceService
+}
+
+func NewDeviceHandler(s *service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{Service: s}
+}
+
+// ListDevices godoc
+// @Summary List active devices
+// @Description Returns devices with an active session, most recently seen first. The device of the current token is marked with current=true.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Device
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 500 {object} models.ErrorResponse "internal error"
+// @Router /api/devices [get]
+func (h *DeviceHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsCtx).(*utils.CustomClaims)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing token claims"))
+		return
+	}
+
+	devices, err := h.Service.List(r.Context(), claims.UserID, claims.DeviceID)
+	if err != nil {
+		logger.Error.Printf("[DeviceHandler] Failed to list devices for userID=%d: %v", claims.UserID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, devices)
+}
+
+// RevokeDevice godoc
+// @Summary Revoke device
+// @Description Ends the session on the device: all its access and refresh tokens stop working immediately
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Device ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 404 {object} models.ErrorResponse "device not found"
+// @Router /api/devices/{id} [delete]
+func (h *DeviceHandler) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	deviceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid device id", err)
+		return
+	}
+
+	if err := h.Service.Revoke(r.Context(), userID, deviceID); err != nil {
+		logger.Warn.Printf("[DeviceHandler] Failed to revoke device id=%d for userID=%d: %v", deviceID, userID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// RegisterDeviceKey godoc
+// @Summary Register device key
+// @Description Binds an Ed25519 public key to the device of the current session. The key enables passwordless login (/api/users/login/device) and request signing for transfers and payments. Requires a step-up token for action security.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RegisterDeviceKeyRequest true "Public key"
+// @Param X-Step-Up-Token header string false "Step-up confirmation token for action security"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse "invalid key"
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 403 {object} models.ErrorResponse "step-up confirmation required"
+// @Failure 404 {object} models.ErrorResponse "device not found"
+// @Router /api/devices/current/key [put]
+func (h *DeviceHandler) RegisterDeviceKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsCtx).(*utils.CustomClaims)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing token claims"))
+		return
+	}
+
+	var req models.RegisterDeviceKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Service.RegisterKey(stepUpContext(r), claims.UserID, claims.DeviceID, req.PublicKey); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "device key registered"})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"WalletX/config"
+	"WalletX/models"
+	"WalletX/pkg/utils"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginWithDeviceKey(t *testing.T) {
+	env := newAuthTestEnv(7)
+	phone := env.users.users[7].Phone
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.devices.devices[1] = models.Device{ID: 1, UserID: 7, DeviceID: "pixel-8", PublicKey: base64.StdEncoding.EncodeToString(public)}
+	env.devices.nextID = 1
+
+	challenge := func() string {
+		t.Helper()
+		rec := env.do(t, http.MethodPost, "/api/users/login/device/challenge", "", map[string]string{"phone": phone, "device_id": "pixel-8"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("challenge: status = %d: %s", rec.Code, rec.Body)
+		}
+		var resp models.DeviceChallengeResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Challenge
+	}
+	sign := func(key ed25519.PrivateKey, c string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(key, utils.DeviceLoginMessage(c)))
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	c := challenge()
+	rec := env.do(t, http.MethodPost, "/api/users/login/device", "", map[string]string{
+		"phone": phone, "device_id": "pixel-8", "challenge": c, "signature": sign(otherKey, c),
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("foreign key: status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+
+	c = challenge()
+	body := map[string]string{"phone": phone, "device_id": "pixel-8", "challenge": c, "signature": sign(private, c)}
+	rec = env.do(t, http.MethodPost, "/api/users/login/device", "", body)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "refresh_token") {
+		t.Fatalf("valid signature: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	rec = env.do(t, http.MethodPost, "/api/users/login/device", "", body)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed challenge: status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+}
+
+func TestLoginWithDeviceKeyRejectsExpiredPassword(t *testing.T) {
+	policy := config.AppSettings.AuthParams.PasswordPolicy
+	config.AppSettings.AuthParams.PasswordPolicy.ExpiryDays = 90
+	t.Cleanup(func() { config.AppSettings.AuthParams.PasswordPolicy = policy })
+
+	env := newAuthTestEnv(7)
+	user := env.users.users[7]
+	changedAt := time.Now().AddDate(0, 0, -91)
+	user.PasswordChangedAt = &changedAt
+	env.users.users[7] = user
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.devices.devices[1] = models.Device{ID: 1, UserID: 7, DeviceID: "pixel-8", PublicKey: base64.StdEncoding.EncodeToString(public)}
+	env.devices.nextID = 1
+
+	rec := env.do(t, http.MethodPost, "/api/users/login/device/challenge", "", map[string]string{"phone": user.Phone, "device_id": "pixel-8"})
+	var challenge models.DeviceChallengeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &challenge); err != nil {
+		t.Fatal(err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, utils.DeviceLoginMessage(challenge.Challenge)))
+
+	rec = env.do(t, http.MethodPost, "/api/users/login/device", "", map[string]string{
+		"phone": user.Phone, "device_id": "pixel-8", "challenge": challenge.Challenge, "signature": signature,
+	})
+	if rec.Code != http.StatusForbidden || strings.Contains(rec.Body.String(), "refresh_token") {
+		t.Fatalf("expired password: status = %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"WalletX/pkg/health"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"net/http"
+	"time"
+)
+
+type HealthHandler struct {
+	Checker   *health.Checker
+	StartedAt time.Time
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{Checker: checker, StartedAt: time.Now()}
+}
+
+// Healthz godoc
+// @Summary      Liveness probe
+// @Description  Reports that the process is running and able to serve HTTP. Dependencies are not checked: a restart would not fix a database outage.
+// @Tags         System
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Router       /healthz [get]
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, http.StatusOK, map[string]interface{}{
+		"status":         health.StatusOK,
+		"uptime_seconds": int64(time.Since(h.StartedAt).Seconds()),
+	})
+}
+
+// Readyz godoc
+// @Summary      Readiness probe
+// @Description  Checks PostgreSQL (connectivity and schema migration version) and Redis, each with its own timeout. Returns 503 if any check fails or the service is shutting down.
+// @Tags         System
+// @Produce      json
+// @Success      200 {object} health.Report
+// @Failure      503 {object} health.Report
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.Checker.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+		for name, result := range report.Checks {
+			if result.Status != health.StatusOK {
+				logger.FromContext(r.Context()).Warn("[HealthHandler] Readiness check failed", "check", name, "error", result.Error)
+			}
+		}
+	}
+	respond.JSON(w, status, report)
+}
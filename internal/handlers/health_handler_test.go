@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"WalletX/pkg/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessReportsChecksAndShutdown(t *testing.T) {
+	checker := health.NewChecker()
+	h := NewHealthHandler(checker)
+
+	redisDown := errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+	var redisErr error
+	checker.Add("postgres", time.Second, func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"migration_version": 13}, nil
+	})
+	checker.Add("redis", time.Second, func(ctx context.Context) (map[string]any, error) {
+		return nil, redisErr
+	})
+
+	readyz := func(want int) health.Report {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != want {
+			t.Fatalf("/readyz status = %d, want %d: %s", rec.Code, want, rec.Body)
+		}
+		var report health.Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	report := readyz(http.StatusOK)
+	if report.Checks["postgres"].Details["migration_version"] != float64(13) {
+		t.Errorf("postgres details missing: %+v", report.Checks["postgres"])
+	}
+
+	redisErr = redisDown
+	report = readyz(http.StatusServiceUnavailable)
+	if report.Checks["redis"].Status != health.StatusFail || report.Checks["redis"].Error != redisDown.Error() ||
+		report.Checks["postgres"].Status != health.StatusOK {
+		t.Errorf("unexpected breakdown: %+v", report.Checks)
+	}
+
+	redisErr = nil
+	checker.SetShuttingDown()
+	if report = readyz(http.StatusServiceUnavailable); !report.ShuttingDown {
+		t.Errorf("report does not say shutting down: %+v", report)
+	}
+
+	// живость от зависимостей и остановки не зависит
+	rec := httptest.NewRecorder()
+	h.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz status = %d", rec.Code)
+	}
+}
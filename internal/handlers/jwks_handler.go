@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/respond"
+	"WalletX/pkg/utils"
+	"net/http"
+	"time"
+)
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys for verifying WalletX access tokens. Includes keys still inside the rotation overlap window and keys scheduled to become active.
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} models.JWKS
+// @Router       /.well-known/jwks.json [get]
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	set := models.JWKS{Keys: []models.JWK{}}
+	if ring := utils.ActiveKeyRing(); ring != nil {
+		set = ring.JWKS(time.Now())
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respond.JSON(w, http.StatusOK, set)
+}
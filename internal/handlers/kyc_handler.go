@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// kycFormOverhead — запас на текстовые поля и границы multipart сверх размера файлов
+const kycFormOverhead = 1 << 20
+
+var kycDocumentFields = []string{models.KYCDocPassportFront, models.KYCDocPassportBack, models.KYCDocSelfie}
+
+type KYCHandler struct {
+	Service *service.KYCService
+}
+
+func NewKYCHandler(s *service.KYCService) *KYCHandler {
+	return &KYCHandler{Service: s}
+}
+
+// SubmitKYC godoc
+// @Summary      Submit KYC application
+// @Description  Uploads passport data, passport scans and a selfie for manual review. passport_front and selfie are required; passport scans may be JPEG, PNG or PDF, the selfie JPEG or PNG. The user stays unverified until the application is approved.
+// @Tags         KYC
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        first_name      formData string true  "First name"
+// @Param        last_name       formData string true  "Last name"
+// @Param        middle_name     formData string true  "Middle name"
+// @Param        passport_number formData string true  "Passport number"
+// @Param        passport_front  formData file   true  "Passport, front side"
+// @Param        passport_back   formData file   false "Passport, back side"
+// @Param        selfie          formData file   true  "Selfie holding the passport"
+// @Success      201 {object} models.KYCApplication
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      409 {object} models.ErrorResponse
+// @Failure      413 {object} models.ErrorResponse
+// @Router       /api/kyc [post]
+func (h *KYCHandler) SubmitKYC(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	maxFile := service.KYCMaxFileSize()
+	r.Body = http.MaxBytesReader(w, r.Body, int64(len(kycDocumentFields))*maxFile+kycFormOverhead)
+	if err := r.ParseMultipartForm(maxFile); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respond.Error(w, http.StatusRequestEntityTooLarge, "request is too large", err)
+			return
+		}
+		respond.Error(w, http.StatusBadRequest, "invalid multipart form", err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	sub := models.KYCSubmission{
+		FirstName:      r.FormValue("first_name"),
+		LastName:       r.FormValue("last_name"),
+		MiddleName:     r.FormValue("middle_name"),
+		PassportNumber: r.FormValue("passport_number"),
+	}
+	for _, kind := range kycDocumentFields {
+		file, _, err := r.FormFile(kind)
+		if errors.Is(err, http.ErrMissingFile) {
+			continue
+		}
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "invalid multipart form", err)
+			return
+		}
+		content, err := io.ReadAll(io.LimitReader(file, maxFile+1))
+		file.Close()
+		if err != nil {
+			respond.Error(w, http.StatusBadRequest, "invalid multipart form", err)
+			return
+		}
+		sub.Files = append(sub.Files, models.KYCUpload{Kind: kind, Content: content})
+	}
+
+	app, err := h.Service.Submit(r.Context(), userID, sub)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, app)
+}
+
+// GetKYCStatus godoc
+// @Summary      Get KYC status
+// @Description  Returns the verification tier and the status of the latest application: not_started, pending, approved or rejected with a reason.
+// @Tags         KYC
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.KYCStatusResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/kyc [get]
+func (h *KYCHandler) GetKYCStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	status, err := h.Service.Status(r.Context(), userID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, status)
+}
+
+// ListKYCQueue godoc
+// @Summary      KYC review queue
+// @Description  Returns pending applications, oldest first. Requires kyc:review.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {array} models.KYCApplication
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Router       /api/admin/kyc [get]
+func (h *KYCHandler) ListKYCQueue(w http.ResponseWriter, r *http.Request) {
+	apps, err := h.Service.Queue(r.Context())
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, apps)
+}
+
+// GetKYCApplication godoc
+// @Summary      Get KYC application
+// @Description  Returns the application with its document list. Requires kyc:review.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Application ID"
+// @Success      200 {object} models.KYCApplication
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/kyc/{id} [get]
+func (h *KYCHandler) GetKYCApplication(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid application id", err)
+		return
+	}
+
+	app, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, app)
+}
+
+// GetKYCDocument godoc
+// @Summary      Download KYC document
+// @Description  Streams an uploaded document of the application. Requires kyc:review.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      image/jpeg,image/png,application/pdf
+// @Param        id    path int true "Application ID"
+// @Param        docID path int true "Document ID"
+// @Success      200 {file} file
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/admin/kyc/{id}/documents/{docID} [get]
+func (h *KYCHandler) GetKYCDocument(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid application id", err)
+		return
+	}
+	docID, err := strconv.Atoi(mux.Vars(r)["docID"])
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid document id", err)
+		return
+	}
+
+	doc, file, err := h.Service.OpenDocument(r.Context(), id, docID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(doc.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, doc.Kind))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, file); err != nil {
+		logger.Error.Printf("[KYCHandler] Failed to stream document %d: %v", doc.ID, err)
+	}
+}
+
+// ApproveKYC godoc
+// @Summary      Approve KYC application
+// @Description  Copies passport data to the user profile and raises the verification tier. Requires kyc:review.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Application ID"
+// @Success      200 {object} models.KYCApplication
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Failure      409 {object} models.ErrorResponse
+// @Router       /api/admin/kyc/{id}/approve [post]
+func (h *KYCHandler) ApproveKYC(w http.ResponseWriter, r *http.Request) {
+	reviewerID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	id, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid application id", err)
+		return
+	}
+
+	app, err := h.Service.Approve(r.Context(), reviewerID, id)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, app)
+}
+
+// RejectKYC godoc
+// @Summary      Reject KYC application
+// @Description  Rejects the application with a reason shown to the user; the user may submit a new one. Requires kyc:review.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Application ID"
+// @Param        request body models.KYCRejectRequest true "Reason"
+// @Success      200 {object} models.KYCApplication
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Failure      409 {object} models.ErrorResponse
+// @Router       /api/admin/kyc/{id}/reject [post]
+func (h *KYCHandler) RejectKYC(w http.ResponseWriter, r *http.Request) {
+	reviewerID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	id, err := pathID(r)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid application id", err)
+		return
+	}
+
+	var req models.KYCRejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	app, err := h.Service.Reject(r.Context(), reviewerID, id, req.Reason)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, app)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeKYCRepo struct {
+	apps  []models.KYCApplication
+	tiers map[int]int
+}
+
+func (r *fakeKYCRepo) Create(ctx context.Context, app models.KYCApplication) (models.KYCApplication, error) {
+	app.ID = len(r.apps) + 1
+	app.Status = models.KYCStatusPending
+	app.SubmittedAt = time.Now()
+	r.apps = append(r.apps, app)
+	return app, nil
+}
+func (r *fakeKYCRepo) Latest(ctx context.Context, userID int) (models.KYCApplication, error) {
+	for i := len(r.apps) - 1; i >= 0; i-- {
+		if r.apps[i].UserID == userID {
+			return r.apps[i], nil
+		}
+	}
+	return models.KYCApplication{}, errs.ErrKYCNotFound
+}
+func (r *fakeKYCRepo) Get(ctx context.Context, id int) (models.KYCApplication, error) {
+	if id < 1 || id > len(r.apps) {
+		return models.KYCApplication{}, errs.ErrKYCNotFound
+	}
+	return r.apps[id-1], nil
+}
+func (r *fakeKYCRepo) ListPending(ctx context.Context, limit int) ([]models.KYCApplication, error) {
+	return nil, nil
+}
+func (r *fakeKYCRepo) GetDocument(ctx context.Context, applicationID, documentID int) (models.KYCDocument, error) {
+	return models.KYCDocument{}, errs.ErrKYCNotFound
+}
+func (r *fakeKYCRepo) Approve(ctx context.Context, id, reviewerID int) (models.KYCApplication, error) {
+	app, err := r.Get(ctx, id)
+	if err != nil {
+		return app, err
+	}
+	if app.Status != models.KYCStatusPending {
+		return models.KYCApplication{}, errs.ErrKYCNotPending
+	}
+	app.Status = models.KYCStatusApproved
+	app.ReviewerID = &reviewerID
+	r.apps[id-1] = app
+	r.tiers[app.UserID] = models.TierVerified
+	return app, nil
+}
+func (r *fakeKYCRepo) Reject(ctx context.Context, id, reviewerID int, reason string) (models.KYCApplication, error) {
+	return models.KYCApplication{}, errs.ErrKYCNotPending
+}
+func (r *fakeKYCRepo) GetTier(ctx context.Context, userID int) (int, error) {
+	return r.tiers[userID], nil
+}
+
+type memoryStore struct {
+	files map[string][]byte
+}
+
+func (s *memoryStore) Save(ctx context.Context, key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.files[key] = data
+	return nil
+}
+func (s *memoryStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s.files[key])), nil
+}
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	delete(s.files, key)
+	return nil
+}
+
+// kycForm собирает multipart-заявку KYC: поля формы и файлы по видам документов
+func kycForm(t *testing.T, fields map[string]string, files map[string][]byte) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for kind, content := range files {
+		part, err := form.CreateFormFile(kind, kind+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body, form.FormDataContentType()
+}
+
+func (e *authTestEnv) submitKYC(t *testing.T, bearer string, files map[string][]byte) *httptest.ResponseRecorder {
+	t.Helper()
+	body, contentType := kycForm(t, map[string]string{
+		"user_id":         "2",
+		"first_name":      "Ali",
+		"last_name":       "Valiev",
+		"middle_name":     "Bobo",
+		"passport_number": "a1234567",
+	}, files)
+	req := httptest.NewRequest(http.MethodPost, "/api/kyc", body)
+	req.Header.Set("Content-Type", contentType)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+var (
+	testJPEG = append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, []byte("jpeg image")...)
+	testPDF  = []byte("%PDF-1.4\n%test document")
+)
+
+func TestSubmitKYCRequiresAuthentication(t *testing.T) {
+	env := newAuthTestEnv(2)
+
+	rec := env.submitKYC(t, "", map[string][]byte{
+		models.KYCDocPassportFront: testJPEG,
+		models.KYCDocSelfie:        testJPEG,
+	})
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+	if len(env.kyc.apps) != 0 || len(env.files.files) != 0 {
+		t.Fatalf("application was stored without authentication: %v", env.kyc.apps)
+	}
+}
+
+func TestSubmitKYCVerifiesOnlyAfterApproval(t *testing.T) {
+	env := newAuthTestEnv(2, 7, 9)
+	env.users.SetRole(9, models.RoleSupport)
+
+	user, err := env.tokens.IssuePair(context.Background(), 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := env.submitKYC(t, user.Token, map[string][]byte{
+		models.KYCDocPassportFront: testPDF,
+		models.KYCDocSelfie:        testPDF,
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("pdf selfie: status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	if len(env.files.files) != 0 {
+		t.Fatalf("files of a rejected submission were stored: %d", len(env.files.files))
+	}
+
+	rec = env.submitKYC(t, user.Token, map[string][]byte{
+		models.KYCDocPassportFront: testPDF,
+		models.KYCDocSelfie:        testJPEG,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if len(env.kyc.apps) != 1 || env.kyc.apps[0].UserID != 7 {
+		t.Fatalf("application was not created for the token owner: %+v", env.kyc.apps)
+	}
+	if env.kyc.apps[0].PassportNumber != "A1234567" || len(env.kyc.apps[0].Documents) != 2 {
+		t.Fatalf("unexpected application: %+v", env.kyc.apps[0])
+	}
+	if len(env.files.files) != 2 {
+		t.Fatalf("stored files = %d, want 2", len(env.files.files))
+	}
+
+	rec = env.submitKYC(t, user.Token, map[string][]byte{
+		models.KYCDocPassportFront: testJPEG,
+		models.KYCDocSelfie:        testJPEG,
+	})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second application: status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	var status models.KYCStatusResponse
+	rec = env.do(t, http.MethodGet, "/api/kyc", user.Token, nil)
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.KYCStatusPending || status.Tier != models.TierBasic {
+		t.Fatalf("status before review = %+v, want pending with basic tier", status)
+	}
+
+	rec = env.do(t, http.MethodPost, "/api/admin/kyc/1/approve", user.Token, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("user approving own application: status = %d, want 403", rec.Code)
+	}
+
+	support, err := env.tokens.IssuePair(context.Background(), 9, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = env.do(t, http.MethodPost, "/api/admin/kyc/1/approve", support.Token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("support approve: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	rec = env.do(t, http.MethodGet, "/api/kyc", user.Token, nil)
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.KYCStatusApproved || status.Tier != models.TierVerified {
+		t.Fatalf("status after approval = %+v, want approved with verified tier", status)
+	}
+	if env.kyc.tiers[2] != models.TierBasic {
+		t.Fatal("tier of the user from the form was changed")
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"WalletX/models"
+	"WalletX/pkg/logger"
 	"WalletX/pkg/utils"
 	"context"
 	"encoding/json"
@@ -14,44 +16,121 @@ const (
 
 type ContextKey string
 
-const UserIDCtx ContextKey = "userID"
+const (
+	UserIDCtx ContextKey = "userID"
+	ClaimsCtx ContextKey = "claims"
+)
+
+// RevocationChecker проверяет, не отозван ли токен (выход из системы, «выйти везде»)
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *utils.CustomClaims) (bool, error)
+}
+
+type Auth struct {
+	Revocation RevocationChecker
+}
+
+func NewAuth(revocation RevocationChecker) *Auth {
+	return &Auth{Revocation: revocation}
+}
 
 func writeJSONError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]string{
 		"error": message,
 	})
 }
 
-func CheckUserAuthentication(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// authenticate проверяет Bearer-токен и отзыв. При ошибке ответ уже записан и возвращается false.
+func (a *Auth) authenticate(w http.ResponseWriter, r *http.Request) (*utils.CustomClaims, bool) {
+	header := r.Header.Get(authorizationHeader)
+	if header == "" {
+		writeJSONError(w, "empty auth header", http.StatusUnauthorized)
+		return nil, false
+	}
 
-		header := r.Header.Get(authorizationHeader)
-		if header == "" {
-			writeJSONError(w, "empty auth header", http.StatusUnauthorized)
-			return
-		}
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		writeJSONError(w, "invalid auth header", http.StatusUnauthorized)
+		return nil, false
+	}
 
-		parts := strings.Split(header, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			writeJSONError(w, "invalid auth header", http.StatusUnauthorized)
-			return
-		}
+	token := parts[1]
+	if len(token) == 0 {
+		writeJSONError(w, "token is empty", http.StatusUnauthorized)
+		return nil, false
+	}
 
-		token := parts[1]
-		if len(token) == 0 {
-			writeJSONError(w, "token is empty", http.StatusUnauthorized)
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	revoked, err := a.Revocation.IsRevoked(r.Context(), claims)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("[AuthMiddleware] Failed to check token revocation", "user_id", claims.UserID, "error", err)
+		writeJSONError(w, "cannot verify token", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if revoked {
+		writeJSONError(w, "token has been revoked", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return claims, true
+}
+
+func withClaims(r *http.Request, claims *utils.CustomClaims) *http.Request {
+	ctx := context.WithValue(r.Context(), UserIDCtx, claims.UserID)
+	ctx = context.WithValue(ctx, ClaimsCtx, claims)
+	setAccessUser(ctx, claims.UserID, claims.ClientID)
+	if claims.ClientID != "" {
+		ctx = logger.With(ctx, "user_id", claims.UserID, "client_id", claims.ClientID)
+	} else {
+		ctx = logger.With(ctx, "user_id", claims.UserID)
+	}
+	return r.WithContext(ctx)
+}
+
+// CheckUserAuthentication пропускает только токены собственных приложений кошелька.
+// Токены партнерских приложений (OAuth) принимаются лишь маршрутами с RequireScope.
+func (a *Auth) CheckUserAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := a.authenticate(w, r)
+		if !ok {
 			return
 		}
-
-		claims, err := utils.ParseToken(token)
-		if err != nil {
-			writeJSONError(w, err.Error(), http.StatusUnauthorized)
+		if claims.ClientID != "" {
+			writeJSONError(w, "token of a third-party application is not accepted here", http.StatusForbidden)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), UserIDCtx, claims.UserID)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, withClaims(r, claims))
 	})
 }
+
+// RequireScope пропускает токены собственных приложений и токены партнерских приложений с областью scope.
+// Для областей с данными пользователя нужен токен, выданный от имени пользователя, а не client_credentials.
+func (a *Auth) RequireScope(scope string) func(http.Handler) http.Handler {
+	info, _ := models.LookupScope(scope)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := a.authenticate(w, r)
+			if !ok {
+				return
+			}
+			if claims.ClientID != "" {
+				if !models.HasScope(claims.Scope, scope) || (info.UserData && claims.UserID == 0) {
+					logger.FromContext(r.Context()).Warn("[AuthMiddleware] Client lacks scope", "client_id", claims.ClientID, "scope", scope)
+					w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+					writeJSONError(w, "insufficient scope", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, withClaims(r, claims))
+		})
+	}
+}
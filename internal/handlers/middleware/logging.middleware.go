@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"WalletX/pkg/logger"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// accessEntry накапливает сведения о запросе, которые становятся известны глубже по цепочке
+type accessEntry struct {
+	userID   int
+	clientID string
+}
+
+type accessEntryKey struct{}
+
+// setAccessUser сообщает журналу доступа, чей это запрос
+func setAccessUser(ctx context.Context, userID int, clientID string) {
+	if entry, ok := ctx.Value(accessEntryKey{}).(*accessEntry); ok {
+		entry.userID, entry.clientID = userID, clientID
+	}
+}
+
+// unmatchedRoute — метка маршрута для запросов, не совпавших ни с одним маршрутом (404, 405).
+// Путь таких запросов в метки метрик и имена спанов не попадает: иначе каждый случайный URL
+// создавал бы новую серию.
+const unmatchedRoute = "unmatched"
+
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return unmatchedRoute
+}
+
+// RequestLogger кладет в контекст запроса логгер с request_id, методом и шаблоном маршрута.
+// Дальше по цепочке его достают через logger.FromContext; авторизация добавляет user_id.
+// Внутри Tracing в логгер попадают и trace_id/span_id, чтобы от записи лога перейти к трассе.
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		args := []any{
+			"request_id", RequestIDFrom(r.Context()),
+			"method", r.Method,
+			"route", route,
+		}
+		if route == unmatchedRoute {
+			args = append(args, "path", r.URL.Path)
+		}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			args = append(args, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		}
+		next.ServeHTTP(w, r.WithContext(logger.With(r.Context(), args...)))
+	})
+}
+
+// AccessLog пишет по строке на запрос: код ответа, размер тела и время обработки.
+// Ответы 5xx пишутся уровнем ERROR, 4xx — WARN.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newResponseRecorder(w)
+		entry := &accessEntry{}
+
+		// запись делается и тогда, когда Recover оборвал ответ повторной паникой
+		defer func() {
+			logAccess(r, rec, entry, time.Since(start))
+		}()
+
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), accessEntryKey{}, entry)))
+	})
+}
+
+func logAccess(r *http.Request, rec *responseRecorder, entry *accessEntry, duration time.Duration) {
+	level := slog.LevelInfo
+	switch {
+	case rec.status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case rec.status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("status", rec.status),
+		slog.Int64("bytes", rec.bytes),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("user_agent", r.UserAgent()),
+	}
+	if entry.userID != 0 {
+		attrs = append(attrs, slog.Int("user_id", entry.userID))
+	}
+	if entry.clientID != "" {
+		attrs = append(attrs, slog.String("client_id", entry.clientID))
+	}
+	logger.FromContext(r.Context()).LogAttrs(r.Context(), level, "[AccessLog] Request completed", attrs...)
+}
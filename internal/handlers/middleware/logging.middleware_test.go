@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"WalletX/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve прогоняет запрос через общую цепочку маршрутизатора и возвращает ответ и JSON-записи лога
+func serve(t *testing.T, h http.Handler, req *http.Request) (*httptest.ResponseRecorder, []map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	req = req.WithContext(logger.WithContext(req.Context(), slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	w := httptest.NewRecorder()
+	RequestID(Tracing(RequestLogger(AccessLog(Recover(h))))).ServeHTTP(w, req)
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		entries = append(entries, entry)
+	}
+	return w, entries
+}
+
+func TestRequestIDIsGeneratedAndEchoed(t *testing.T) {
+	var seen string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+		w.Write([]byte("ok"))
+	})
+
+	w, entries := serve(t, h, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if len(seen) != 32 || w.Header().Get(RequestIDHeader) != seen {
+		t.Fatalf("request id = %q, header = %q", seen, w.Header().Get(RequestIDHeader))
+	}
+
+	access := entries[len(entries)-1]
+	if access["request_id"] != seen || access["status"] != float64(200) || access["bytes"] != float64(2) {
+		t.Errorf("unexpected access log entry: %v", access)
+	}
+	if _, ok := access["duration_ms"]; !ok {
+		t.Errorf("access log has no duration: %v", access)
+	}
+}
+
+func TestRequestIDFromClientIsPropagated(t *testing.T) {
+	for id, keep := range map[string]bool{
+		"req-42":                    true,
+		"bad id\nINFO forged entry": false,
+		strings.Repeat("a", 129):    false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(RequestIDHeader, id)
+
+		w, _ := serve(t, http.NotFoundHandler(), req)
+		if got := w.Header().Get(RequestIDHeader); (got == id) != keep || got == "" {
+			t.Errorf("X-Request-ID %q -> %q", id, got)
+		}
+	}
+}
+
+func TestRecoverReturnsJSONError(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w, entries := serve(t, h, httptest.NewRequest(http.MethodPost, "/api/transfer", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] != "internal error" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+	if _, ok := body["details"]; ok {
+		t.Errorf("details must be omitted: %v", body)
+	}
+
+	if len(entries) != 2 || entries[0]["panic"] != "boom" || entries[0]["stack"] == "" {
+		t.Fatalf("panic is not logged: %v", entries)
+	}
+	if entries[1]["status"] != float64(500) || entries[1]["level"] != "ERROR" {
+		t.Errorf("unexpected access log entry: %v", entries[1])
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"WalletX/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics считает запросы и их длительность по шаблону маршрута, а не по пути:
+// /api/receipts/{number} — одна серия, а не серия на каждый чек
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newResponseRecorder(w)
+		route := routeTemplate(r)
+
+		defer func() {
+			status := strconv.Itoa(rec.status)
+			metrics.HTTPRequests.WithLabelValues(r.Method, route, status).Inc()
+			metrics.HTTPDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
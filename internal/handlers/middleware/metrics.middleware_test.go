@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"WalletX/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsUseRouteTemplate(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(Metrics, Recover)
+	r.HandleFunc("/api/receipts/{number}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["number"] == "broken" {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	for _, number := range []string{"R-1", "R-2", "broken"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/receipts/"+number, nil))
+	}
+
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/api/receipts/{number}", "404")); got != 2 {
+		t.Errorf("404 requests = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/api/receipts/{number}", "500")); got != 1 {
+		t.Errorf("500 requests = %v, want 1", got)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`walletx_http_requests_total{method="GET",route="/api/receipts/{number}",status="404"} 2`,
+		`walletx_http_request_duration_seconds_bucket{method="GET",route="/api/receipts/{number}",status="500",le="+Inf"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics has no %s", want)
+		}
+	}
+	if strings.Contains(body, "R-1") {
+		t.Error("raw path leaked into labels")
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"net/http"
+)
+
+// RequirePermission пропускает запрос, только если роль из access-токена дает разрешение permission.
+// Должен стоять после CheckUserAuthentication.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(ClaimsCtx).(*utils.CustomClaims)
+			if !ok {
+				writeJSONError(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !models.HasPermission(claims.Role, permission) {
+				logger.FromContext(r.Context()).Warn("[PermissionMiddleware] Permission denied",
+					"role", models.NormalizeRole(claims.Role), "permission", permission)
+				writeJSONError(w, "insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover перехватывает панику обработчика: пишет ее со стеком в лог и отвечает 500 с обычным телом ошибки,
+// если ответ еще не начат. http.ErrAbortHandler пробрасывается дальше — им обработчик сам прерывает ответ.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := newResponseRecorder(w)
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			logger.FromContext(r.Context()).Error("[RecoverMiddleware] Panic in handler",
+				"panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+
+			if rec.wroteHeader {
+				// часть ответа уже ушла клиенту — остается только оборвать соединение
+				panic(http.ErrAbortHandler)
+			}
+			respond.Error(rec, http.StatusInternalServerError, errs.ErrInternal.Error(), nil)
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
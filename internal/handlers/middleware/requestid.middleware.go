@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const RequestIDCtx ContextKey = "requestID"
+
+// validRequestID отсекает слишком длинные идентификаторы и идентификаторы с пробелами и управляющими
+// символами, чтобы клиент не мог подделать строки в логах
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestID берет идентификатор запроса из X-Request-ID (его передают клиент или балансировщик)
+// или создает новый, кладет в контекст и возвращает в заголовке ответа
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), RequestIDCtx, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFrom возвращает идентификатор текущего запроса, например для передачи во внешние сервисы
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDCtx).(string)
+	return id
+}
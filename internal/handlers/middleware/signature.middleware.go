@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	signatureNonceHeader     = "X-Signature-Nonce"
+
+	minNonceLength      = 16
+	maxNonceLength      = 128
+	maxSignedBodyBytes  = 1 << 20
+	defaultMaxClockSkew = 5 * time.Minute
+)
+
+// DeviceKeyProvider возвращает открытый ключ устройства или пустую строку, если ключа нет
+type DeviceKeyProvider interface {
+	PublicKey(ctx context.Context, deviceID int) (string, error)
+}
+
+// NonceStore запоминает использованные nonce; false — nonce уже был
+type NonceStore interface {
+	Remember(ctx context.Context, deviceID int, nonce string, ttl time.Duration) (bool, error)
+}
+
+// RequestSignature проверяет подпись запросов ключом устройства. Подписываются метод, путь,
+// время, nonce и хеш тела (utils.RequestSigningPayload). Запрос с временем вне MaxClockSkew
+// или с уже встречавшимся nonce отклоняется. Ставится после CheckUserAuthentication.
+type RequestSignature struct {
+	Keys         DeviceKeyProvider
+	Nonces       NonceStore
+	Required     bool
+	MaxClockSkew time.Duration
+}
+
+func NewRequestSignature(keys DeviceKeyProvider, nonces NonceStore, required bool, maxClockSkew time.Duration) *RequestSignature {
+	if maxClockSkew <= 0 {
+		maxClockSkew = defaultMaxClockSkew
+	}
+	return &RequestSignature{Keys: keys, Nonces: nonces, Required: required, MaxClockSkew: maxClockSkew}
+}
+
+// VerifyRequestSignature требует подпись от устройств с зарегистрированным ключом,
+// а при Required — от всех устройств
+func (s *RequestSignature) VerifyRequestSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(ClaimsCtx).(*utils.CustomClaims)
+		if !ok {
+			writeJSONError(w, "user not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		publicKey := ""
+		if claims.DeviceID != 0 {
+			key, err := s.Keys.PublicKey(r.Context(), claims.DeviceID)
+			if err != nil {
+				logger.FromContext(r.Context()).Error("[SignatureMiddleware] Failed to load device key", "device_id", claims.DeviceID, "error", err)
+				writeJSONError(w, "cannot verify signature", http.StatusServiceUnavailable)
+				return
+			}
+			publicKey = key
+		}
+		if publicKey == "" {
+			if s.Required {
+				writeJSONError(w, "request signing key is not registered for this device", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		signature := r.Header.Get(signatureHeader)
+		timestamp := r.Header.Get(signatureTimestampHeader)
+		nonce := r.Header.Get(signatureNonceHeader)
+		if signature == "" || timestamp == "" || nonce == "" {
+			writeJSONError(w, "request signature required", http.StatusUnauthorized)
+			return
+		}
+		if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+			writeJSONError(w, "invalid signature nonce", http.StatusUnauthorized)
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			writeJSONError(w, "invalid signature timestamp", http.StatusUnauthorized)
+			return
+		}
+		if skew := time.Since(time.Unix(unix, 0)); skew > s.MaxClockSkew || skew < -s.MaxClockSkew {
+			writeJSONError(w, "signature timestamp out of range", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+		if err != nil || len(body) > maxSignedBodyBytes {
+			writeJSONError(w, "cannot read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		payload := utils.RequestSigningPayload(r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+		if !utils.VerifyDeviceSignature(publicKey, payload, signature) {
+			logger.FromContext(r.Context()).Warn("[SignatureMiddleware] Invalid request signature", "device_id", claims.DeviceID)
+			writeJSONError(w, "invalid request signature", http.StatusUnauthorized)
+			return
+		}
+
+		// nonce запоминается на все окно допустимого времени, после него запрос отклонит проверка времени
+		fresh, err := s.Nonces.Remember(r.Context(), claims.DeviceID, nonce, 2*s.MaxClockSkew)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("[SignatureMiddleware] Failed to store nonce", "device_id", claims.DeviceID, "error", err)
+			writeJSONError(w, "cannot verify signature", http.StatusServiceUnavailable)
+			return
+		}
+		if !fresh {
+			logger.FromContext(r.Context()).Warn("[SignatureMiddleware] Replayed request", "device_id", claims.DeviceID)
+			writeJSONError(w, "replayed request", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
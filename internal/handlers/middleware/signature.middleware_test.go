@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	discard := log.New(io.Discard, "", 0)
+	logger.Info, logger.Warn, logger.Error, logger.Debug = discard, discard, discard, discard
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type staticKeys map[int]string
+
+func (k staticKeys) PublicKey(ctx context.Context, deviceID int) (string, error) {
+	return k[deviceID], nil
+}
+
+type memoryNonces map[string]bool
+
+func (n memoryNonces) Remember(ctx context.Context, deviceID int, nonce string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("%d:%s", deviceID, nonce)
+	if n[key] {
+		return false, nil
+	}
+	n[key] = true
+	return true, nil
+}
+
+func TestVerifyRequestSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := staticKeys{1: base64.StdEncoding.EncodeToString(public)}
+	sig := NewRequestSignature(keys, memoryNonces{}, false, time.Minute)
+
+	var gotBody string
+	handler := sig.VerifyRequestSignature(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := []byte(`{"to_phone":"+992931753756","amount":50}`)
+	request := func(deviceID int, ts time.Time, nonce string, sign bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/transfer", bytes.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), ClaimsCtx, &utils.CustomClaims{UserID: 7, DeviceID: deviceID}))
+		if sign {
+			timestamp := strconv.FormatInt(ts.Unix(), 10)
+			payload := utils.RequestSigningPayload(http.MethodPost, "/api/transfer", timestamp, nonce, body)
+			req.Header.Set(signatureHeader, base64.StdEncoding.EncodeToString(ed25519.Sign(private, payload)))
+			req.Header.Set(signatureTimestampHeader, timestamp)
+			req.Header.Set(signatureNonceHeader, nonce)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(1, time.Now(), "nonce-0000000001", true); rec.Code != http.StatusOK {
+		t.Fatalf("signed request: status = %d: %s", rec.Code, rec.Body)
+	}
+	if gotBody != string(body) {
+		t.Fatalf("body was not passed to the handler: %q", gotBody)
+	}
+	if rec := request(1, time.Now(), "nonce-0000000001", true); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed nonce: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := request(1, time.Now().Add(-2*time.Minute), "nonce-0000000002", true); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("stale timestamp: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := request(1, time.Now(), "nonce-0000000003", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned request from device with key: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := request(2, time.Now(), "", false); rec.Code != http.StatusOK {
+		t.Fatalf("device without key: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	sig.Required = true
+	if rec := request(2, time.Now(), "", false); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("device without key when signing is required: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"WalletX/pkg/tracing"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing открывает серверный спан на запрос. Если клиент или шлюз прислал traceparent,
+// спан продолжает его трассу; trace_id попадает в логи запроса через RequestLogger.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := routeTemplate(r)
+
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("request.id", RequestIDFrom(r.Context())),
+			),
+		)
+		rec := newResponseRecorder(w)
+		defer func() {
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", rec.status),
+				attribute.Int64("http.response.body.size", rec.bytes),
+			)
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+			span.End()
+		}()
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
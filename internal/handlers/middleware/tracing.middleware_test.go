@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracingContinuesW3CTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parent  = "00f067aa0ba902b7"
+	)
+	var inner trace.SpanContext
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner = trace.SpanContextFromContext(r.Context())
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/transfer", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parent+"-01")
+	_, entries := serve(t, h, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.SpanContext.TraceID().String() != traceID || span.Parent.SpanID().String() != parent {
+		t.Errorf("span is not a child of traceparent: trace=%s parent=%s", span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+	if span.SpanKind != trace.SpanKindServer || span.Status.Code != codes.Error {
+		t.Errorf("kind = %v, status = %v", span.SpanKind, span.Status)
+	}
+	if inner.SpanID() != span.SpanContext.SpanID() {
+		t.Error("handler context does not carry the server span")
+	}
+	if entries[0]["trace_id"] != traceID {
+		t.Errorf("log entry has no trace_id: %v", entries[0])
+	}
+}
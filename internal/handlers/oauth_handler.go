@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/respond"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type OAuthHandler struct {
+	Service *service.OAuthService
+}
+
+func NewOAuthHandler(s *service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{Service: s}
+}
+
+// ConsentScreen godoc
+// @Summary      OAuth consent screen
+// @Description  Validates an authorization request of a partner application and returns what to show on the consent screen. Called by the wallet app on behalf of the signed-in user.
+// @Tags         OAuth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        response_type query string true "Must be code"
+// @Param        client_id query string true "Client ID"
+// @Param        redirect_uri query string true "Registered redirect URI"
+// @Param        scope query string true "Space-separated scopes" example(balance:read history:read)
+// @Param        state query string false "Opaque value returned to the client"
+// @Param        code_challenge query string true "PKCE challenge: BASE64URL(SHA256(code_verifier))"
+// @Param        code_challenge_method query string true "Must be S256"
+// @Success      200 {object} models.OAuthConsentScreen
+// @Failure      400 {object} models.OAuthErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/oauth/authorize [get]
+func (h *OAuthHandler) ConsentScreen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	q := r.URL.Query()
+	req := models.OAuthAuthorizeRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}
+
+	screen, err := h.Service.ConsentScreen(r.Context(), userID, req)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, screen)
+}
+
+// Authorize godoc
+// @Summary      Approve or deny OAuth access
+// @Description  Records the user's decision. On approval the consent is saved and redirect_to carries a single-use authorization code (valid 5 minutes); on denial it carries error=access_denied.
+// @Tags         OAuth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body models.OAuthAuthorizeRequest true "Authorization request and decision"
+// @Success      200 {object} models.OAuthAuthorizeResponse
+// @Failure      400 {object} models.OAuthErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/oauth/authorize [post]
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.OAuthAuthorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	redirectTo, err := h.Service.Authorize(r.Context(), userID, req)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, models.OAuthAuthorizeResponse{RedirectTo: redirectTo})
+}
+
+// Token godoc
+// @Summary      OAuth token endpoint
+// @Description  Issues tokens to partner applications (RFC 6749). Grants: authorization_code (with PKCE code_verifier), refresh_token, client_credentials. Confidential clients authenticate with HTTP Basic or client_secret in the form.
+// @Tags         OAuth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type formData string true "Grant type" Enums(authorization_code, refresh_token, client_credentials)
+// @Param        code formData string false "Authorization code"
+// @Param        redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param        code_verifier formData string false "PKCE code verifier"
+// @Param        refresh_token formData string false "Refresh token"
+// @Param        scope formData string false "Requested scopes (client_credentials)"
+// @Param        client_id formData string false "Client ID (if not sent with HTTP Basic)"
+// @Param        client_secret formData string false "Client secret (if not sent with HTTP Basic)"
+// @Success      200 {object} models.OAuthTokenResponse
+// @Failure      400 {object} models.OAuthErrorResponse
+// @Failure      401 {object} models.OAuthErrorResponse
+// @Router       /api/oauth/token [post]
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		respond.HandleError(w, errs.NewOAuthError(errs.OAuthInvalidRequest, "malformed form body"))
+		return
+	}
+
+	req := models.OAuthTokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+	if id, secret, ok := r.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+
+	resp, err := h.Service.Token(r.Context(), req)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// ListConsents godoc
+// @Summary      List OAuth consents
+// @Description  Returns partner applications the user has granted access to
+// @Tags         OAuth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} models.OAuthConsent
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/oauth/consents [get]
+func (h *OAuthHandler) ListConsents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	consents, err := h.Service.ListConsents(r.Context(), userID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, consents)
+}
+
+// RevokeConsent godoc
+// @Summary      Revoke OAuth consent
+// @Description  Removes the consent and immediately invalidates all access and refresh tokens issued to the application on behalf of the user
+// @Tags         OAuth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        client_id path string true "Client ID"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      404 {object} models.ErrorResponse
+// @Router       /api/oauth/consents/{client_id} [delete]
+func (h *OAuthHandler) RevokeConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	if err := h.Service.RevokeConsent(r.Context(), userID, mux.Vars(r)["client_id"]); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "consent revoked"})
+}
+
+// CreateClient godoc
+// @Summary      Register OAuth client
+// @Description  Registers a partner application. The client secret of a confidential client is returned only once. Requires clients:manage.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body models.CreateOAuthClientRequest true "Client"
+// @Success      201 {object} models.CreateOAuthClientResponse
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Router       /api/admin/oauth/clients [post]
+func (h *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateOAuthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	resp, err := h.Service.CreateClient(r.Context(), req)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, resp)
+}
+
+// ListClients godoc
+// @Summary      List OAuth clients
+// @Description  Returns registered partner applications. Requires clients:manage.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {array} models.OAuthClient
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse
+// @Router       /api/admin/oauth/clients [get]
+func (h *OAuthHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.Service.ListClients(r.Context())
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, clients)
+}
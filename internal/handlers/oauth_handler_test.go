@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeOAuthRepo struct {
+	clients  map[string]models.OAuthClient
+	consents map[string][]string
+}
+
+func (r *fakeOAuthRepo) CreateClient(ctx context.Context, client models.OAuthClient) (models.OAuthClient, error) {
+	client.ID = len(r.clients) + 1
+	client.IsActive = true
+	client.Confidential = client.SecretHash != ""
+	r.clients[client.ClientID] = client
+	return client, nil
+}
+func (r *fakeOAuthRepo) GetClient(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	client, ok := r.clients[clientID]
+	if !ok {
+		return models.OAuthClient{}, errs.ErrClientNotFound
+	}
+	return client, nil
+}
+func (r *fakeOAuthRepo) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	return nil, nil
+}
+func (r *fakeOAuthRepo) SaveConsent(ctx context.Context, userID, clientRowID int, scopes []string) error {
+	key := fmt.Sprintf("%d:%d", userID, clientRowID)
+	r.consents[key] = append(r.consents[key], scopes...)
+	return nil
+}
+func (r *fakeOAuthRepo) GetConsent(ctx context.Context, userID, clientRowID int) ([]string, error) {
+	scopes, ok := r.consents[fmt.Sprintf("%d:%d", userID, clientRowID)]
+	if !ok {
+		return nil, errs.ErrConsentNotFound
+	}
+	return scopes, nil
+}
+func (r *fakeOAuthRepo) ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error) {
+	return nil, nil
+}
+func (r *fakeOAuthRepo) DeleteConsent(ctx context.Context, userID int, clientID string) error {
+	key := fmt.Sprintf("%d:%d", userID, r.clients[clientID].ID)
+	if _, ok := r.consents[key]; !ok {
+		return errs.ErrConsentNotFound
+	}
+	delete(r.consents, key)
+	return nil
+}
+
+type fakeOAuthCodeRepo struct {
+	codes map[string]models.OAuthAuthorizationCode
+}
+
+func (r *fakeOAuthCodeRepo) Save(ctx context.Context, codeHash string, code models.OAuthAuthorizationCode, ttl time.Duration) error {
+	r.codes[codeHash] = code
+	return nil
+}
+func (r *fakeOAuthCodeRepo) Consume(ctx context.Context, codeHash string) (models.OAuthAuthorizationCode, error) {
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return models.OAuthAuthorizationCode{}, errs.ErrInvalidOneTimeToken
+	}
+	delete(r.codes, codeHash)
+	return code, nil
+}
+
+func (e *authTestEnv) oauthToken(t *testing.T, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOAuthAuthorizationCodeWithPKCE(t *testing.T) {
+	env := newAuthTestEnv(2)
+	const redirectURI = "https://partner.example.com/callback"
+	client, err := env.oauth.CreateClient(context.Background(), models.CreateOAuthClientRequest{
+		Name:         "Partner",
+		RedirectURIs: []string{redirectURI},
+		Scopes:       []string{models.ScopeBalanceRead},
+		GrantTypes:   []string{models.GrantAuthorizationCode},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID := client.Client.ClientID
+
+	userTokens, err := env.tokens.IssuePair(context.Background(), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := strings.Repeat("v", 50)
+	sum := sha256.Sum256([]byte(verifier))
+	authorize := func() string {
+		t.Helper()
+		rec := env.do(t, http.MethodPost, "/api/oauth/authorize", userTokens.Token, map[string]interface{}{
+			"response_type":         "code",
+			"client_id":             clientID,
+			"redirect_uri":          redirectURI,
+			"scope":                 models.ScopeBalanceRead,
+			"state":                 "xyz",
+			"code_challenge":        base64.RawURLEncoding.EncodeToString(sum[:]),
+			"code_challenge_method": "S256",
+			"approve":               true,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("authorize: status = %d, body=%s", rec.Code, rec.Body.String())
+		}
+		var resp models.OAuthAuthorizeResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		u, err := url.Parse(resp.RedirectTo)
+		if err != nil || u.Query().Get("state") != "xyz" || u.Query().Get("code") == "" {
+			t.Fatalf("unexpected redirect %q", resp.RedirectTo)
+		}
+		return u.Query().Get("code")
+	}
+	exchange := func(code, codeVerifier string) *httptest.ResponseRecorder {
+		return env.oauthToken(t, url.Values{
+			"grant_type":    {models.GrantAuthorizationCode},
+			"client_id":     {clientID},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {codeVerifier},
+		})
+	}
+
+	code := authorize()
+	if rec := exchange(code, strings.Repeat("w", 50)); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_grant") {
+		t.Fatalf("wrong verifier: status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := exchange(code, verifier); rec.Code != http.StatusBadRequest {
+		t.Fatalf("reused code: status = %d, want 400", rec.Code)
+	}
+
+	rec := exchange(authorize(), verifier)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("exchange: status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var tokens models.OAuthTokenResponse
+	json.NewDecoder(rec.Body).Decode(&tokens)
+	claims, err := utils.ParseToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ClientID != clientID || claims.Scope != models.ScopeBalanceRead || claims.UserID != 2 {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	if rec := env.do(t, http.MethodGet, "/api/devices", tokens.AccessToken, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("partner token on first-party route: status = %d, want 403", rec.Code)
+	}
+
+	if rec := env.do(t, http.MethodDelete, "/api/oauth/consents/"+clientID, userTokens.Token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("revoke consent: status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	refresh := env.oauthToken(t, url.Values{
+		"grant_type":    {models.GrantRefreshToken},
+		"client_id":     {clientID},
+		"refresh_token": {tokens.RefreshToken},
+	})
+	if refresh.Code != http.StatusBadRequest {
+		t.Fatalf("refresh after revocation: status = %d, want 400", refresh.Code)
+	}
+}
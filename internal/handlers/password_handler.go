@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ForgotPassword godoc
+// @Summary      Forgot password
+// @Description  Sends an SMS code for password recovery. The response is the same whether or not the phone is registered. A code can be requested once a minute and up to 5 times an hour.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ForgotPasswordRequest true "Phone"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      429 {object} models.ErrorResponse
+// @Router       /api/users/password/forgot [post]
+func (h *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Service.RequestPasswordReset(r.Context(), req.Phone); err != nil {
+		logger.Error.Printf("Failed to send password reset code to %s: %v", req.Phone, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{
+		"message": "if the phone is registered, a reset code has been sent",
+	})
+}
+
+// VerifyResetCode godoc
+// @Summary      Verify password reset code
+// @Description  Exchanges the SMS code for a one-time reset token
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.VerifyResetCodeRequest true "Phone and SMS code"
+// @Success      200 {object} models.PasswordResetTokenResponse
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      429 {object} models.ErrorResponse
+// @Router       /api/users/password/verify [post]
+func (h *UserHandler) VerifyResetCode(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyResetCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	token, err := h.Service.VerifyPasswordReset(r.Context(), req.Phone, req.Code)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, token)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password using the one-time reset token. Removes a temporary lockout and ends all sessions.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/users/password/reset [post]
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Service.ResetPassword(r.Context(), req.ResetToken, req.Password); err != nil {
+		logger.Warn.Printf("Password reset failed: %v", err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "password has been reset, please log in"})
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Changes the password of the authenticated user. All sessions, including the current one, are ended.
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ChangePasswordRequest true "Current and new password"
+// @Param        X-Step-Up-Token header string false "Step-up confirmation token for action security"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse "step-up confirmation required"
+// @Router       /api/users/password/change [post]
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Service.ChangePassword(stepUpContext(r), userID, req.OldPassword, req.NewPassword); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "password changed, please log in again"})
+}
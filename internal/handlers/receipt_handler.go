@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"WalletX/config"
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/pdf"
+	"WalletX/pkg/respond"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type ReceiptHandler struct {
+	Service *service.ReceiptService
+}
+
+func NewReceiptHandler(s *service.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{Service: s}
+}
+
+// GetReceipt godoc
+// @Summary Get transaction receipt
+// @Description Returns the receipt of a completed transfer or payment. Available to the payer and the payee.
+// @Tags receipts
+// @Produce json
+// @Security BearerAuth
+// @Param number path string true "Receipt number" example(WX-20251102-00000042)
+// @Success 200 {object} models.Receipt
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 404 {object} models.ErrorResponse "receipt not found"
+// @Router /api/receipts/{number} [get]
+func (h *ReceiptHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	receipt, err := h.Service.GetByNumber(r.Context(), userID, mux.Vars(r)["number"])
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, receipt)
+}
+
+// GetReceiptPDF godoc
+// @Summary Download transaction receipt as PDF
+// @Tags receipts
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param number path string true "Receipt number" example(WX-20251102-00000042)
+// @Success 200 {file} file
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 404 {object} models.ErrorResponse "receipt not found"
+// @Router /api/receipts/{number}/pdf [get]
+func (h *ReceiptHandler) GetReceiptPDF(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	receipt, err := h.Service.GetByNumber(r.Context(), userID, mux.Vars(r)["number"])
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, receipt.ReceiptNumber))
+	if err := pdf.Receipt(w, config.AppSettings.AppParams.ServerName, receipt); err != nil {
+		logger.Error.Printf("[ReceiptHandler] Failed to render receipt %s: %v", receipt.ReceiptNumber, err)
+	}
+}
@@ -2,38 +2,157 @@ package handlers
 
 import (
 	"WalletX/internal/handlers/middleware"
+	"WalletX/models"
+	"WalletX/pkg/respond"
+	"net/http"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func RegisterRoutes(r *mux.Router, userHandler *UserHandler, servicesHandler *ServicesHandler, accountHandler *AccountHandler, userProfileHandler *UserProfileHandler, transferHandler *TransferHandler) {
+// Handlers — обработчики и middleware, из которых собираются маршруты. Обработчик, не нужный
+// вызывающему (например, в тестах), можно оставить nil, пока его маршруты не вызываются.
+type Handlers struct {
+	User        *UserHandler
+	Services    *ServicesHandler
+	Account     *AccountHandler
+	UserProfile *UserProfileHandler
+	Transfer    *TransferHandler
+	Template    *TemplateHandler
+	Receipt     *ReceiptHandler
+	Statement   *StatementHandler
+	Analytics   *AnalyticsHandler
+	Token       *TokenHandler
+	Device      *DeviceHandler
+	Admin       *AdminHandler
+	Security    *SecurityHandler
+	OAuth       *OAuthHandler
+	KYC         *KYCHandler
+	Health      *HealthHandler
+
+	Auth      *middleware.Auth
+	Signature *middleware.RequestSignature
+}
+
+func RegisterRoutes(r *mux.Router, h Handlers) {
+
+	// Порядок важен: идентификатор запроса и спан нужны логгеру, журнал доступа и метрики должны увидеть ответ 500 после паники
+	chain := []mux.MiddlewareFunc{middleware.RequestID, middleware.Tracing, middleware.RequestLogger, middleware.AccessLog, middleware.Metrics, middleware.Recover}
+	r.Use(chain...)
+	// 404 и 405 mux отдает в обход r.Use, поэтому они оборачиваются той же цепочкой вручную
+	r.NotFoundHandler = withChain(chain, http.HandlerFunc(notFound))
+	r.MethodNotAllowedHandler = withChain(chain, http.HandlerFunc(methodNotAllowed))
 
 	pingHandler := NewHandler()
 	r.HandleFunc("/ping", pingHandler.Ping).Methods("GET")
+	r.HandleFunc("/healthz", h.Health.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.Health.Readyz).Methods("GET")
+	r.HandleFunc("/.well-known/jwks.json", JWKS).Methods("GET")
 
 	api := r.PathPrefix("/api").Subrouter()
 
 	users := api.PathPrefix("/users").Subrouter()
 	{
-		users.HandleFunc("/signUp", userHandler.SignUp).Methods("POST")
-		users.HandleFunc("/set-password", userHandler.SetPassword).Methods("POST")
-		users.HandleFunc("/login", userHandler.Login).Methods("POST")
-		users.HandleFunc("/verify", userHandler.VerifyIdentity).Methods("POST")
+		users.HandleFunc("/signUp", h.User.SignUp).Methods("POST")
+		users.HandleFunc("/set-password", h.User.SetPassword).Methods("POST")
+		users.HandleFunc("/login", h.User.Login).Methods("POST")
+		users.HandleFunc("/login/2fa", h.User.LoginTwoFactor).Methods("POST")
+		users.HandleFunc("/login/device/challenge", h.User.DeviceLoginChallenge).Methods("POST")
+		users.HandleFunc("/login/device", h.User.LoginWithDeviceKey).Methods("POST")
+		users.HandleFunc("/unlock", h.User.RequestUnlock).Methods("POST")
+		users.HandleFunc("/unlock/confirm", h.User.ConfirmUnlock).Methods("POST")
+		users.HandleFunc("/password/forgot", h.User.ForgotPassword).Methods("POST")
+		users.HandleFunc("/password/verify", h.User.VerifyResetCode).Methods("POST")
+		users.HandleFunc("/password/reset", h.User.ResetPassword).Methods("POST")
 	}
 
-	services := api.PathPrefix("").Subrouter()
-	services.Use(middleware.CheckUserAuthentication)
-	services.HandleFunc("/services", servicesHandler.GetAllServices).Methods("GET")
+	api.HandleFunc("/auth/refresh", h.Token.Refresh).Methods("POST")
+	api.HandleFunc("/oauth/token", h.OAuth.Token).Methods("POST")
+
+	// Маршруты, доступные и партнерским приложениям с нужной областью доступа
+	scoped := api.PathPrefix("").Subrouter()
+	scope := func(name string, next http.Handler) http.Handler {
+		return h.Auth.RequireScope(name)(next)
+	}
+	scoped.Handle("/services", scope(models.ScopeServicesRead, http.HandlerFunc(h.Services.GetAllServices))).Methods("GET")
+	scoped.Handle("/users/balance", scope(models.ScopeBalanceRead, http.HandlerFunc(h.UserProfile.GetUserBalance))).Methods("GET")
+	scoped.Handle("/history", scope(models.ScopeHistoryRead, http.HandlerFunc(h.Transfer.TransactionHistory))).Methods("GET")
+	scoped.Handle("/pay", scope(models.ScopePaymentsWrite, h.Signature.VerifyRequestSignature(http.HandlerFunc(h.Account.PayForService)))).Methods("POST")
+
+	// Операции с деньгами: с устройств, у которых есть ключ, запрос должен быть подписан
+	signed := api.PathPrefix("").Subrouter()
+	signed.Use(h.Auth.CheckUserAuthentication, h.Signature.VerifyRequestSignature)
+	signed.HandleFunc("/transfer", h.Transfer.Transfer).Methods("POST")
+	signed.HandleFunc("/templates/{id:[0-9]+}/execute", h.Template.ExecuteTemplate).Methods("POST")
 
 	protected := api.PathPrefix("").Subrouter()
-	protected.Use(middleware.CheckUserAuthentication)
-	protected.HandleFunc("/users/profile", userProfileHandler.GetUserProfile).Methods("GET")
-	protected.HandleFunc("/users/balance", userProfileHandler.GetUserBalance).Methods("GET")
-	protected.HandleFunc("/transfer", transferHandler.Transfer).Methods("POST")
-	protected.HandleFunc("/pay", accountHandler.PayForService).Methods("POST")
-	protected.HandleFunc("/history", transferHandler.TransactionHistory).Methods("GET")
+	protected.Use(h.Auth.CheckUserAuthentication)
+	protected.HandleFunc("/auth/logout", h.Token.Logout).Methods("POST")
+	protected.HandleFunc("/auth/logout-all", h.Token.LogoutAll).Methods("POST")
+	protected.HandleFunc("/devices", h.Device.ListDevices).Methods("GET")
+	protected.HandleFunc("/devices/{id:[0-9]+}", h.Device.RevokeDevice).Methods("DELETE")
+	protected.HandleFunc("/devices/current/key", h.Device.RegisterDeviceKey).Methods("PUT")
+	protected.HandleFunc("/security/pin", h.Security.SetPIN).Methods("POST")
+	protected.HandleFunc("/security/step-up", h.Security.StartStepUp).Methods("POST")
+	protected.HandleFunc("/security/step-up/confirm", h.Security.ConfirmStepUp).Methods("POST")
+	protected.HandleFunc("/security/2fa/setup", h.Security.SetupTwoFactor).Methods("POST")
+	protected.HandleFunc("/security/2fa/enable", h.Security.EnableTwoFactor).Methods("POST")
+	protected.HandleFunc("/security/2fa/disable", h.Security.DisableTwoFactor).Methods("POST")
+	protected.HandleFunc("/security/2fa/recovery-codes", h.Security.RegenerateRecoveryCodes).Methods("POST")
+	protected.HandleFunc("/users/profile", h.UserProfile.GetUserProfile).Methods("GET")
+	protected.HandleFunc("/users/password/change", h.User.ChangePassword).Methods("POST")
+	protected.HandleFunc("/kyc", h.KYC.SubmitKYC).Methods("POST")
+	protected.HandleFunc("/kyc", h.KYC.GetKYCStatus).Methods("GET")
+	protected.HandleFunc("/statement", h.Statement.ExportStatement).Methods("GET")
+	protected.HandleFunc("/analytics/spending", h.Analytics.GetSpendingAnalytics).Methods("GET")
+	protected.HandleFunc("/templates", h.Template.ListTemplates).Methods("GET")
+	protected.HandleFunc("/templates", h.Template.CreateTemplate).Methods("POST")
+	protected.HandleFunc("/templates/{id:[0-9]+}", h.Template.DeleteTemplate).Methods("DELETE")
+	protected.HandleFunc("/recipients/recent", h.Template.RecentRecipients).Methods("GET")
+	protected.HandleFunc("/receipts/{number}", h.Receipt.GetReceipt).Methods("GET")
+	protected.HandleFunc("/receipts/{number}/pdf", h.Receipt.GetReceiptPDF).Methods("GET")
+	protected.HandleFunc("/oauth/authorize", h.OAuth.ConsentScreen).Methods("GET")
+	protected.HandleFunc("/oauth/authorize", h.OAuth.Authorize).Methods("POST")
+	protected.HandleFunc("/oauth/consents", h.OAuth.ListConsents).Methods("GET")
+	protected.HandleFunc("/oauth/consents/{client_id}", h.OAuth.RevokeConsent).Methods("DELETE")
+
+	// Административный API: доступ по роли из access-токена, разрешение проверяется на каждом маршруте
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(h.Auth.CheckUserAuthentication)
+	allow := func(permission string, next http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(permission)(next)
+	}
+	admin.Handle("/users", allow(models.PermUsersRead, h.Admin.FindUsers)).Methods("GET")
+	admin.Handle("/users/{id:[0-9]+}", allow(models.PermUsersRead, h.Admin.GetUser)).Methods("GET")
+	admin.Handle("/users/{id:[0-9]+}/unblock", allow(models.PermUsersUnblock, h.Admin.UnblockUser)).Methods("POST")
+	admin.Handle("/users/{id:[0-9]+}/role", allow(models.PermRolesManage, h.Admin.SetRole)).Methods("PUT")
+	admin.Handle("/accounts/{id:[0-9]+}/freeze", allow(models.PermAccountsFreeze, h.Admin.FreezeAccount)).Methods("POST")
+	admin.Handle("/accounts/{id:[0-9]+}/unfreeze", allow(models.PermAccountsFreeze, h.Admin.UnfreezeAccount)).Methods("POST")
+	admin.Handle("/transactions", allow(models.PermTransactionsRead, h.Admin.SearchTransactions)).Methods("GET")
+	admin.Handle("/oauth/clients", allow(models.PermClientsManage, h.OAuth.CreateClient)).Methods("POST")
+	admin.Handle("/oauth/clients", allow(models.PermClientsManage, h.OAuth.ListClients)).Methods("GET")
+	admin.Handle("/kyc", allow(models.PermKYCReview, h.KYC.ListKYCQueue)).Methods("GET")
+	admin.Handle("/kyc/{id:[0-9]+}", allow(models.PermKYCReview, h.KYC.GetKYCApplication)).Methods("GET")
+	admin.Handle("/kyc/{id:[0-9]+}/documents/{docID:[0-9]+}", allow(models.PermKYCReview, h.KYC.GetKYCDocument)).Methods("GET")
+	admin.Handle("/kyc/{id:[0-9]+}/approve", allow(models.PermKYCReview, h.KYC.ApproveKYC)).Methods("POST")
+	admin.Handle("/kyc/{id:[0-9]+}/reject", allow(models.PermKYCReview, h.KYC.RejectKYC)).Methods("POST")
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 	//http://localhost:8080/swagger/index.html
 }
+
+// withChain оборачивает h цепочкой middleware так же, как r.Use: первая в списке — внешняя
+func withChain(chain []mux.MiddlewareFunc, h http.Handler) http.Handler {
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+	return h
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	respond.Error(w, http.StatusNotFound, "not found", nil)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respond.Error(w, http.StatusMethodNotAllowed, "method not allowed", nil)
+}
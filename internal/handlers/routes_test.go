@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUnmatchedRequestsGoThroughMiddleware(t *testing.T) {
+	r := mux.NewRouter()
+	RegisterRoutes(r, Handlers{Auth: middleware.NewAuth(nil)})
+
+	cases := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/no-such-page/12345", http.StatusNotFound},
+		{http.MethodDelete, "/ping", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		counter := metrics.HTTPRequests.WithLabelValues(c.method, "unmatched", strconv.Itoa(c.status))
+		before := testutil.ToFloat64(counter)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+
+		if rec.Code != c.status {
+			t.Fatalf("%s %s: status = %d, want %d", c.method, c.path, rec.Code, c.status)
+		}
+		if rec.Header().Get(middleware.RequestIDHeader) == "" {
+			t.Errorf("%s %s: no request id, middleware chain skipped", c.method, c.path)
+		}
+		if !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Errorf("%s %s: body is not a JSON error: %s", c.method, c.path, rec.Body)
+		}
+		if got := testutil.ToFloat64(counter) - before; got != 1 {
+			t.Errorf("%s %s: unmatched requests counted %v times, want 1", c.method, c.path, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "no-such-page") {
+		t.Error("raw path of an unmatched request leaked into metric labels")
+	}
+}
+
+func TestMetricsAreNotServedOnAPIRouter(t *testing.T) {
+	r := mux.NewRouter()
+	RegisterRoutes(r, Handlers{Auth: middleware.NewAuth(nil)})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("/metrics on the API router: status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const stepUpTokenHeader = "X-Step-Up-Token"
+
+// stepUpContext передает сервисам токен подтверждения из заголовка запроса
+func stepUpContext(r *http.Request) context.Context {
+	return service.WithStepUpToken(r.Context(), r.Header.Get(stepUpTokenHeader))
+}
+
+type SecurityHandler struct {
+	StepUp    *service.StepUpService
+	TwoFactor *service.TwoFactorService
+}
+
+func NewSecurityHandler(stepUp *service.StepUpService, twoFactor *service.TwoFactorService) *SecurityHandler {
+	return &SecurityHandler{StepUp: stepUp, TwoFactor: twoFactor}
+}
+
+// SetPIN godoc
+// @Summary      Set transaction PIN
+// @Description  Sets or changes the PIN used to confirm sensitive operations. Requires a step-up token for action security (obtained via SMS when no PIN is set yet).
+// @Tags         Security
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.SetPINRequest true "New PIN"
+// @Param        X-Step-Up-Token header string false "Step-up confirmation token for action security"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      403 {object} models.ErrorResponse "step-up confirmation required"
+// @Router       /api/security/pin [post]
+func (h *SecurityHandler) SetPIN(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.SetPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.StepUp.SetPIN(stepUpContext(r), userID, req.PIN); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "transaction PIN set"})
+}
+
+// StartStepUp godoc
+// @Summary      Start step-up confirmation
+// @Description  With method pin the PIN is checked and a one-time step-up token is returned. With method sms a confirmation code is sent to the user's phone, exchange it via /api/security/step-up/confirm.
+// @Tags         Security
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.StepUpRequest true "Action and confirmation method"
+// @Success      200 {object} models.StepUpTokenResponse
+// @Success      202 {object} map[string]string "code sent"
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      429 {object} models.ErrorResponse "too many attempts"
+// @Router       /api/security/step-up [post]
+func (h *SecurityHandler) StartStepUp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	switch req.Method {
+	case models.StepUpMethodPIN:
+		resp, err := h.StepUp.ConfirmWithPIN(r.Context(), userID, req.Action, req.Operation, req.PIN)
+		if err != nil {
+			logger.Warn.Printf("[SecurityHandler] PIN step-up failed for userID=%d: %v", userID, err)
+			respond.HandleError(w, err)
+			return
+		}
+		respond.JSON(w, http.StatusOK, resp)
+	case models.StepUpMethodSMS:
+		if err := h.StepUp.SendSMS(r.Context(), userID, req.Action); err != nil {
+			respond.HandleError(w, err)
+			return
+		}
+		respond.JSON(w, http.StatusAccepted, map[string]string{"message": "confirmation code sent"})
+	default:
+		respond.HandleError(w, fmt.Errorf("%w: method must be pin or sms", errs.ErrValidationFailed))
+	}
+}
+
+// ConfirmStepUp godoc
+// @Summary      Confirm step-up with SMS code
+// @Description  Exchanges the SMS confirmation code for a one-time step-up token
+// @Tags         Security
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.StepUpConfirmRequest true "Action and SMS code"
+// @Success      200 {object} models.StepUpTokenResponse
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      429 {object} models.ErrorResponse "too many attempts"
+// @Router       /api/security/step-up/confirm [post]
+func (h *SecurityHandler) ConfirmStepUp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.StepUpConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	resp, err := h.StepUp.ConfirmWithSMS(r.Context(), userID, req.Action, req.Operation, req.Code)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// SetupTwoFactor godoc
+// @Summary      Start two-factor setup
+// @Description  Generates a TOTP secret for an authenticator app. Show provisioning_uri as a QR code, then confirm with /api/security/2fa/enable.
+// @Tags         Security
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.TwoFactorSetupResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      409 {object} models.ErrorResponse "already enabled"
+// @Router       /api/security/2fa/setup [post]
+func (h *SecurityHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	resp, err := h.TwoFactor.Setup(r.Context(), userID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// EnableTwoFactor godoc
+// @Summary      Enable two-factor authentication
+// @Description  Confirms the setup with the first code from the authenticator app. Returns one-time recovery codes; they are shown only once.
+// @Tags         Security
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success      200 {object} models.RecoveryCodesResponse
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      409 {object} models.ErrorResponse "already enabled"
+// @Failure      429 {object} models.ErrorResponse "too many attempts"
+// @Router       /api/security/2fa/enable [post]
+func (h *SecurityHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	resp, err := h.TwoFactor.Enable(r.Context(), userID, req.Code)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable two-factor authentication
+// @Description  Requires the current password and a code from the authenticator app or a recovery code
+// @Tags         Security
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TwoFactorDisableRequest true "Password and second factor"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      429 {object} models.ErrorResponse "too many attempts"
+// @Router       /api/security/2fa/disable [post]
+func (h *SecurityHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.TwoFactorDisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.TwoFactor.Disable(r.Context(), userID, req); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Issues a new set of recovery codes; the previous codes stop working
+// @Tags         Security
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success      200 {object} models.RecoveryCodesResponse
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Failure      429 {object} models.ErrorResponse "too many attempts"
+// @Router       /api/security/2fa/recovery-codes [post]
+func (h *SecurityHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	resp, err := h.TwoFactor.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"WalletX/config"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/totp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeTwoFactorRepo struct {
+	states map[int]models.TwoFactorState
+}
+
+func (r *fakeTwoFactorRepo) Get(ctx context.Context, userID int) (models.TwoFactorState, error) {
+	return r.states[userID], nil
+}
+func (r *fakeTwoFactorRepo) SavePendingSecret(ctx context.Context, userID int, secret string) error {
+	r.states[userID] = models.TwoFactorState{Secret: secret}
+	return nil
+}
+func (r *fakeTwoFactorRepo) Enable(ctx context.Context, userID int, step int64, hashes []string) error {
+	r.states[userID] = models.TwoFactorState{Secret: r.states[userID].Secret, Enabled: true, LastStep: step}
+	return nil
+}
+func (r *fakeTwoFactorRepo) Disable(ctx context.Context, userID int) error {
+	delete(r.states, userID)
+	return nil
+}
+func (r *fakeTwoFactorRepo) AcceptStep(ctx context.Context, userID int, step int64) (bool, error) {
+	state := r.states[userID]
+	if step <= state.LastStep {
+		return false, nil
+	}
+	state.LastStep = step
+	r.states[userID] = state
+	return true, nil
+}
+func (r *fakeTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	return false, nil
+}
+func (r *fakeTwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	return nil
+}
+
+func TestLoginWithTwoFactorRequiresCode(t *testing.T) {
+	env := newAuthTestEnv(7)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("walletx2025"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := env.users.users[7]
+	user.Password = string(hash)
+	env.users.users[7] = user
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.twoFactor.states[7] = models.TwoFactorState{Secret: secret, Enabled: true}
+
+	login := func() string {
+		t.Helper()
+		rec := env.do(t, http.MethodPost, "/api/users/login", "", map[string]string{"phone": user.Phone, "password": "walletx2025"})
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("login: status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+		}
+		var challenge map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &challenge); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := challenge["token"]; ok {
+			t.Fatal("tokens issued before the second factor")
+		}
+		return challenge["mfa_token"].(string)
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/users/login/2fa", "", map[string]string{"mfa_token": login(), "code": "000000"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("wrong code: status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = env.do(t, http.MethodPost, "/api/users/login/2fa", "", map[string]string{"mfa_token": login(), "code": code})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "refresh_token") {
+		t.Fatalf("valid code: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	rec = env.do(t, http.MethodPost, "/api/users/login/2fa", "", map[string]string{"mfa_token": login(), "code": code})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed code: status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+}
+
+func TestDisableTwoFactorWrongPasswordLocksAccount(t *testing.T) {
+	env := newAuthTestEnv(7)
+	env.setPassword(t, 7, "walletx2025")
+	env.twoFactor.states[7] = models.TwoFactorState{Secret: "JBSWY3DPEHPK3PXP", Enabled: true}
+	tokens, err := env.tokens.IssuePair(context.Background(), 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		rec := env.do(t, http.MethodPost, "/api/security/2fa/disable", tokens.Token, map[string]string{"password": "guess", "code": "000000"})
+		want := http.StatusUnauthorized
+		if attempt == 3 {
+			want = http.StatusLocked
+		}
+		if rec.Code != want {
+			t.Fatalf("attempt %d: status = %d, want %d: %s", attempt, rec.Code, want, rec.Body)
+		}
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/security/2fa/disable", tokens.Token, map[string]string{"password": "walletx2025", "code": "000000"})
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("after lockout: status = %d, want %d: %s", rec.Code, http.StatusLocked, rec.Body)
+	}
+	if !env.twoFactor.states[7].Enabled {
+		t.Fatal("2FA was disabled on a locked account")
+	}
+}
+
+func TestStepUpTokenIsBoundToOperation(t *testing.T) {
+	stepUpCfg := config.AppSettings.AuthParams.StepUp
+	config.AppSettings.AuthParams.StepUp.AmountThreshold = 1000
+	t.Cleanup(func() { config.AppSettings.AuthParams.StepUp = stepUpCfg })
+
+	env := newAuthTestEnv(7)
+	pin, err := bcrypt.GenerateFromPassword([]byte("4821"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.users.pins[7] = string(pin)
+	tokens, err := env.tokens.IssuePair(context.Background(), 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := service.StepUpOperation{Action: models.StepUpActionTransfer, Amount: 5000, FromAccountID: 1, ToAccountID: 2}
+	var required *errs.StepUpRequiredError
+	if err := env.stepUp.Authorize(context.Background(), 7, op); !errors.As(err, &required) || required.Operation == "" {
+		t.Fatalf("Authorize without token = %v, want step-up with operation", err)
+	}
+
+	rec := env.do(t, http.MethodPost, "/api/security/step-up", tokens.Token, map[string]string{"action": "transfer", "method": "pin", "pin": "4821"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("step-up without operation: status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	rec = env.do(t, http.MethodPost, "/api/security/step-up", tokens.Token,
+		map[string]string{"action": "transfer", "operation": required.Operation, "method": "pin", "pin": "4821"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("step-up: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var confirmed models.StepUpTokenResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &confirmed); err != nil {
+		t.Fatal(err)
+	}
+	ctx := service.WithStepUpToken(context.Background(), confirmed.StepUpToken)
+
+	tampered := op
+	tampered.Amount, tampered.ToAccountID = 50000, 3
+	if err := env.stepUp.Authorize(ctx, 7, tampered); !errors.As(err, &required) || required.Reason != service.StepUpReasonInvalidToken {
+		t.Fatalf("token used for another operation: err = %v, want invalid step-up token", err)
+	}
+	if err := env.stepUp.Authorize(ctx, 7, op); err != nil {
+		t.Fatalf("token of the confirmed operation was rejected or burned: %v", err)
+	}
+	if err := env.stepUp.Authorize(ctx, 7, op); !errors.Is(err, errs.ErrStepUpRequired) {
+		t.Fatalf("token accepted twice: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"WalletX/config"
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"WalletX/pkg/statement"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type StatementHandler struct {
+	Service *service.StatementService
+}
+
+func NewStatementHandler(s *service.StatementService) *StatementHandler {
+	return &StatementHandler{Service: s}
+}
+
+// parseDateRange читает параметры start и end (YYYY-MM-DD); end включает весь день
+func parseDateRange(r *http.Request) (time.Time, time.Time) {
+	query := r.URL.Query()
+	startStr := query.Get("start")
+	endStr := query.Get("end")
+
+	layout := "2006-01-02"
+	start, err := time.Parse(layout, startStr)
+	if err != nil || startStr == "" {
+		start = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+		logger.Info.Printf("No valid start date provided, using %s", start)
+	}
+
+	end, err := time.Parse(layout, endStr)
+	if err != nil || endStr == "" {
+		end = time.Now()
+		logger.Info.Printf("No valid end date provided, using %s", end)
+	} else {
+		end = end.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return start, end
+}
+
+// writeTracker запоминает, начали ли мы уже отдавать тело ответа
+type writeTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (t *writeTracker) Write(p []byte) (int, error) {
+	t.written = true
+	return t.ResponseWriter.Write(p)
+}
+
+// ExportStatement godoc
+// @Summary Export account statement
+// @Description Streams the statement for a period with opening/closing balance, totals and all incoming and outgoing transactions. A PDF statement is limited to 5000 transactions; a larger period returns 400.
+// @Tags transactions
+// @Produce text/csv
+// @Produce application/pdf
+// @Produce application/xml
+// @Produce application/x-ofx
+// @Security BearerAuth
+// @Param format query string true "Statement format" Enums(csv, pdf, camt053, ofx)
+// @Param start query string false "Start date (YYYY-MM-DD)" example(2025-11-02)
+// @Param end query string false "End date (YYYY-MM-DD)" example(2025-12-15)
+// @Success 200 {file} file
+// @Failure 400 {object} models.ErrorResponse "bad request"
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 500 {object} models.ErrorResponse "internal error"
+// @Router /api/statement [get]
+func (h *StatementHandler) ExportStatement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	formatName := r.URL.Query().Get("format")
+	format, err := statement.LookupFormat(formatName)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "unsupported format, use csv, pdf, camt053 or ofx", err)
+		return
+	}
+
+	start, end := parseDateRange(r)
+
+	tw := &writeTracker{ResponseWriter: w}
+	writer, err := statement.NewWriter(formatName, tw, config.AppSettings.AppParams.ServerName)
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "unsupported format", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement_%s_%s.%s"`,
+		start.Format("20060102"), end.Format("20060102"), format.Extension))
+
+	if err := h.Service.Export(r.Context(), userID, start, end, writer); err != nil {
+		logger.Error.Printf("[StatementHandler] Failed to export %s statement for userID=%d: %v", formatName, userID, err)
+		if !tw.written {
+			w.Header().Del("Content-Disposition")
+			respond.HandleError(w, err)
+		}
+		return
+	}
+
+	logger.Info.Printf("[StatementHandler] Exported %s statement for userID=%d", formatName, userID)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/pdf"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeAccountRepo struct {
+	account models.Account
+}
+
+func (r *fakeAccountRepo) CreateAccount(account models.Account) (models.Account, error) {
+	return account, nil
+}
+func (r *fakeAccountRepo) GetByID(ctx context.Context, id int) (*models.Account, error) {
+	return &r.account, nil
+}
+func (r *fakeAccountRepo) DecreaseBalance(ctx context.Context, id int, amount float64) error {
+	return nil
+}
+func (r *fakeAccountRepo) IncreaseBalance(ctx context.Context, id int, amount float64) error {
+	return nil
+}
+func (r *fakeAccountRepo) GetByUserID(ctx context.Context, userID int) (models.Account, error) {
+	return r.account, nil
+}
+func (r *fakeAccountRepo) GetByPhone(ctx context.Context, phone string) (*models.Account, error) {
+	return &r.account, nil
+}
+func (r *fakeAccountRepo) GetTransactions(ctx context.Context, accountID int, filter models.HistoryFilter) ([]models.TransactionHistory, error) {
+	return nil, nil
+}
+
+// fakeStatementRepo отдает count одинаковых входящих операций
+type fakeStatementRepo struct {
+	count int
+}
+
+func (r *fakeStatementRepo) GetSummary(ctx context.Context, accountID int, start, end time.Time) (models.Statement, error) {
+	return models.Statement{AccountID: accountID, From: start, To: end, CountIn: r.count, TotalIn: float64(r.count)}, nil
+}
+func (r *fakeStatementRepo) StreamEntries(ctx context.Context, accountID int, start, end time.Time, fn func(models.StatementEntry) error) error {
+	for i := 1; i <= r.count; i++ {
+		if err := fn(models.StatementEntry{TransactionID: i, Type: "transfer", Direction: models.DirectionIn, Amount: 1, CreatedAt: start}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportStatement(count int, format string) *httptest.ResponseRecorder {
+	h := NewStatementHandler(service.NewStatementService(&fakeStatementRepo{count: count}, &fakeAccountRepo{account: models.Account{ID: 3, UserID: 7}}))
+	req := httptest.NewRequest(http.MethodGet, "/api/statement?format="+format, nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDCtx, 7))
+	rec := httptest.NewRecorder()
+	h.ExportStatement(rec, req)
+	return rec
+}
+
+func TestPDFStatementIsLimited(t *testing.T) {
+	if rec := exportStatement(3, "pdf"); rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("small pdf: status = %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec := exportStatement(pdf.MaxStatementEntries+1, "pdf")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("pdf over the limit: status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Content-Disposition") != "" {
+		t.Error("rejected statement is still sent as an attachment")
+	}
+
+	if rec := exportStatement(pdf.MaxStatementEntries+1, "csv"); rec.Code != http.StatusOK {
+		t.Fatalf("csv is streamed without the limit: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type TemplateHandler struct {
+	Service *service.TemplateService
+}
+
+func NewTemplateHandler(s *service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{Service: s}
+}
+
+// ListTemplates godoc
+// @Summary List payment templates
+// @Description Returns saved transfer and payment templates of the authenticated user
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.PaymentTemplate
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 500 {object} models.ErrorResponse "internal error"
+// @Router /api/templates [get]
+func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	templates, err := h.Service.List(r.Context(), userID)
+	if err != nil {
+		logger.Error.Printf("[TemplateHandler] Failed to list templates for userID=%d: %v", userID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, templates)
+}
+
+// CreateTemplate godoc
+// @Summary Create payment template
+// @Description Saves a transfer (target is a phone) or service payment (target is a subscriber account) template
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateTemplateRequest true "Template"
+// @Success 201 {object} models.PaymentTemplate
+// @Failure 400 {object} models.ErrorResponse "bad request"
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 500 {object} models.ErrorResponse "internal error"
+// @Router /api/templates [post]
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	var req models.CreateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	template, err := h.Service.Create(r.Context(), userID, req)
+	if err != nil {
+		logger.Warn.Printf("[TemplateHandler] Failed to create template for userID=%d: %v", userID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, template)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete payment template
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 404 {object} models.ErrorResponse "template not found"
+// @Router /api/templates/{id} [delete]
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	templateID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid template id", err)
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), userID, templateID); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ExecuteTemplate godoc
+// @Summary Execute payment template
+// @Description Performs the transfer or payment saved in the template. Amount in the body overrides the template default.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body models.ExecuteTemplateRequest false "Amount override"
+// @Param X-Step-Up-Token header string false "Step-up confirmation token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} models.ErrorResponse "bad request"
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 404 {object} models.ErrorResponse "template not found"
+// @Router /api/templates/{id}/execute [post]
+func (h *TemplateHandler) ExecuteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	templateID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid template id", err)
+		return
+	}
+
+	var req models.ExecuteTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respond.Error(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	receipt, err := h.Service.Execute(stepUpContext(r), userID, templateID, req.Amount)
+	if err != nil {
+		logger.Warn.Printf("[TemplateHandler] Failed to execute template id=%d for userID=%d: %v", templateID, userID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]interface{}{
+		"status":         "success",
+		"receipt_number": receipt.ReceiptNumber,
+		"amount":         receipt.Amount,
+	})
+}
+
+// RecentRecipients godoc
+// @Summary Recent transfer recipients
+// @Description Returns recipients of the user's latest transfers, most recent first
+// @Tags templates
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.RecentRecipient
+// @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 500 {object} models.ErrorResponse "internal error"
+// @Router /api/recipients/recent [get]
+func (h *TemplateHandler) RecentRecipients(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	recipients, err := h.Service.RecentRecipients(r.Context(), userID)
+	if err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, recipients)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"WalletX/internal/handlers/middleware"
+	"WalletX/internal/service"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/respond"
+	"WalletX/pkg/utils"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type TokenHandler struct {
+	Tokens *service.TokenService
+}
+
+func NewTokenHandler(tokens *service.TokenService) *TokenHandler {
+	return &TokenHandler{Tokens: tokens}
+}
+
+// Refresh godoc
+// @Summary      Refresh session
+// @Description  Exchanges a refresh token for a new access/refresh pair. Each refresh token is single-use; presenting a used one revokes all sessions of the user.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RefreshRequest true "Refresh token"
+// @Success      200 {object} models.TokenPair
+// @Failure      400 {object} models.ErrorResponse
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/auth/refresh [post]
+func (h *TokenHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	pair, err := h.Tokens.Refresh(r.Context(), req.RefreshToken, utils.ClientIP(r))
+	if err != nil {
+		logger.Warn.Printf("[TokenHandler] Refresh failed: %v", err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, pair)
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revokes the current access token and, if provided, the refresh token of the session
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.LogoutRequest false "Refresh token of the session"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/auth/logout [post]
+func (h *TokenHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.ClaimsCtx).(*utils.CustomClaims)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing token claims"))
+		return
+	}
+
+	var req models.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respond.Error(w, http.StatusBadRequest, "invalid request", err)
+		return
+	}
+
+	if err := h.Tokens.Logout(r.Context(), claims, req.RefreshToken); err != nil {
+		logger.Warn.Printf("[TokenHandler] Logout failed for userID=%d: %v", claims.UserID, err)
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "logged out"})
+}
+
+// LogoutAll godoc
+// @Summary      Logout everywhere
+// @Description  Invalidates all access and refresh tokens issued to the user
+// @Tags         Auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} models.ErrorResponse
+// @Router       /api/auth/logout-all [post]
+func (h *TokenHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
+		return
+	}
+
+	if err := h.Tokens.LogoutAll(r.Context(), userID); err != nil {
+		respond.HandleError(w, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "logged out from all devices"})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRefreshFailsAfterBlocking(t *testing.T) {
+	env := newAuthTestEnv(5)
+
+	pair, err := env.tokens.IssuePair(context.Background(), 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := env.do(t, http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": pair.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var next models.TokenPair
+	if err := json.Unmarshal(rec.Body.Bytes(), &next); err != nil {
+		t.Fatal(err)
+	}
+
+	blocked := env.users.users[5]
+	blocked.IsBlocked = true
+	env.users.users[5] = blocked
+
+	rec = env.do(t, http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": next.RefreshToken})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("refresh of a blocked user: status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestTokenIssuedRightAfterLogoutAllIsValid(t *testing.T) {
+	env := newAuthTestEnv(5)
+	ctx := context.Background()
+	revoked := func(token string) bool {
+		t.Helper()
+		claims, err := utils.ParseToken(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := env.tokens.IsRevoked(ctx, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	old, err := env.tokens.IssuePair(ctx, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := env.tokens.LogoutAll(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	fresh, err := env.tokens.IssuePair(ctx, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !revoked(old.Token) {
+		t.Fatal("token issued before LogoutAll is still valid")
+	}
+	if revoked(fresh.Token) {
+		t.Fatal("token issued right after LogoutAll is treated as revoked")
+	}
+}
@@ -1,6 +1,7 @@
 package transaction
 
 import (
+	"WalletX/pkg/tracing"
 	"context"
 	"database/sql"
 )
@@ -20,8 +21,13 @@ func NewTransactionManager(db *sql.DB) TransactionManager {
 	return &transactionManager{db: db}
 }
 
-func (tm *transactionManager) WithinTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
-	tx, err := tm.db.Begin()
+// WithinTransaction выполняет fn в транзакции. Спан охватывает всю транзакцию до commit/rollback,
+// запросы внутри fn (через txCtx) становятся его дочерними спанами.
+func (tm *transactionManager) WithinTransaction(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionManager.WithinTransaction")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := tm.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -32,13 +38,21 @@ func (tm *transactionManager) WithinTransaction(ctx context.Context, fn func(txC
 
 	if err != nil {
 		tx.Rollback()
+		span.AddEvent("rollback")
 		return err
 	}
 
+	span.AddEvent("commit")
 	return tx.Commit()
 }
 
 func (tm *transactionManager) GetTx(ctx context.Context) *sql.Tx {
+	return TxFromContext(ctx)
+}
+
+// TxFromContext возвращает транзакцию, открытую WithinTransaction, или nil вне транзакции.
+// Репозитории используют его, чтобы их запросы попадали в общую транзакцию.
+func TxFromContext(ctx context.Context) *sql.Tx {
 	tx, _ := ctx.Value(txKey{}).(*sql.Tx)
 	return tx
 }
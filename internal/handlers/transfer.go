@@ -4,12 +4,16 @@ import (
 	"WalletX/internal/handlers/middleware"
 	"WalletX/internal/service"
 	"WalletX/models"
+	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
 	"WalletX/pkg/respond"
+	"WalletX/pkg/utils"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 )
 
 type TransferHandler struct {
@@ -30,23 +34,27 @@ func NewTransferHandler(ts *service.TransferService) *TransferHandler {
 // @Produce json
 // @Security BearerAuth
 // @Param request body models.TransferRequest true "Transfer request"
+// @Param X-Step-Up-Token header string false "Step-up confirmation token for action transfer"
 // @Success 200 {object} map[string]string "success"
 // @Failure 400 {object} models.ErrorResponse "bad request"
 // @Failure 401 {object} models.ErrorResponse "unauthorized"
+// @Failure 403 {object} models.ErrorResponse "step-up confirmation required or account is frozen"
 // @Failure 404 {object} models.ErrorResponse "recipient not found"
 // @Failure 500 {object} models.ErrorResponse "internal error"
 // @Router /api/transfer [post]
 func (h *TransferHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
 	var req models.TransferRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Warn.Printf("[TransferHandler] Invalid request body: %v", err)
+		log.Warn("[TransferHandler] Invalid request body", "error", err)
 		respond.Error(w, http.StatusBadRequest, "invalid request body", err)
 		return
 	}
 
 	userIDRaw := r.Context().Value(middleware.UserIDCtx)
 	if userIDRaw == nil {
-		logger.Warn.Println("[TransferHandler] User not authenticated")
+		log.Warn("[TransferHandler] User not authenticated")
 		respond.Error(w, http.StatusUnauthorized, "user not authenticated", nil)
 		return
 	}
@@ -54,94 +62,147 @@ func (h *TransferHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 
 	fromAcc, err := h.TransferService.AccountRepo.GetByUserID(r.Context(), fromUserID)
 	if err != nil {
-		logger.Warn.Printf("[TransferHandler] Sender account not found: userID=%d", fromUserID)
+		log.Warn("[TransferHandler] Sender account not found", "error", err)
 		respond.Error(w, http.StatusBadRequest, "sender account not found", err)
 		return
 	}
 
 	toAcc, err := h.TransferService.AccountRepo.GetByPhone(r.Context(), req.ToPhone)
 	if err != nil {
-		logger.Warn.Printf("[TransferHandler] Recipient account not found: phone=%s", req.ToPhone)
+		log.Warn("[TransferHandler] Recipient account not found", "to_phone", req.ToPhone)
 		respond.Error(w, http.StatusNotFound, "recipient not found", err)
 		return
 	}
 
-	if err := h.TransferService.Transfer(r.Context(), fromAcc.ID, toAcc.ID, req.Amount); err != nil {
+	receipt, err := h.TransferService.Transfer(stepUpContext(r), fromAcc.ID, toAcc.ID, req.Amount)
+	if err != nil {
+		if errors.Is(err, errs.ErrStepUpRequired) || errors.Is(err, errs.ErrAccountFrozen) {
+			respond.HandleError(w, err)
+			return
+		}
 		if err.Error() == "cannot transfer to your own account" {
-			logger.Warn.Printf("[TransferHandler] Attempt to transfer to self: fromID=%d", fromAcc.ID)
+			log.Warn("[TransferHandler] Attempt to transfer to self", "from_account_id", fromAcc.ID)
 			respond.Error(w, http.StatusBadRequest, "cannot transfer to your own account", errors.New("cannot transfer to your own account"))
 			return
 		}
-		logger.Error.Printf("[TransferHandler] Transfer failed: %v", err)
+		log.Error("[TransferHandler] Transfer failed", "from_account_id", fromAcc.ID, "to_account_id", toAcc.ID, "amount", req.Amount, "error", err)
 		respond.Error(w, http.StatusBadRequest, "transfer failed", err)
 		return
 	}
 
-	logger.Info.Printf("[TransferHandler] Transfer completed: fromAccountID=%d, toAccountID=%d, amount=%.2f",
-		fromAcc.ID, toAcc.ID, req.Amount)
-	respond.JSON(w, http.StatusOK, map[string]string{"status": "success"})
+	log.Info("[TransferHandler] Transfer completed",
+		"from_account_id", fromAcc.ID, "to_account_id", toAcc.ID, "amount", req.Amount, "receipt_number", receipt.ReceiptNumber)
+	respond.JSON(w, http.StatusOK, map[string]string{
+		"status":         "success",
+		"receipt_number": receipt.ReceiptNumber,
+	})
+}
+
+// parseHistoryFilter собирает фильтр истории из query-параметров
+func parseHistoryFilter(r *http.Request) (models.HistoryFilter, error) {
+	query := r.URL.Query()
+	start, end := parseDateRange(r)
+	filter := models.HistoryFilter{
+		Start:        start,
+		End:          end,
+		Direction:    query.Get("direction"),
+		Counterparty: strings.TrimSpace(query.Get("counterparty")),
+	}
+
+	if filter.Direction != "" && filter.Direction != models.DirectionIn && filter.Direction != models.DirectionOut {
+		return filter, fmt.Errorf("%w: direction must be in or out", errs.ErrValidationFailed)
+	}
+
+	for _, raw := range query["type"] {
+		for _, t := range strings.Split(raw, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				filter.Types = append(filter.Types, t)
+			}
+		}
+	}
+
+	parseAmount := func(name string) (*float64, error) {
+		raw := query.Get(name)
+		if raw == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("%w: invalid %s", errs.ErrValidationFailed, name)
+		}
+		return &v, nil
+	}
+	var err error
+	if filter.MinAmount, err = parseAmount("min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmount("max_amount"); err != nil {
+		return filter, err
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil || filter.Limit <= 0 {
+			return filter, fmt.Errorf("%w: invalid limit", errs.ErrValidationFailed)
+		}
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		cursorTime, cursorID, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %v", errs.ErrValidationFailed, err)
+		}
+		filter.CursorTime = &cursorTime
+		filter.CursorID = cursorID
+	}
+
+	return filter, nil
 }
 
 // TransactionHistory godoc
 // @Summary Get transaction history
-// @Description Returns transaction history for authenticated user within date range
+// @Description Returns incoming and outgoing transactions of the authenticated user, newest first, with cursor pagination
 // @Tags transactions
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param start query string false "Start date (YYYY-MM-DD)" example(2025-11-02)
 // @Param end query string false "End date (YYYY-MM-DD)" example(2025-12-15)
-// @Success 200 {array} models.TransactionHistory
+// @Param direction query string false "Direction" Enums(in, out)
+// @Param type query string false "Transaction types, comma separated" example(transfer,internet)
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param counterparty query string false "Counterparty phone" example(+992931753756)
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} models.TransactionHistoryPage
 // @Failure 400 {object} models.ErrorResponse "bad request"
 // @Failure 401 {object} models.ErrorResponse "unauthorized"
 // @Failure 500 {object} models.ErrorResponse "internal error"
 // @Router /api/history [get]
 func (h *TransferHandler) TransactionHistory(w http.ResponseWriter, r *http.Request) {
-	logger.Info.Println("[TransferHandler] TransactionHistory called")
+	log := logger.FromContext(r.Context())
 
-	query := r.URL.Query()
-	startStr := query.Get("start")
-	endStr := query.Get("end")
-
-	layout := "2006-01-02"
-	start, err := time.Parse(layout, startStr)
-	if err != nil || startStr == "" {
-		start = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-		logger.Info.Printf("[TransferHandler] No valid start date provided, using %s", start)
-	}
-
-	end, err := time.Parse(layout, endStr)
-	if err != nil || endStr == "" {
-		end = time.Now()
-		logger.Info.Printf("[TransferHandler] No valid end date provided, using %s", end)
-	} else {
-		end = end.Add(24*time.Hour - time.Nanosecond)
-	}
-
-	userIDRaw := r.Context().Value(middleware.UserIDCtx)
-	if userIDRaw == nil {
-		logger.Warn.Println("[TransferHandler] User not authenticated")
-		respond.Error(w, http.StatusUnauthorized, "user not authenticated", nil)
+	userID, ok := r.Context().Value(middleware.UserIDCtx).(int)
+	if !ok {
+		log.Warn("[TransferHandler] User not authenticated")
+		respond.Error(w, http.StatusUnauthorized, "user not authenticated", errors.New("missing user id"))
 		return
 	}
-	userID := userIDRaw.(int)
-	logger.Info.Printf("[TransferHandler] UserID from token: %d", userID)
 
-	account, err := h.TransferService.AccountRepo.GetByUserID(r.Context(), userID)
+	filter, err := parseHistoryFilter(r)
 	if err != nil {
-		logger.Warn.Printf("[TransferHandler] Account not found for userID=%d: %v", userID, err)
-		respond.Error(w, http.StatusBadRequest, "account not found", err)
+		log.Warn("[TransferHandler] Invalid history filter", "error", err)
+		respond.HandleError(w, err)
 		return
 	}
-	logger.Info.Printf("[TransferHandler] Found account: %+v", account)
 
-	transactions, err := h.TransferService.AccountRepo.GetTransactions(r.Context(), account.ID, start, end)
+	page, err := h.TransferService.History(r.Context(), userID, filter)
 	if err != nil {
-		logger.Error.Printf("[TransferHandler] Failed to get transactions for accountID=%d: %v", account.ID, err)
-		respond.Error(w, http.StatusInternalServerError, "failed to get transactions", err)
+		log.Error("[TransferHandler] Failed to get transactions", "error", err)
+		respond.HandleError(w, err)
 		return
 	}
-	logger.Info.Printf("[TransferHandler] Retrieved %d transactions for accountID=%d", len(transactions), account.ID)
+	log.Info("[TransferHandler] Transaction history retrieved", "count", len(page.Items))
 
-	respond.JSON(w, http.StatusOK, transactions)
+	respond.JSON(w, http.StatusOK, page)
 }
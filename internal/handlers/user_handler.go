@@ -42,7 +42,7 @@ func (h *UserProfileHandler) GetUserProfile(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	user, err := h.Service.GetProfileByID(userID)
+	user, err := h.Service.GetProfileByID(r.Context(), userID)
 	if err != nil {
 		respond.HandleError(w, err)
 		return
@@ -1,12 +1,12 @@
 package repository
 
 import (
+	"WalletX/internal/handlers/transaction"
 	"WalletX/models"
 	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
 	"context"
 	"database/sql"
-	"time"
 )
 
 type AccountRepository interface {
@@ -16,7 +16,7 @@ type AccountRepository interface {
 	IncreaseBalance(ctx context.Context, id int, amount float64) error
 	GetByUserID(ctx context.Context, userID int) (models.Account, error)
 	GetByPhone(ctx context.Context, phone string) (*models.Account, error)
-	GetTransactions(ctx context.Context, accountID int, start, end time.Time) ([]models.TransactionHistory, error)
+	GetTransactions(ctx context.Context, accountID int, filter models.HistoryFilter) ([]models.TransactionHistory, error)
 }
 
 type accountRepo struct {
@@ -30,13 +30,13 @@ func NewAccountRepository(db *sql.DB) AccountRepository {
 func (r *accountRepo) GetByPhone(ctx context.Context, phone string) (*models.Account, error) {
 	var acc models.Account
 	query := `
-		SELECT a.id, a.user_id, a.balance, a.bonus_balance, a.created_at, a.updated_at
+		SELECT a.id, a.user_id, a.balance, a.bonus_balance, a.is_frozen, a.created_at, a.updated_at
 		FROM accounts a
 		JOIN users u ON a.user_id = u.id
 		WHERE u.phone = $1
 	`
 	err := r.db.QueryRowContext(ctx, query, phone).
-		Scan(&acc.ID, &acc.UserID, &acc.Balance, &acc.BonusBalance, &acc.CreatedAt, &acc.UpdatedAt)
+		Scan(&acc.ID, &acc.UserID, &acc.Balance, &acc.BonusBalance, &acc.IsFrozen, &acc.CreatedAt, &acc.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Warn.Printf("[AccountRepository] Account not found by phone=%s", phone)
@@ -46,7 +46,7 @@ func (r *accountRepo) GetByPhone(ctx context.Context, phone string) (*models.Acc
 		return nil, errs.ErrInternal
 	}
 
-	logger.Info.Printf("[AccountRepository] Found account by phone %s: %+v", phone, acc)
+	logger.Info.Printf("[AccountRepository] Found account %d by phone %s", acc.ID, phone)
 	return &acc, nil
 }
 
@@ -67,8 +67,7 @@ func (r *accountRepo) CreateAccount(account models.Account) (models.Account, err
 }
 
 func getTx(ctx context.Context) *sql.Tx {
-	tx, _ := ctx.Value(struct{}{}).(*sql.Tx)
-	return tx
+	return transaction.TxFromContext(ctx)
 }
 
 func (r *accountRepo) GetByID(ctx context.Context, id int) (*models.Account, error) {
@@ -76,13 +75,13 @@ func (r *accountRepo) GetByID(ctx context.Context, id int) (*models.Account, err
 
 	var row *sql.Row
 	if tx != nil {
-		row = tx.QueryRow("SELECT id, user_id, balance, bonus_balance, created_at, updated_at FROM accounts WHERE id = $1", id)
+		row = tx.QueryRowContext(ctx, "SELECT id, user_id, balance, bonus_balance, is_frozen, created_at, updated_at FROM accounts WHERE id = $1", id)
 	} else {
-		row = r.db.QueryRow("SELECT id, user_id, balance, bonus_balance, created_at, updated_at FROM accounts WHERE id = $1", id)
+		row = r.db.QueryRowContext(ctx, "SELECT id, user_id, balance, bonus_balance, is_frozen, created_at, updated_at FROM accounts WHERE id = $1", id)
 	}
 
 	var account models.Account
-	err := row.Scan(&account.ID, &account.UserID, &account.Balance, &account.BonusBalance, &account.CreatedAt, &account.UpdatedAt)
+	err := row.Scan(&account.ID, &account.UserID, &account.Balance, &account.BonusBalance, &account.IsFrozen, &account.CreatedAt, &account.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			logger.Warn.Printf("[AccountRepository] Account not found: id=%d", id)
@@ -97,9 +96,9 @@ func (r *accountRepo) GetByID(ctx context.Context, id int) (*models.Account, err
 func (r *accountRepo) GetByUserID(ctx context.Context, userID int) (models.Account, error) {
 	var account models.Account
 	err := r.db.QueryRowContext(ctx,
-		"SELECT id, user_id, balance, bonus_balance, created_at, updated_at FROM accounts WHERE user_id = $1",
+		"SELECT id, user_id, balance, bonus_balance, is_frozen, created_at, updated_at FROM accounts WHERE user_id = $1",
 		userID,
-	).Scan(&account.ID, &account.UserID, &account.Balance, &account.BonusBalance, &account.CreatedAt, &account.UpdatedAt)
+	).Scan(&account.ID, &account.UserID, &account.Balance, &account.BonusBalance, &account.IsFrozen, &account.CreatedAt, &account.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -117,8 +116,14 @@ func (r *accountRepo) DecreaseBalance(ctx context.Context, id int, amount float6
 	tx := getTx(ctx)
 
 	var balance float64
-	row := r.db.QueryRow("SELECT balance FROM accounts WHERE id = $1", id)
-	if err := row.Scan(&balance); err != nil {
+	var frozen bool
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, "SELECT balance, is_frozen FROM accounts WHERE id = $1 FOR UPDATE", id)
+	} else {
+		row = r.db.QueryRowContext(ctx, "SELECT balance, is_frozen FROM accounts WHERE id = $1", id)
+	}
+	if err := row.Scan(&balance, &frozen); err != nil {
 		logger.Error.Printf(
 			"[AccountRepository] Failed to fetch balance for accountID=%d: %v",
 			id, err,
@@ -129,6 +134,11 @@ func (r *accountRepo) DecreaseBalance(ctx context.Context, id int, amount float6
 		return errs.ErrInternal
 	}
 
+	if frozen {
+		logger.Warn.Printf("[AccountRepository] Debit rejected: account ID %d is frozen", id)
+		return errs.ErrAccountFrozen
+	}
+
 	if balance < amount {
 		logger.Warn.Printf("[AccountRepository] Insufficient balance for account ID %d, requested: %.2f, available: %.2f", id, amount, balance)
 		return errs.ErrInsufficientBalance
@@ -137,9 +147,9 @@ func (r *accountRepo) DecreaseBalance(ctx context.Context, id int, amount float6
 	var exec sql.Result
 	var err error
 	if tx != nil {
-		exec, err = tx.Exec("UPDATE accounts SET balance = balance - $1 WHERE id = $2 AND balance >= $1", amount, id)
+		exec, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2 AND balance >= $1 AND NOT is_frozen", amount, id)
 	} else {
-		exec, err = r.db.Exec("UPDATE accounts SET balance = balance - $1 WHERE id = $2 AND balance >= $1", amount, id)
+		exec, err = r.db.ExecContext(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2 AND balance >= $1 AND NOT is_frozen", amount, id)
 	}
 
 	if err != nil {
@@ -164,9 +174,9 @@ func (r *accountRepo) IncreaseBalance(ctx context.Context, id int, amount float6
 	var exec sql.Result
 	var err error
 	if tx != nil {
-		exec, err = tx.Exec("UPDATE accounts SET balance = balance + $1 WHERE id = $2", amount, id)
+		exec, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = balance + $1 WHERE id = $2", amount, id)
 	} else {
-		exec, err = r.db.Exec("UPDATE accounts SET balance = balance + $1 WHERE id = $2", amount, id)
+		exec, err = r.db.ExecContext(ctx, "UPDATE accounts SET balance = balance + $1 WHERE id = $2", amount, id)
 	}
 
 	if err != nil {
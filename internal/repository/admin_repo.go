@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// AdminRepository — запросы административного API: поиск пользователей, заморозка счетов, поиск операций
+type AdminRepository interface {
+	FindUsers(ctx context.Context, filter models.AdminUserFilter) ([]models.AdminUser, error)
+	SetAccountFrozen(ctx context.Context, accountID int, frozen bool, reason string) error
+	SearchTransactions(ctx context.Context, filter models.TransactionSearchFilter) ([]models.AdminTransaction, error)
+}
+
+type adminRepo struct {
+	db *sql.DB
+}
+
+func NewAdminRepository(db *sql.DB) AdminRepository {
+	return &adminRepo{db: db}
+}
+
+func (r *adminRepo) FindUsers(ctx context.Context, filter models.AdminUserFilter) ([]models.AdminUser, error) {
+	query := `
+		SELECT id, phone, role, is_verified, is_blocked, locked_until
+		FROM users
+		WHERE TRUE
+	`
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != 0 {
+		query += " AND id = " + arg(filter.UserID)
+	}
+	if filter.Phone != "" {
+		query += " AND phone = " + arg(filter.Phone)
+	}
+	if filter.PhonePrefix != "" {
+		query += " AND phone LIKE " + arg(filter.PhonePrefix+"%")
+	}
+	query += " ORDER BY id LIMIT " + arg(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error.Printf("[AdminRepository] FindUsers failed: %v", err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	users := make([]models.AdminUser, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var u models.AdminUser
+		if err := rows.Scan(&u.ID, &u.Phone, &u.Role, &u.IsVerified, &u.IsBlocked, &u.LockedUntil); err != nil {
+			logger.Error.Printf("[AdminRepository] FindUsers scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		u.Accounts = make([]models.Account, 0)
+		index[u.ID] = len(users)
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[AdminRepository] FindUsers rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+	if len(users) == 0 {
+		return users, nil
+	}
+
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, int64(u.ID))
+	}
+	accRows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, balance, bonus_balance, is_frozen, created_at, updated_at
+		 FROM accounts WHERE user_id = ANY($1) ORDER BY id`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		logger.Error.Printf("[AdminRepository] FindUsers accounts failed: %v", err)
+		return nil, errs.ErrInternal
+	}
+	defer accRows.Close()
+
+	for accRows.Next() {
+		var a models.Account
+		if err := accRows.Scan(&a.ID, &a.UserID, &a.Balance, &a.BonusBalance, &a.IsFrozen, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			logger.Error.Printf("[AdminRepository] FindUsers accounts scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		i := index[a.UserID]
+		users[i].Accounts = append(users[i].Accounts, a)
+	}
+	if err := accRows.Err(); err != nil {
+		logger.Error.Printf("[AdminRepository] FindUsers accounts rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return users, nil
+}
+
+// SetAccountFrozen замораживает или размораживает счет. Списания с замороженного счета отклоняются.
+func (r *adminRepo) SetAccountFrozen(ctx context.Context, accountID int, frozen bool, reason string) error {
+	var res sql.Result
+	var err error
+	if frozen {
+		res, err = r.db.ExecContext(ctx,
+			`UPDATE accounts SET is_frozen = TRUE, frozen_at = NOW(), frozen_reason = $2 WHERE id = $1`,
+			accountID, reason,
+		)
+	} else {
+		res, err = r.db.ExecContext(ctx,
+			`UPDATE accounts SET is_frozen = FALSE, frozen_at = NULL, frozen_reason = NULL WHERE id = $1`,
+			accountID,
+		)
+	}
+	if err != nil {
+		logger.Error.Printf("[AdminRepository] SetAccountFrozen failed: accountID=%d, err=%v", accountID, err)
+		return errs.ErrInternal
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrAccountNotFound
+	}
+
+	logger.Info.Printf("[AdminRepository] Account %d frozen=%t", accountID, frozen)
+	return nil
+}
+
+// SearchTransactions ищет операции по всем счетам или по одному счету, от новых к старым.
+// Counterparty фильтра сравнивается с телефоном любой из сторон операции.
+func (r *adminRepo) SearchTransactions(ctx context.Context, filter models.TransactionSearchFilter) ([]models.AdminTransaction, error) {
+	query := `
+		SELECT t.id, t.account_from, t.account_to, t.amount, t.type, t.created_at, fu.phone, tu.phone
+		FROM transactions t
+		LEFT JOIN accounts fa ON fa.id = t.account_from
+		LEFT JOIN users fu ON fu.id = fa.user_id
+		LEFT JOIN accounts ta ON t.type = 'transfer' AND ta.id = t.account_to
+		LEFT JOIN users tu ON tu.id = ta.user_id
+		WHERE t.created_at BETWEEN $1 AND $2
+	`
+	args := []interface{}{filter.Start, filter.End}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.AccountID != 0 {
+		account := arg(filter.AccountID)
+		switch filter.Direction {
+		case models.DirectionIn:
+			query += " AND t.account_to = " + account + " AND t.type = 'transfer'"
+		case models.DirectionOut:
+			query += " AND t.account_from = " + account
+		default:
+			query += " AND (t.account_from = " + account + " OR (t.account_to = " + account + " AND t.type = 'transfer'))"
+		}
+	}
+	if len(filter.Types) > 0 {
+		query += " AND t.type = ANY(" + arg(pq.Array(filter.Types)) + ")"
+	}
+	if filter.MinAmount != nil {
+		query += " AND t.amount >= " + arg(*filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query += " AND t.amount <= " + arg(*filter.MaxAmount)
+	}
+	if filter.Counterparty != "" {
+		phone := arg(filter.Counterparty)
+		query += " AND (fu.phone = " + phone + " OR tu.phone = " + phone + ")"
+	}
+	if filter.CursorTime != nil {
+		query += " AND (t.created_at, t.id) < (" + arg(*filter.CursorTime) + ", " + arg(filter.CursorID) + ")"
+	}
+	query += " ORDER BY t.created_at DESC, t.id DESC LIMIT " + arg(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error.Printf("[AdminRepository] SearchTransactions failed: %v", err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	txs := make([]models.AdminTransaction, 0)
+	for rows.Next() {
+		var t models.AdminTransaction
+		var fromPhone, toPhone sql.NullString
+		if err := rows.Scan(&t.ID, &t.AccountFrom, &t.AccountTo, &t.Amount, &t.Type, &t.CreatedAt, &fromPhone, &toPhone); err != nil {
+			logger.Error.Printf("[AdminRepository] SearchTransactions scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		if fromPhone.Valid {
+			t.FromPhone = &fromPhone.String
+		}
+		if toPhone.Valid {
+			t.ToPhone = &toPhone.String
+		}
+		txs = append(txs, t)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[AdminRepository] SearchTransactions rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return txs, nil
+}
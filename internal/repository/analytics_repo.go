@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type AnalyticsRepository interface {
+	GetTotals(ctx context.Context, accountID int, start, end time.Time) (models.AnalyticsTotals, error)
+	GetByPeriod(ctx context.Context, accountID int, start, end time.Time, period string) ([]models.AnalyticsPeriodBucket, error)
+	GetByCategory(ctx context.Context, accountID int, start, end time.Time) ([]models.AnalyticsCategory, error)
+	GetByCounterparty(ctx context.Context, accountID int, start, end time.Time, limit int) ([]models.AnalyticsCounterparty, error)
+}
+
+type analyticsRepo struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) AnalyticsRepository {
+	return &analyticsRepo{db: db}
+}
+
+// Итоговые колонки, общие для всех группировок
+const analyticsTotalsColumns = `
+	COALESCE(SUM(t.amount) FILTER (WHERE t.account_from <> $1), 0) AS total_in,
+	COALESCE(SUM(t.amount) FILTER (WHERE t.account_from = $1), 0) AS total_out,
+	COUNT(*) FILTER (WHERE t.account_from <> $1) AS count_in,
+	COUNT(*) FILTER (WHERE t.account_from = $1) AS count_out
+`
+
+const analyticsWhere = `
+	WHERE ` + accountMovementsCondition + `
+	  AND t.created_at BETWEEN $2 AND $3
+`
+
+func (r *analyticsRepo) GetTotals(ctx context.Context, accountID int, start, end time.Time) (models.AnalyticsTotals, error) {
+	query := `SELECT ` + analyticsTotalsColumns + ` FROM transactions t ` + analyticsWhere
+
+	var totals models.AnalyticsTotals
+	err := r.db.QueryRowContext(ctx, query, accountID, start, end).
+		Scan(&totals.In, &totals.Out, &totals.CountIn, &totals.CountOut)
+	if err != nil {
+		logger.Error.Printf("[AnalyticsRepository] GetTotals failed: accountID=%d, err=%v", accountID, err)
+		return models.AnalyticsTotals{}, errs.ErrInternal
+	}
+
+	return totals, nil
+}
+
+func (r *analyticsRepo) GetByPeriod(ctx context.Context, accountID int, start, end time.Time, period string) ([]models.AnalyticsPeriodBucket, error) {
+	query := `
+		SELECT date_trunc($4, t.created_at) AS period_start, ` + analyticsTotalsColumns + `
+		FROM transactions t ` + analyticsWhere + `
+		GROUP BY period_start
+		ORDER BY period_start
+	`
+	rows, err := r.db.QueryContext(ctx, query, accountID, start, end, period)
+	if err != nil {
+		logger.Error.Printf("[AnalyticsRepository] GetByPeriod failed: accountID=%d, err=%v", accountID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	buckets := make([]models.AnalyticsPeriodBucket, 0)
+	for rows.Next() {
+		var b models.AnalyticsPeriodBucket
+		if err := rows.Scan(&b.PeriodStart, &b.In, &b.Out, &b.CountIn, &b.CountOut); err != nil {
+			logger.Error.Printf("[AnalyticsRepository] Scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[AnalyticsRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return buckets, nil
+}
+
+func (r *analyticsRepo) GetByCategory(ctx context.Context, accountID int, start, end time.Time) ([]models.AnalyticsCategory, error) {
+	query := `
+		SELECT t.type, ` + analyticsTotalsColumns + `
+		FROM transactions t ` + analyticsWhere + `
+		GROUP BY t.type
+		ORDER BY total_out DESC, total_in DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, accountID, start, end)
+	if err != nil {
+		logger.Error.Printf("[AnalyticsRepository] GetByCategory failed: accountID=%d, err=%v", accountID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	categories := make([]models.AnalyticsCategory, 0)
+	for rows.Next() {
+		var c models.AnalyticsCategory
+		if err := rows.Scan(&c.Category, &c.In, &c.Out, &c.CountIn, &c.CountOut); err != nil {
+			logger.Error.Printf("[AnalyticsRepository] Scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[AnalyticsRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return categories, nil
+}
+
+func (r *analyticsRepo) GetByCounterparty(ctx context.Context, accountID int, start, end time.Time, limit int) ([]models.AnalyticsCounterparty, error) {
+	query := `
+		SELECT u.phone, ` + analyticsTotalsColumns + `
+		FROM transactions t
+		JOIN accounts a ON a.id = CASE WHEN t.account_from = $1 THEN t.account_to ELSE t.account_from END
+		JOIN users u ON u.id = a.user_id
+		` + analyticsWhere + `
+		  AND t.type = 'transfer'
+		GROUP BY u.phone
+		ORDER BY SUM(t.amount) DESC
+		LIMIT $4
+	`
+	rows, err := r.db.QueryContext(ctx, query, accountID, start, end, limit)
+	if err != nil {
+		logger.Error.Printf("[AnalyticsRepository] GetByCounterparty failed: accountID=%d, err=%v", accountID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	counterparties := make([]models.AnalyticsCounterparty, 0)
+	for rows.Next() {
+		var c models.AnalyticsCounterparty
+		if err := rows.Scan(&c.Phone, &c.In, &c.Out, &c.CountIn, &c.CountOut); err != nil {
+			logger.Error.Printf("[AnalyticsRepository] Scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		counterparties = append(counterparties, c)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[AnalyticsRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return counterparties, nil
+}
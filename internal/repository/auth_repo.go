@@ -2,19 +2,26 @@ package repository
 
 import (
 	"WalletX/models"
+	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
 	"database/sql"
+	"time"
 )
 
 type UserRepository interface {
 	CreateUser(user models.User) (models.User, error)
 	GetByPhone(phone string) (models.User, error)
-	UpdatePassword(userID int, hashedPassword string) error
-	UpdateVerification(userID int, firstName, lastName, middleName, passport string) error
+	UpdatePassword(userID int, hashedPassword string, keepHistory int) error
+	GetPasswordHistory(userID, limit int) ([]string, error)
 	GetByID(userID int) (models.User, error)
-	IncrementPasswordAttempts(userID int) error
-	ResetPasswordAttempts(userID int) error
-	BlockUser(userID int) error
+	RegisterFailedLogin(userID int, window time.Duration) (int, error)
+	ResetLoginFailures(userID int) error
+	LockUser(userID int, until time.Time) error
+	UnlockUser(userID int) error
+	UnblockUser(userID int) error
+	GetTransactionPIN(userID int) (string, error)
+	SetTransactionPIN(userID int, pinHash string) error
+	SetRole(userID int, role string) error
 }
 
 type PostgresUserRepo struct {
@@ -41,11 +48,11 @@ func (r *PostgresUserRepo) CreateUser(user models.User) (models.User, error) {
 func (r *PostgresUserRepo) GetByPhone(phone string) (models.User, error) {
 	var user models.User
 	err := r.DB.QueryRow(
-		`SELECT id, phone, password, device_id, password_attempts, is_verified, first_name, last_name, middle_name, passport_number 
+		`SELECT id, phone, password, role, is_blocked, password_attempts, locked_until, lock_count, password_changed_at, is_verified
 		 FROM users WHERE phone=$1`,
 		phone,
-	).Scan(&user.ID, &user.Phone, &user.Password, &user.DeviceID, &user.PasswordAttempts,
-		&user.IsVerified, &user.FirstName, &user.LastName, &user.MiddleName, &user.PassportNumber)
+	).Scan(&user.ID, &user.Phone, &user.Password, &user.Role, &user.IsBlocked, &user.PasswordAttempts, &user.LockedUntil, &user.LockCount, &user.PasswordChangedAt,
+		&user.IsVerified)
 	if err != nil {
 		logger.Warn.Printf("[GetByPhone] failed for phone=%s: %v", phone, err)
 	} else {
@@ -54,8 +61,9 @@ func (r *PostgresUserRepo) GetByPhone(phone string) (models.User, error) {
 	return user, translateError(err)
 }
 
-func (r *PostgresUserRepo) UpdatePassword(userID int, hashedPassword string) error {
-	_, err := r.DB.Exec(`UPDATE users SET password=$1 WHERE id=$2`, hashedPassword, userID)
+// UpdatePassword меняет пароль и сохраняет его хеш в истории, оставляя keepHistory последних записей
+func (r *PostgresUserRepo) UpdatePassword(userID int, hashedPassword string, keepHistory int) error {
+	err := r.updatePassword(userID, hashedPassword, keepHistory)
 	if err != nil {
 		logger.Error.Printf("[UpdatePassword] failed: userID=%d, err=%v", userID, err)
 	} else {
@@ -64,59 +72,147 @@ func (r *PostgresUserRepo) UpdatePassword(userID int, hashedPassword string) err
 	return translateError(err)
 }
 
-func (r *PostgresUserRepo) IncrementPasswordAttempts(userID int) error {
-	_, err := r.DB.Exec(`UPDATE users SET password_attempts = password_attempts + 1 WHERE id=$1`, userID)
+func (r *PostgresUserRepo) updatePassword(userID int, hashedPassword string, keepHistory int) error {
+	tx, err := r.DB.Begin()
 	if err != nil {
-		logger.Warn.Printf("[IncrementPasswordAttempts] failed: userID=%d, err=%v", userID, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET password=$1, password_changed_at=NOW() WHERE id=$2`, hashedPassword, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO password_history (user_id, password_hash) VALUES ($1, $2)`, userID, hashedPassword); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`DELETE FROM password_history
+		 WHERE user_id=$1 AND id NOT IN (
+		     SELECT id FROM password_history WHERE user_id=$1 ORDER BY created_at DESC, id DESC LIMIT $2
+		 )`,
+		userID, keepHistory,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPasswordHistory возвращает хеши последних limit паролей, начиная с текущего
+func (r *PostgresUserRepo) GetPasswordHistory(userID, limit int) ([]string, error) {
+	rows, err := r.DB.Query(
+		`SELECT password_hash FROM password_history WHERE user_id=$1 ORDER BY created_at DESC, id DESC LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		logger.Error.Printf("[GetPasswordHistory] failed: userID=%d, err=%v", userID, err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			logger.Error.Printf("[GetPasswordHistory] scan failed: userID=%d, err=%v", userID, err)
+			return nil, translateError(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, translateError(rows.Err())
+}
+
+// RegisterFailedLogin учитывает неверный пароль и возвращает число ошибок подряд.
+// Если с прошлой ошибки прошло больше window, счет начинается заново.
+func (r *PostgresUserRepo) RegisterFailedLogin(userID int, window time.Duration) (int, error) {
+	var attempts int
+	err := r.DB.QueryRow(
+		`UPDATE users
+		 SET password_attempts = CASE
+		         WHEN last_failed_login_at IS NULL OR last_failed_login_at < NOW() - make_interval(secs => $2) THEN 1
+		         ELSE password_attempts + 1
+		     END,
+		     last_failed_login_at = NOW()
+		 WHERE id=$1
+		 RETURNING password_attempts`,
+		userID, window.Seconds(),
+	).Scan(&attempts)
+	if err != nil {
+		logger.Warn.Printf("[RegisterFailedLogin] failed: userID=%d, err=%v", userID, err)
 	} else {
-		logger.Info.Printf("[IncrementPasswordAttempts] success: userID=%d", userID)
+		logger.Info.Printf("[RegisterFailedLogin] success: userID=%d, attempts=%d", userID, attempts)
 	}
-	return translateError(err)
+	return attempts, translateError(err)
 }
 
-func (r *PostgresUserRepo) ResetPasswordAttempts(userID int) error {
-	_, err := r.DB.Exec(`UPDATE users SET password_attempts = 0 WHERE id=$1`, userID)
+// ResetLoginFailures вызывается после успешного входа: история блокировок тоже обнуляется
+func (r *PostgresUserRepo) ResetLoginFailures(userID int) error {
+	_, err := r.DB.Exec(
+		`UPDATE users SET password_attempts = 0, last_failed_login_at = NULL, lock_count = 0, locked_until = NULL WHERE id=$1`,
+		userID,
+	)
 	if err != nil {
-		logger.Warn.Printf("[ResetPasswordAttempts] failed: userID=%d, err=%v", userID, err)
+		logger.Warn.Printf("[ResetLoginFailures] failed: userID=%d, err=%v", userID, err)
 	} else {
-		logger.Info.Printf("[ResetPasswordAttempts] success: userID=%d", userID)
+		logger.Info.Printf("[ResetLoginFailures] success: userID=%d", userID)
 	}
 	return translateError(err)
 }
 
-func (r *PostgresUserRepo) BlockUser(userID int) error {
-	_, err := r.DB.Exec(`UPDATE users SET device_id = true WHERE id=$1`, userID)
+func (r *PostgresUserRepo) LockUser(userID int, until time.Time) error {
+	_, err := r.DB.Exec(
+		`UPDATE users SET locked_until = $2, lock_count = lock_count + 1, password_attempts = 0 WHERE id=$1`,
+		userID, until,
+	)
 	if err != nil {
-		logger.Warn.Printf("[BlockUser] failed: userID=%d, err=%v", userID, err)
+		logger.Warn.Printf("[LockUser] failed: userID=%d, err=%v", userID, err)
 	} else {
-		logger.Info.Printf("[BlockUser] success: userID=%d", userID)
+		logger.Info.Printf("[LockUser] success: userID=%d, until=%s", userID, until.Format(time.RFC3339))
 	}
 	return translateError(err)
 }
 
-func (r *PostgresUserRepo) UpdateVerification(userID int, firstName, lastName, middleName, passport_number string) error {
+// UnlockUser снимает временную блокировку. lock_count сохраняется, чтобы следующая блокировка была дольше.
+func (r *PostgresUserRepo) UnlockUser(userID int) error {
 	_, err := r.DB.Exec(
-		`UPDATE users 
-		 SET first_name=$1, last_name=$2, middle_name=$3, passport_number=$4, is_verified=true 
-		 WHERE id=$5`,
-		firstName, lastName, middleName, passport_number, userID,
+		`UPDATE users SET locked_until = NULL, password_attempts = 0, last_failed_login_at = NULL WHERE id=$1`,
+		userID,
 	)
 	if err != nil {
-		logger.Error.Printf("[UpdateVerification] failed: userID=%d, err=%v", userID, err)
+		logger.Warn.Printf("[UnlockUser] failed: userID=%d, err=%v", userID, err)
 	} else {
-		logger.Info.Printf("[UpdateVerification] success: userID=%d", userID)
+		logger.Info.Printf("[UnlockUser] success: userID=%d", userID)
 	}
 	return translateError(err)
 }
 
+// UnblockUser полностью снимает блокировку, включая ручную, и обнуляет историю блокировок
+func (r *PostgresUserRepo) UnblockUser(userID int) error {
+	res, err := r.DB.Exec(
+		`UPDATE users
+		 SET is_blocked = false, locked_until = NULL, lock_count = 0, password_attempts = 0, last_failed_login_at = NULL
+		 WHERE id=$1`,
+		userID,
+	)
+	if err != nil {
+		logger.Warn.Printf("[UnblockUser] failed: userID=%d, err=%v", userID, err)
+		return translateError(err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errs.ErrUserNotFound
+	}
+
+	logger.Info.Printf("[UnblockUser] success: userID=%d", userID)
+	return nil
+}
+
 func (r *PostgresUserRepo) GetByID(userID int) (models.User, error) {
 	var user models.User
 	err := r.DB.QueryRow(
-		`SELECT id, phone, password, is_verified, first_name, last_name, middle_name, passport_number
+		`SELECT id, phone, password, role, is_blocked, locked_until, lock_count, is_verified
 		 FROM users WHERE id=$1`,
 		userID,
-	).Scan(&user.ID, &user.Phone, &user.Password, &user.IsVerified,
-		&user.FirstName, &user.LastName, &user.MiddleName, &user.PassportNumber)
+	).Scan(&user.ID, &user.Phone, &user.Password, &user.Role, &user.IsBlocked, &user.LockedUntil, &user.LockCount, &user.IsVerified)
 	if err != nil {
 		logger.Warn.Printf("[GetByID] failed: userID=%d, err=%v", userID, err)
 	} else {
@@ -124,3 +220,38 @@ func (r *PostgresUserRepo) GetByID(userID int) (models.User, error) {
 	}
 	return user, translateError(err)
 }
+
+// GetTransactionPIN возвращает bcrypt-хеш PIN-кода операций или пустую строку, если PIN не задан
+func (r *PostgresUserRepo) GetTransactionPIN(userID int) (string, error) {
+	var pinHash sql.NullString
+	err := r.DB.QueryRow(`SELECT transaction_pin_hash FROM users WHERE id=$1`, userID).Scan(&pinHash)
+	if err != nil {
+		logger.Warn.Printf("[GetTransactionPIN] failed: userID=%d, err=%v", userID, err)
+		return "", translateError(err)
+	}
+	return pinHash.String, nil
+}
+
+func (r *PostgresUserRepo) SetTransactionPIN(userID int, pinHash string) error {
+	_, err := r.DB.Exec(`UPDATE users SET transaction_pin_hash=$1 WHERE id=$2`, pinHash, userID)
+	if err != nil {
+		logger.Error.Printf("[SetTransactionPIN] failed: userID=%d, err=%v", userID, err)
+	} else {
+		logger.Info.Printf("[SetTransactionPIN] success: userID=%d", userID)
+	}
+	return translateError(err)
+}
+
+// SetRole меняет роль пользователя
+func (r *PostgresUserRepo) SetRole(userID int, role string) error {
+	res, err := r.DB.Exec(`UPDATE users SET role=$1 WHERE id=$2`, role, userID)
+	if err != nil {
+		logger.Error.Printf("[SetRole] failed: userID=%d, err=%v", userID, err)
+		return translateError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrUserNotFound
+	}
+	logger.Info.Printf("[SetRole] success: userID=%d, role=%s", userID, role)
+	return nil
+}
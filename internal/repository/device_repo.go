@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type DeviceRepository interface {
+	Upsert(ctx context.Context, device models.Device) (models.Device, bool, error)
+	GetActiveByUserID(ctx context.Context, userID int) ([]models.Device, error)
+	Touch(ctx context.Context, id int, ip string) error
+	Revoke(ctx context.Context, userID, id int) error
+	GetByID(ctx context.Context, id int) (models.Device, error)
+	GetByDeviceID(ctx context.Context, userID int, deviceID string) (models.Device, error)
+	SetPublicKey(ctx context.Context, userID, id int, publicKey string) error
+}
+
+type deviceRepo struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) DeviceRepository {
+	return &deviceRepo{db: db}
+}
+
+// Upsert регистрирует устройство при входе или обновляет уже известное.
+// Второй результат равен true, если устройство встретилось впервые. Отозванное устройство с тем же
+// device_id тоже считается новым: отзыв не должен сниматься повторным входом без уведомления.
+func (r *deviceRepo) Upsert(ctx context.Context, device models.Device) (models.Device, bool, error) {
+	// xmax = 0 только у строки, которая была вставлена, а не обновлена по ON CONFLICT;
+	// previous видит строку до обновления, поэтому показывает, была ли она отозвана
+	query := `
+		WITH previous AS (
+			SELECT revoked_at IS NOT NULL AS revoked
+			FROM devices
+			WHERE user_id = $1 AND device_id = $2
+		)
+		INSERT INTO devices (user_id, device_id, name, platform, last_ip)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, device_id) DO UPDATE
+		SET name = EXCLUDED.name,
+		    platform = EXCLUDED.platform,
+		    last_ip = EXCLUDED.last_ip,
+		    last_seen_at = NOW(),
+		    created_at = CASE WHEN devices.revoked_at IS NULL THEN devices.created_at ELSE NOW() END,
+		    revoked_at = NULL
+		RETURNING id, last_seen_at, created_at, (xmax = 0 OR COALESCE((SELECT revoked FROM previous), false))
+	`
+	var inserted bool
+	err := r.db.QueryRowContext(ctx, query,
+		device.UserID, device.DeviceID, device.Name, device.Platform, device.LastIP,
+	).Scan(&device.ID, &device.LastSeenAt, &device.CreatedAt, &inserted)
+	if err != nil {
+		logger.Error.Printf("[DeviceRepository] Upsert failed: userID=%d, err=%v", device.UserID, err)
+		return models.Device{}, false, errs.ErrInternal
+	}
+
+	logger.Info.Printf("[DeviceRepository] Device id=%d registered for userID=%d (new=%t)", device.ID, device.UserID, inserted)
+	return device, inserted, nil
+}
+
+func (r *deviceRepo) GetActiveByUserID(ctx context.Context, userID int) ([]models.Device, error) {
+	query := `
+		SELECT id, user_id, device_id, name, platform, last_ip, last_seen_at, created_at, public_key IS NOT NULL
+		FROM devices
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		logger.Error.Printf("[DeviceRepository] GetActiveByUserID failed: userID=%d, err=%v", userID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	devices := make([]models.Device, 0)
+	for rows.Next() {
+		var d models.Device
+		if err := rows.Scan(&d.ID, &d.UserID, &d.DeviceID, &d.Name, &d.Platform, &d.LastIP, &d.LastSeenAt, &d.CreatedAt, &d.HasKey); err != nil {
+			logger.Error.Printf("[DeviceRepository] Scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[DeviceRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return devices, nil
+}
+
+func (r *deviceRepo) Touch(ctx context.Context, id int, ip string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE devices SET last_seen_at = NOW(), last_ip = $2 WHERE id = $1`, id, ip)
+	if err != nil {
+		logger.Warn.Printf("[DeviceRepository] Touch failed: id=%d, err=%v", id, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *deviceRepo) Revoke(ctx context.Context, userID, id int) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE devices SET revoked_at = NOW(), public_key = NULL, key_registered_at = NULL
+		 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		logger.Error.Printf("[DeviceRepository] Revoke failed: id=%d, userID=%d, err=%v", id, userID, err)
+		return errs.ErrInternal
+	}
+
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return errs.ErrDeviceNotFound
+	}
+
+	logger.Info.Printf("[DeviceRepository] Revoked device id=%d for userID=%d", id, userID)
+	return nil
+}
+
+const deviceColumns = `id, user_id, device_id, name, platform, last_ip, last_seen_at, created_at, revoked_at, public_key`
+
+func scanDevice(row *sql.Row) (models.Device, error) {
+	var (
+		d         models.Device
+		publicKey sql.NullString
+	)
+	err := row.Scan(&d.ID, &d.UserID, &d.DeviceID, &d.Name, &d.Platform, &d.LastIP, &d.LastSeenAt, &d.CreatedAt, &d.RevokedAt, &publicKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Device{}, errs.ErrDeviceNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[DeviceRepository] Scan error: %v", err)
+		return models.Device{}, errs.ErrInternal
+	}
+	d.PublicKey = publicKey.String
+	d.HasKey = publicKey.Valid
+	return d, nil
+}
+
+// GetByID возвращает устройство, в том числе отозванное (RevokedAt != nil)
+func (r *deviceRepo) GetByID(ctx context.Context, id int) (models.Device, error) {
+	return scanDevice(r.db.QueryRowContext(ctx, `SELECT `+deviceColumns+` FROM devices WHERE id = $1`, id))
+}
+
+// GetByDeviceID ищет устройство пользователя по идентификатору установки приложения
+func (r *deviceRepo) GetByDeviceID(ctx context.Context, userID int, deviceID string) (models.Device, error) {
+	return scanDevice(r.db.QueryRowContext(ctx,
+		`SELECT `+deviceColumns+` FROM devices WHERE user_id = $1 AND device_id = $2`, userID, deviceID))
+}
+
+// SetPublicKey привязывает открытый ключ к активному устройству, заменяя прежний
+func (r *deviceRepo) SetPublicKey(ctx context.Context, userID, id int, publicKey string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE devices SET public_key = $3, key_registered_at = NOW()
+		 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID, publicKey,
+	)
+	if err != nil {
+		logger.Error.Printf("[DeviceRepository] SetPublicKey failed: id=%d, userID=%d, err=%v", id, userID, err)
+		return errs.ErrInternal
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrDeviceNotFound
+	}
+
+	logger.Info.Printf("[DeviceRepository] Public key registered for device id=%d, userID=%d", id, userID)
+	return nil
+}
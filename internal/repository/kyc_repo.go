@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/pii"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// KYCRepository — заявки на проверку личности и их документы
+type KYCRepository interface {
+	Create(ctx context.Context, app models.KYCApplication) (models.KYCApplication, error)
+	Latest(ctx context.Context, userID int) (models.KYCApplication, error)
+	Get(ctx context.Context, id int) (models.KYCApplication, error)
+	ListPending(ctx context.Context, limit int) ([]models.KYCApplication, error)
+	GetDocument(ctx context.Context, applicationID, documentID int) (models.KYCDocument, error)
+	Approve(ctx context.Context, id, reviewerID int) (models.KYCApplication, error)
+	Reject(ctx context.Context, id, reviewerID int, reason string) (models.KYCApplication, error)
+	GetTier(ctx context.Context, userID int) (int, error)
+}
+
+// kycRepo хранит имена и номер паспорта зашифрованными; для поиска совпадений паспортов
+// используется слепой индекс passport_index
+type kycRepo struct {
+	db  *sql.DB
+	pii *pii.Cipher
+}
+
+func NewKYCRepository(db *sql.DB, cipher *pii.Cipher) KYCRepository {
+	return &kycRepo{db: db, pii: cipher}
+}
+
+const kycApplicationColumns = `a.id, a.user_id, u.phone, a.status, a.first_name, a.last_name, a.middle_name, a.passport_number,
+	a.passport_index, a.reject_reason, a.reviewer_id, a.submitted_at, a.reviewed_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanApplication читает и расшифровывает заявку. stale == true, если данные заявки нужно
+// перешифровать активным ключом или у нее еще нет слепого индекса паспорта.
+func (r *kycRepo) scanApplication(row rowScanner) (models.KYCApplication, bool, error) {
+	var (
+		app           models.KYCApplication
+		sealed        [4]sql.NullString
+		passportIndex sql.NullString
+		rejectReason  sql.NullString
+		reviewerID    sql.NullInt64
+		reviewedAt    sql.NullTime
+	)
+	err := row.Scan(&app.ID, &app.UserID, &app.Phone, &app.Status, &sealed[0], &sealed[1], &sealed[2],
+		&sealed[3], &passportIndex, &rejectReason, &reviewerID, &app.SubmittedAt, &reviewedAt)
+	if err != nil {
+		return models.KYCApplication{}, false, err
+	}
+
+	stale := !passportIndex.Valid
+	columns := piiColumns("kyc_applications")
+	for i, field := range []*string{&app.FirstName, &app.LastName, &app.MiddleName, &app.PassportNumber} {
+		plaintext, fieldStale, err := decryptPII(r.pii, sealed[i], pii.AAD(columns[i], app.UserID))
+		if err != nil {
+			return models.KYCApplication{}, false, err
+		}
+		*field = plaintext
+		stale = stale || fieldStale
+	}
+
+	if rejectReason.Valid {
+		app.RejectReason = &rejectReason.String
+	}
+	if reviewerID.Valid {
+		id := int(reviewerID.Int64)
+		app.ReviewerID = &id
+	}
+	if reviewedAt.Valid {
+		app.ReviewedAt = &reviewedAt.Time
+	}
+	return app, stale, nil
+}
+
+// sealApplication шифрует персональные данные заявки активным ключом для колонок таблицы table:
+// kyc_applications при сохранении заявки, users при переносе данных в профиль
+func (r *kycRepo) sealApplication(app models.KYCApplication, table string) ([]interface{}, error) {
+	values := make([]interface{}, 0, 5)
+	columns := piiColumns(table)
+	for i, field := range []string{app.FirstName, app.LastName, app.MiddleName, app.PassportNumber} {
+		sealed, err := encryptPII(r.pii, field, pii.AAD(columns[i], app.UserID))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, sealed)
+	}
+	return append(values, r.pii.BlindIndex(app.PassportNumber)), nil
+}
+
+// reseal перешифровывает заявку, прочитанную со старым ключом или открытым текстом. Ошибка
+// только пишется в лог: заявка перешифруется при следующем чтении.
+func (r *kycRepo) reseal(ctx context.Context, app models.KYCApplication) {
+	values, err := r.sealApplication(app, "kyc_applications")
+	if err == nil {
+		_, err = r.db.ExecContext(ctx,
+			`UPDATE kyc_applications
+			 SET first_name = $2, last_name = $3, middle_name = $4, passport_number = $5, passport_index = $6
+			 WHERE id = $1`,
+			append([]interface{}{app.ID}, values...)...,
+		)
+	}
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] Failed to re-encrypt application %d: %v", app.ID, err)
+	}
+}
+
+// Create сохраняет заявку вместе с документами. Вторая заявка на проверке у того же
+// пользователя отклоняется уникальным индексом.
+func (r *kycRepo) Create(ctx context.Context, app models.KYCApplication) (models.KYCApplication, error) {
+	values, err := r.sealApplication(app, "kyc_applications")
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] Create: encryption failed for userID=%d: %v", app.UserID, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO kyc_applications (user_id, status, first_name, last_name, middle_name, passport_number, passport_index)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 RETURNING id, submitted_at`,
+			append([]interface{}{app.UserID, models.KYCStatusPending}, values...)...,
+		).Scan(&app.ID, &app.SubmittedAt)
+		if err != nil {
+			return err
+		}
+
+		for i := range app.Documents {
+			doc := &app.Documents[i]
+			doc.ApplicationID = app.ID
+			err := tx.QueryRowContext(ctx,
+				`INSERT INTO kyc_documents (application_id, kind, storage_key, content_type, size_bytes)
+				 VALUES ($1, $2, $3, $4, $5)
+				 RETURNING id, created_at`,
+				app.ID, doc.Kind, doc.StorageKey, doc.ContentType, doc.Size,
+			).Scan(&doc.ID, &doc.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.KYCApplication{}, errs.ErrKYCPending
+		}
+		logger.Error.Printf("[KYCRepository] Create failed: userID=%d, err=%v", app.UserID, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+
+	app.Status = models.KYCStatusPending
+	return app, nil
+}
+
+// Latest возвращает последнюю заявку пользователя без документов
+func (r *kycRepo) Latest(ctx context.Context, userID int) (models.KYCApplication, error) {
+	app, stale, err := r.scanApplication(r.db.QueryRowContext(ctx,
+		`SELECT `+kycApplicationColumns+`
+		 FROM kyc_applications a JOIN users u ON u.id = a.user_id
+		 WHERE a.user_id = $1
+		 ORDER BY a.id DESC
+		 LIMIT 1`,
+		userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.KYCApplication{}, errs.ErrKYCNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] Latest failed: userID=%d, err=%v", userID, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+	if stale {
+		r.reseal(ctx, app)
+	}
+	return app, nil
+}
+
+// Get возвращает заявку с документами
+func (r *kycRepo) Get(ctx context.Context, id int) (models.KYCApplication, error) {
+	app, stale, err := r.scanApplication(r.db.QueryRowContext(ctx,
+		`SELECT `+kycApplicationColumns+`
+		 FROM kyc_applications a JOIN users u ON u.id = a.user_id
+		 WHERE a.id = $1`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.KYCApplication{}, errs.ErrKYCNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] Get failed: id=%d, err=%v", id, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+	if stale {
+		r.reseal(ctx, app)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, application_id, kind, storage_key, content_type, size_bytes, created_at
+		 FROM kyc_documents WHERE application_id = $1 ORDER BY id`,
+		id,
+	)
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] Get documents failed: id=%d, err=%v", id, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	app.Documents = make([]models.KYCDocument, 0)
+	for rows.Next() {
+		var doc models.KYCDocument
+		if err := rows.Scan(&doc.ID, &doc.ApplicationID, &doc.Kind, &doc.StorageKey, &doc.ContentType, &doc.Size, &doc.CreatedAt); err != nil {
+			logger.Error.Printf("[KYCRepository] Get documents scan error: %v", err)
+			return models.KYCApplication{}, errs.ErrInternal
+		}
+		app.Documents = append(app.Documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[KYCRepository] Get documents rows error: %v", err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+	return app, nil
+}
+
+// ListPending возвращает очередь заявок на проверке, самые старые первыми
+func (r *kycRepo) ListPending(ctx context.Context, limit int) ([]models.KYCApplication, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+kycApplicationColumns+`
+		 FROM kyc_applications a JOIN users u ON u.id = a.user_id
+		 WHERE a.status = $1
+		 ORDER BY a.id
+		 LIMIT $2`,
+		models.KYCStatusPending, limit,
+	)
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] ListPending failed: %v", err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	apps := make([]models.KYCApplication, 0)
+	var stale []models.KYCApplication
+	for rows.Next() {
+		app, isStale, err := r.scanApplication(rows)
+		if err != nil {
+			logger.Error.Printf("[KYCRepository] ListPending scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		apps = append(apps, app)
+		if isStale {
+			stale = append(stale, app)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[KYCRepository] ListPending rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+	rows.Close()
+
+	for _, app := range stale {
+		r.reseal(ctx, app)
+	}
+	return apps, nil
+}
+
+func (r *kycRepo) GetDocument(ctx context.Context, applicationID, documentID int) (models.KYCDocument, error) {
+	var doc models.KYCDocument
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, application_id, kind, storage_key, content_type, size_bytes, created_at
+		 FROM kyc_documents WHERE id = $1 AND application_id = $2`,
+		documentID, applicationID,
+	).Scan(&doc.ID, &doc.ApplicationID, &doc.Kind, &doc.StorageKey, &doc.ContentType, &doc.Size, &doc.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.KYCDocument{}, errs.ErrKYCNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] GetDocument failed: id=%d, err=%v", documentID, err)
+		return models.KYCDocument{}, errs.ErrInternal
+	}
+	return doc, nil
+}
+
+// Approve одобряет заявку и в той же транзакции переносит данные в профиль пользователя
+// и повышает уровень верификации. Паспорт, уже подтвержденный у другого пользователя, не принимается.
+func (r *kycRepo) Approve(ctx context.Context, id, reviewerID int) (models.KYCApplication, error) {
+	var app models.KYCApplication
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		app, _, err = r.scanApplication(tx.QueryRowContext(ctx,
+			`SELECT `+kycApplicationColumns+`
+			 FROM kyc_applications a JOIN users u ON u.id = a.user_id
+			 WHERE a.id = $1
+			 FOR UPDATE OF a`,
+			id,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrKYCNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if app.Status != models.KYCStatusPending {
+			return errs.ErrKYCNotPending
+		}
+
+		values, err := r.sealApplication(app, "users")
+		if err != nil {
+			return err
+		}
+
+		var taken bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM users WHERE passport_index = $1 AND id <> $2 AND is_verified)`,
+			r.pii.BlindIndex(app.PassportNumber), app.UserID,
+		).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errs.ErrPassportInUse
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`UPDATE kyc_applications SET status = $2, reviewer_id = $3, reviewed_at = NOW()
+			 WHERE id = $1
+			 RETURNING reviewed_at`,
+			id, models.KYCStatusApproved, reviewerID,
+		).Scan(&app.ReviewedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE users
+			 SET first_name = $3, last_name = $4, middle_name = $5, passport_number = $6, passport_index = $7,
+			     is_verified = TRUE, verification_tier = $2
+			 WHERE id = $1`,
+			append([]interface{}{app.UserID, models.TierVerified}, values...)...,
+		)
+		return err
+	})
+	if err != nil {
+		// одновременное одобрение того же паспорта у другого пользователя отклоняет уникальный индекс
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.KYCApplication{}, errs.ErrPassportInUse
+		}
+		if errors.Is(err, errs.ErrKYCNotFound) || errors.Is(err, errs.ErrKYCNotPending) || errors.Is(err, errs.ErrPassportInUse) {
+			return models.KYCApplication{}, err
+		}
+		logger.Error.Printf("[KYCRepository] Approve failed: id=%d, err=%v", id, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+
+	app.Status = models.KYCStatusApproved
+	app.ReviewerID = &reviewerID
+	return app, nil
+}
+
+// Reject отклоняет заявку на проверке; профиль и уровень пользователя не меняются
+func (r *kycRepo) Reject(ctx context.Context, id, reviewerID int, reason string) (models.KYCApplication, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE kyc_applications SET status = $2, reviewer_id = $3, reject_reason = $4, reviewed_at = NOW()
+		 WHERE id = $1 AND status = $5`,
+		id, models.KYCStatusRejected, reviewerID, reason, models.KYCStatusPending,
+	)
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] Reject failed: id=%d, err=%v", id, err)
+		return models.KYCApplication{}, errs.ErrInternal
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return models.KYCApplication{}, err
+		}
+		return models.KYCApplication{}, errs.ErrKYCNotPending
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *kycRepo) GetTier(ctx context.Context, userID int) (int, error) {
+	var tier int
+	err := r.db.QueryRowContext(ctx, `SELECT verification_tier FROM users WHERE id = $1`, userID).Scan(&tier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errs.ErrUserNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[KYCRepository] GetTier failed: userID=%d, err=%v", userID, err)
+		return 0, errs.ErrInternal
+	}
+	return tier, nil
+}
+
+func (r *kycRepo) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
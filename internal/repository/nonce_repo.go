@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceRepository запоминает nonce подписанных запросов, чтобы повтор запроса отклонялся
+type NonceRepository interface {
+	Remember(ctx context.Context, deviceID int, nonce string, ttl time.Duration) (bool, error)
+}
+
+type redisNonceRepo struct {
+	rdb *redis.Client
+}
+
+func NewNonceRepository(rdb *redis.Client) NonceRepository {
+	return &redisNonceRepo{rdb: rdb}
+}
+
+func nonceKey(deviceID int, nonce string) string {
+	return "request_nonce:" + strconv.Itoa(deviceID) + ":" + nonce
+}
+
+// Remember сохраняет nonce на ttl. false означает, что nonce уже встречался.
+func (r *redisNonceRepo) Remember(ctx context.Context, deviceID int, nonce string, ttl time.Duration) (bool, error) {
+	fresh, err := r.rdb.SetNX(ctx, nonceKey(deviceID, nonce), 1, ttl).Result()
+	if err != nil {
+		logger.Error.Printf("[NonceRepository] Remember failed for deviceID=%d: %v", deviceID, err)
+		return false, errs.ErrInternal
+	}
+	return fresh, nil
+}
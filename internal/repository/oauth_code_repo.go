@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OAuthCodeRepository хранит коды авторизации OAuth до обмена на токены
+type OAuthCodeRepository interface {
+	Save(ctx context.Context, codeHash string, code models.OAuthAuthorizationCode, ttl time.Duration) error
+	Consume(ctx context.Context, codeHash string) (models.OAuthAuthorizationCode, error)
+}
+
+type redisOAuthCodeRepo struct {
+	rdb *redis.Client
+}
+
+func NewOAuthCodeRepository(rdb *redis.Client) OAuthCodeRepository {
+	return &redisOAuthCodeRepo{rdb: rdb}
+}
+
+func oauthCodeKey(codeHash string) string { return "oauth_code:" + codeHash }
+
+func (r *redisOAuthCodeRepo) Save(ctx context.Context, codeHash string, code models.OAuthAuthorizationCode, ttl time.Duration) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return errs.ErrInternal
+	}
+	if err := r.rdb.Set(ctx, oauthCodeKey(codeHash), data, ttl).Err(); err != nil {
+		logger.Error.Printf("[OAuthCodeRepository] Save failed for userID=%d: %v", code.UserID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+// Consume атомарно забирает код, так что обменять его на токены можно только один раз
+func (r *redisOAuthCodeRepo) Consume(ctx context.Context, codeHash string) (models.OAuthAuthorizationCode, error) {
+	data, err := r.rdb.GetDel(ctx, oauthCodeKey(codeHash)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return models.OAuthAuthorizationCode{}, errs.ErrInvalidOneTimeToken
+		}
+		logger.Error.Printf("[OAuthCodeRepository] Consume failed: %v", err)
+		return models.OAuthAuthorizationCode{}, errs.ErrInternal
+	}
+
+	var code models.OAuthAuthorizationCode
+	if err := json.Unmarshal(data, &code); err != nil {
+		logger.Error.Printf("[OAuthCodeRepository] Corrupted authorization code: %v", err)
+		return models.OAuthAuthorizationCode{}, errs.ErrInternal
+	}
+	return code, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// OAuthRepository хранит партнерские приложения и согласия пользователей
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client models.OAuthClient) (models.OAuthClient, error)
+	GetClient(ctx context.Context, clientID string) (models.OAuthClient, error)
+	ListClients(ctx context.Context) ([]models.OAuthClient, error)
+	SaveConsent(ctx context.Context, userID, clientRowID int, scopes []string) error
+	GetConsent(ctx context.Context, userID, clientRowID int) ([]string, error)
+	ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error)
+	DeleteConsent(ctx context.Context, userID int, clientID string) error
+}
+
+type oauthRepo struct {
+	db *sql.DB
+}
+
+func NewOAuthRepository(db *sql.DB) OAuthRepository {
+	return &oauthRepo{db: db}
+}
+
+const oauthClientColumns = `id, client_id, secret_hash, name, redirect_uris, scopes, grant_types, is_active, created_at`
+
+func scanOAuthClient(row interface{ Scan(...interface{}) error }) (models.OAuthClient, error) {
+	var c models.OAuthClient
+	var secretHash sql.NullString
+	err := row.Scan(&c.ID, &c.ClientID, &secretHash, &c.Name, pq.Array(&c.RedirectURIs), pq.Array(&c.Scopes),
+		pq.Array(&c.GrantTypes), &c.IsActive, &c.CreatedAt)
+	c.SecretHash = secretHash.String
+	c.Confidential = secretHash.Valid
+	return c, err
+}
+
+func (r *oauthRepo) CreateClient(ctx context.Context, client models.OAuthClient) (models.OAuthClient, error) {
+	var secretHash sql.NullString
+	if client.SecretHash != "" {
+		secretHash = sql.NullString{String: client.SecretHash, Valid: true}
+	}
+
+	created, err := scanOAuthClient(r.db.QueryRowContext(ctx,
+		`INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, grant_types)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING `+oauthClientColumns,
+		client.ClientID, secretHash, client.Name, pq.Array(client.RedirectURIs), pq.Array(client.Scopes), pq.Array(client.GrantTypes),
+	))
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] CreateClient failed: %v", err)
+		return models.OAuthClient{}, errs.ErrInternal
+	}
+
+	logger.Info.Printf("[OAuthRepository] Client %s (%s) registered", created.ClientID, created.Name)
+	return created, nil
+}
+
+func (r *oauthRepo) GetClient(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	client, err := scanOAuthClient(r.db.QueryRowContext(ctx,
+		`SELECT `+oauthClientColumns+` FROM oauth_clients WHERE client_id = $1`, clientID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.OAuthClient{}, errs.ErrClientNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] GetClient failed: client=%s, err=%v", clientID, err)
+		return models.OAuthClient{}, errs.ErrInternal
+	}
+	return client, nil
+}
+
+func (r *oauthRepo) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients ORDER BY id`)
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] ListClients failed: %v", err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	clients := make([]models.OAuthClient, 0)
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			logger.Error.Printf("[OAuthRepository] ListClients scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		clients = append(clients, client)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[OAuthRepository] ListClients rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+	return clients, nil
+}
+
+// SaveConsent записывает согласие; области добавляются к уже выданным ранее
+func (r *oauthRepo) SaveConsent(ctx context.Context, userID, clientRowID int, scopes []string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO oauth_consents (user_id, client_id, scopes)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, client_id) DO UPDATE
+		 SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+		     updated_at = NOW()`,
+		userID, clientRowID, pq.Array(scopes),
+	)
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] SaveConsent failed: userID=%d, client=%d, err=%v", userID, clientRowID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *oauthRepo) GetConsent(ctx context.Context, userID, clientRowID int) ([]string, error) {
+	var scopes []string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT scopes FROM oauth_consents WHERE user_id = $1 AND client_id = $2`, userID, clientRowID,
+	).Scan(pq.Array(&scopes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errs.ErrConsentNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] GetConsent failed: userID=%d, client=%d, err=%v", userID, clientRowID, err)
+		return nil, errs.ErrInternal
+	}
+	return scopes, nil
+}
+
+func (r *oauthRepo) ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT c.client_id, c.name, oc.scopes, oc.created_at, oc.updated_at
+		 FROM oauth_consents oc
+		 JOIN oauth_clients c ON c.id = oc.client_id
+		 WHERE oc.user_id = $1
+		 ORDER BY oc.updated_at DESC`,
+		userID,
+	)
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] ListConsents failed: userID=%d, err=%v", userID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	consents := make([]models.OAuthConsent, 0)
+	for rows.Next() {
+		var c models.OAuthConsent
+		if err := rows.Scan(&c.ClientID, &c.ClientName, pq.Array(&c.Scopes), &c.GrantedAt, &c.UpdatedAt); err != nil {
+			logger.Error.Printf("[OAuthRepository] ListConsents scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		consents = append(consents, c)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[OAuthRepository] ListConsents rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+	return consents, nil
+}
+
+func (r *oauthRepo) DeleteConsent(ctx context.Context, userID int, clientID string) error {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM oauth_consents
+		 WHERE user_id = $1 AND client_id = (SELECT id FROM oauth_clients WHERE client_id = $2)`,
+		userID, clientID,
+	)
+	if err != nil {
+		logger.Error.Printf("[OAuthRepository] DeleteConsent failed: userID=%d, client=%s, err=%v", userID, clientID, err)
+		return errs.ErrInternal
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrConsentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OTPRepository хранит одноразовые SMS-коды. purpose разделяет сценарии (разблокировка,
+// сброс пароля и т.д.), subject — кому выдан код (обычно номер телефона).
+type OTPRepository interface {
+	Save(ctx context.Context, purpose, subject, codeHash string, ttl time.Duration) error
+	ReserveSend(ctx context.Context, purpose, subject string, cooldown, window time.Duration) (bool, int, error)
+	Get(ctx context.Context, purpose, subject string) (string, error)
+	IncrementAttempts(ctx context.Context, purpose, subject string, ttl time.Duration) (int, error)
+	Delete(ctx context.Context, purpose, subject string) error
+}
+
+type redisOTPRepo struct {
+	rdb *redis.Client
+}
+
+func NewOTPRepository(rdb *redis.Client) OTPRepository {
+	return &redisOTPRepo{rdb: rdb}
+}
+
+func otpKey(purpose, subject string) string         { return "otp:" + purpose + ":" + subject }
+func otpAttemptsKey(purpose, subject string) string { return "otp_attempts:" + purpose + ":" + subject }
+func otpCooldownKey(purpose, subject string) string { return "otp_cooldown:" + purpose + ":" + subject }
+func otpSendsKey(purpose, subject string) string    { return "otp_sends:" + purpose + ":" + subject }
+
+// Save сохраняет новый код. Счетчик неверных попыток при этом не сбрасывается:
+// иначе повторная отправка давала бы новый запас попыток подбора.
+func (r *redisOTPRepo) Save(ctx context.Context, purpose, subject, codeHash string, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, otpKey(purpose, subject), codeHash, ttl).Err(); err != nil {
+		logger.Error.Printf("[OTPRepository] Save failed for %s: %v", purpose, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+// ReserveSend резервирует отправку кода: false, если с прошлой отправки не прошло cooldown.
+// Второе значение — число отправок в текущем окне window, отсчитываемом от первой из них.
+func (r *redisOTPRepo) ReserveSend(ctx context.Context, purpose, subject string, cooldown, window time.Duration) (bool, int, error) {
+	ok, err := r.rdb.SetNX(ctx, otpCooldownKey(purpose, subject), 1, cooldown).Result()
+	if err != nil {
+		logger.Error.Printf("[OTPRepository] ReserveSend failed for %s: %v", purpose, err)
+		return false, 0, errs.ErrInternal
+	}
+	if !ok {
+		return false, 0, nil
+	}
+
+	key := otpSendsKey(purpose, subject)
+	sends, err := r.rdb.Incr(ctx, key).Result()
+	if err == nil && sends == 1 {
+		err = r.rdb.Expire(ctx, key, window).Err()
+	}
+	if err != nil {
+		logger.Error.Printf("[OTPRepository] ReserveSend failed for %s: %v", purpose, err)
+		return false, 0, errs.ErrInternal
+	}
+	return true, int(sends), nil
+}
+
+func (r *redisOTPRepo) Get(ctx context.Context, purpose, subject string) (string, error) {
+	codeHash, err := r.rdb.Get(ctx, otpKey(purpose, subject)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", errs.ErrCodeExpired
+		}
+		logger.Error.Printf("[OTPRepository] Get failed for %s: %v", purpose, err)
+		return "", errs.ErrInternal
+	}
+	return codeHash, nil
+}
+
+func (r *redisOTPRepo) IncrementAttempts(ctx context.Context, purpose, subject string, ttl time.Duration) (int, error) {
+	key := otpAttemptsKey(purpose, subject)
+	pipe := r.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Error.Printf("[OTPRepository] IncrementAttempts failed for %s: %v", purpose, err)
+		return 0, errs.ErrInternal
+	}
+	return int(incr.Val()), nil
+}
+
+func (r *redisOTPRepo) Delete(ctx context.Context, purpose, subject string) error {
+	if err := r.rdb.Del(ctx, otpKey(purpose, subject), otpAttemptsKey(purpose, subject)).Err(); err != nil {
+		logger.Error.Printf("[OTPRepository] Delete failed for %s: %v", purpose, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
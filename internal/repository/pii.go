@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"WalletX/pkg/pii"
+	"database/sql"
+)
+
+// piiColumns — зашифрованные колонки таблицы: имя, фамилия, отчество, номер паспорта. Имя колонки
+// вместе с id владельца входит в AAD шифртекста.
+func piiColumns(table string) [4]string {
+	return [4]string{table + ".first_name", table + ".last_name", table + ".middle_name", table + ".passport_number"}
+}
+
+// decryptPII расшифровывает необязательное поле. stale == true, если значение записано открытым
+// текстом или не активным ключом и его нужно перешифровать.
+func decryptPII(c *pii.Cipher, value sql.NullString, aad []byte) (plaintext string, stale bool, err error) {
+	if !value.Valid || value.String == "" {
+		return "", false, nil
+	}
+	plaintext, err = c.Decrypt(value.String, aad)
+	if err != nil {
+		return "", false, err
+	}
+	return plaintext, c.Stale(value.String), nil
+}
+
+// encryptPII шифрует необязательное поле; пустое значение сохраняется как NULL
+func encryptPII(c *pii.Cipher, value string, aad []byte) (sql.NullString, error) {
+	if value == "" {
+		return sql.NullString{}, nil
+	}
+	sealed, err := c.Encrypt(value, aad)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: sealed, Valid: true}, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+)
+
+type ReceiptRepository interface {
+	Create(ctx context.Context, receipt models.Receipt) (models.Receipt, error)
+	GetByNumber(ctx context.Context, receiptNumber string, userID int) (models.Receipt, error)
+}
+
+type receiptRepo struct {
+	db *sql.DB
+}
+
+func NewReceiptRepository(db *sql.DB) ReceiptRepository {
+	return &receiptRepo{db: db}
+}
+
+// Create сохраняет чек. Владельцы и телефоны плательщика и получателя подтягиваются по id счетов;
+// для платежей за услуги получатель — это услуга, поэтому пользователь-получатель не заполняется.
+func (r *receiptRepo) Create(ctx context.Context, receipt models.Receipt) (models.Receipt, error) {
+	query := `
+		INSERT INTO receipts (
+			receipt_number, transaction_id, type, status,
+			payer_user_id, payer_account_id, payer_phone,
+			payee_user_id, payee_account_id, payee_phone,
+			service_name, service_description, subscriber_account,
+			amount, fee, total, created_at
+		)
+		VALUES (
+			$1, $2, $3, $4,
+			(SELECT user_id FROM accounts WHERE id = $5), $5,
+			(SELECT u.phone FROM accounts a JOIN users u ON u.id = a.user_id WHERE a.id = $5),
+			CASE WHEN $3::text = 'transfer' THEN (SELECT user_id FROM accounts WHERE id = $6) END, $6,
+			CASE WHEN $3::text = 'transfer' THEN (SELECT u.phone FROM accounts a JOIN users u ON u.id = a.user_id WHERE a.id = $6) END,
+			$7, $8, $9,
+			$10, $11, $12, $13
+		)
+		RETURNING payer_user_id, payer_phone, payee_user_id, payee_phone, issued_at
+	`
+	args := []any{
+		receipt.ReceiptNumber, receipt.TransactionID, receipt.Type, receipt.Status,
+		receipt.PayerAccountID, receipt.PayeeAccountID,
+		receipt.ServiceName, receipt.ServiceDescription, receipt.SubscriberAccount,
+		receipt.Amount, receipt.Fee, receipt.Total, receipt.CreatedAt,
+	}
+	// Чек пишется в той же транзакции, что и списание: если вставка упадет, откатятся и балансы
+	var row *sql.Row
+	if tx := getTx(ctx); tx != nil {
+		row = tx.QueryRowContext(ctx, query, args...)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, args...)
+	}
+	err := row.Scan(&receipt.PayerUserID, &receipt.PayerPhone, &receipt.PayeeUserID, &receipt.PayeePhone, &receipt.IssuedAt)
+	if err != nil {
+		logger.Error.Printf("[ReceiptRepository] Create failed: transactionID=%d, err=%v", receipt.TransactionID, err)
+		return models.Receipt{}, errs.ErrInternal
+	}
+
+	logger.Info.Printf("[ReceiptRepository] Issued receipt %s for transactionID=%d", receipt.ReceiptNumber, receipt.TransactionID)
+	return receipt, nil
+}
+
+// GetByNumber возвращает чек, если пользователь является его плательщиком или получателем
+func (r *receiptRepo) GetByNumber(ctx context.Context, receiptNumber string, userID int) (models.Receipt, error) {
+	query := `
+		SELECT receipt_number, transaction_id, type, status,
+		       payer_user_id, payer_account_id, payer_phone,
+		       payee_user_id, payee_account_id, payee_phone,
+		       service_name, service_description, subscriber_account,
+		       amount, fee, total, created_at, issued_at
+		FROM receipts
+		WHERE receipt_number = $1
+		  AND (payer_user_id = $2 OR payee_user_id = $2)
+	`
+	var rc models.Receipt
+	err := r.db.QueryRowContext(ctx, query, receiptNumber, userID).Scan(
+		&rc.ReceiptNumber, &rc.TransactionID, &rc.Type, &rc.Status,
+		&rc.PayerUserID, &rc.PayerAccountID, &rc.PayerPhone,
+		&rc.PayeeUserID, &rc.PayeeAccountID, &rc.PayeePhone,
+		&rc.ServiceName, &rc.ServiceDescription, &rc.SubscriberAccount,
+		&rc.Amount, &rc.Fee, &rc.Total, &rc.CreatedAt, &rc.IssuedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Warn.Printf("[ReceiptRepository] Receipt %s not found for userID=%d", receiptNumber, userID)
+			return models.Receipt{}, errs.ErrReceiptNotFound
+		}
+		logger.Error.Printf("[ReceiptRepository] GetByNumber DB error: %v", err)
+		return models.Receipt{}, errs.ErrInternal
+	}
+
+	return rc, nil
+}
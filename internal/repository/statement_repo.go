@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type StatementRepository interface {
+	GetSummary(ctx context.Context, accountID int, start, end time.Time) (models.Statement, error)
+	StreamEntries(ctx context.Context, accountID int, start, end time.Time, fn func(models.StatementEntry) error) error
+}
+
+type statementRepo struct {
+	db *sql.DB
+}
+
+func NewStatementRepository(db *sql.DB) StatementRepository {
+	return &statementRepo{db: db}
+}
+
+// Входящими считаются только переводы: у платежей account_to хранит id услуги, а не счета.
+const accountMovementsCondition = `(t.account_from = $1 OR (t.account_to = $1 AND t.type = 'transfer'))`
+
+// GetSummary считает итоги за период и восстанавливает входящий и исходящий остатки
+// от текущего баланса счета, вычитая движения после начала и после конца периода.
+func (r *statementRepo) GetSummary(ctx context.Context, accountID int, start, end time.Time) (models.Statement, error) {
+	query := `
+		WITH movements AS (
+			SELECT
+				t.created_at,
+				CASE WHEN t.account_from = $1 THEN -t.amount ELSE t.amount END AS signed_amount
+			FROM transactions t
+			WHERE ` + accountMovementsCondition + `
+		)
+		SELECT
+			a.balance,
+			u.phone,
+			COALESCE(SUM(m.signed_amount) FILTER (WHERE m.created_at BETWEEN $2 AND $3 AND m.signed_amount > 0), 0),
+			COALESCE(-SUM(m.signed_amount) FILTER (WHERE m.created_at BETWEEN $2 AND $3 AND m.signed_amount < 0), 0),
+			COUNT(*) FILTER (WHERE m.created_at BETWEEN $2 AND $3 AND m.signed_amount > 0),
+			COUNT(*) FILTER (WHERE m.created_at BETWEEN $2 AND $3 AND m.signed_amount < 0),
+			COALESCE(SUM(m.signed_amount) FILTER (WHERE m.created_at >= $2), 0),
+			COALESCE(SUM(m.signed_amount) FILTER (WHERE m.created_at > $3), 0)
+		FROM accounts a
+		JOIN users u ON u.id = a.user_id
+		LEFT JOIN movements m ON true
+		WHERE a.id = $1
+		GROUP BY a.balance, u.phone
+	`
+
+	st := models.Statement{AccountID: accountID, From: start, To: end}
+	var balance, netSinceStart, netAfterEnd float64
+	err := r.db.QueryRowContext(ctx, query, accountID, start, end).Scan(
+		&balance, &st.Phone, &st.TotalIn, &st.TotalOut, &st.CountIn, &st.CountOut, &netSinceStart, &netAfterEnd,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Statement{}, errs.ErrAccountNotFound
+		}
+		logger.Error.Printf("[StatementRepository] GetSummary failed: accountID=%d, err=%v", accountID, err)
+		return models.Statement{}, errs.ErrInternal
+	}
+
+	st.OpeningBalance = balance - netSinceStart
+	st.ClosingBalance = balance - netAfterEnd
+	return st, nil
+}
+
+// StreamEntries читает операции периода по возрастанию даты и передает их в fn по одной,
+// не загружая весь период в память.
+func (r *statementRepo) StreamEntries(ctx context.Context, accountID int, start, end time.Time, fn func(models.StatementEntry) error) error {
+	query := `
+		SELECT
+			t.id,
+			t.account_from,
+			t.amount,
+			t.type,
+			t.created_at,
+			u.phone
+		FROM transactions t
+		LEFT JOIN accounts a ON t.type = 'transfer'
+			AND a.id = CASE WHEN t.account_from = $1 THEN t.account_to ELSE t.account_from END
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE ` + accountMovementsCondition + `
+		  AND t.created_at BETWEEN $2 AND $3
+		ORDER BY t.created_at, t.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, accountID, start, end)
+	if err != nil {
+		logger.Error.Printf("[StatementRepository] StreamEntries failed: accountID=%d, err=%v", accountID, err)
+		return errs.ErrInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.StatementEntry
+		var accountFrom int
+		var phone sql.NullString
+		if err := rows.Scan(&e.TransactionID, &accountFrom, &e.Amount, &e.Type, &e.CreatedAt, &phone); err != nil {
+			logger.Error.Printf("[StatementRepository] Scan error: %v", err)
+			return errs.ErrInternal
+		}
+
+		e.Direction = models.DirectionIn
+		if accountFrom == accountID {
+			e.Direction = models.DirectionOut
+		}
+		if phone.Valid {
+			e.CounterpartyPhone = &phone.String
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[StatementRepository] Rows error: %v", err)
+		return errs.ErrInternal
+	}
+
+	return nil
+}
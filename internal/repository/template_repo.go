@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+)
+
+type TemplateRepository interface {
+	Create(ctx context.Context, template models.PaymentTemplate) (models.PaymentTemplate, error)
+	GetByUserID(ctx context.Context, userID int) ([]models.PaymentTemplate, error)
+	GetByID(ctx context.Context, userID, templateID int) (models.PaymentTemplate, error)
+	Delete(ctx context.Context, userID, templateID int) error
+	GetRecentRecipients(ctx context.Context, accountID, limit int) ([]models.RecentRecipient, error)
+}
+
+type templateRepo struct {
+	db *sql.DB
+}
+
+func NewTemplateRepository(db *sql.DB) TemplateRepository {
+	return &templateRepo{db: db}
+}
+
+func (r *templateRepo) Create(ctx context.Context, template models.PaymentTemplate) (models.PaymentTemplate, error) {
+	query := `
+		INSERT INTO payment_templates (user_id, name, type, service_type, target, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		template.UserID, template.Name, template.Type, template.ServiceType, template.Target, template.Amount,
+	).Scan(&template.ID, &template.CreatedAt)
+	if err != nil {
+		logger.Error.Printf("[TemplateRepository] Create failed: userID=%d, err=%v", template.UserID, err)
+		return models.PaymentTemplate{}, errs.ErrInternal
+	}
+
+	logger.Info.Printf("[TemplateRepository] Created template id=%d for userID=%d", template.ID, template.UserID)
+	return template, nil
+}
+
+func (r *templateRepo) GetByUserID(ctx context.Context, userID int) ([]models.PaymentTemplate, error) {
+	query := `
+		SELECT id, user_id, name, type, service_type, target, amount, created_at
+		FROM payment_templates
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		logger.Error.Printf("[TemplateRepository] GetByUserID failed: userID=%d, err=%v", userID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	templates := make([]models.PaymentTemplate, 0)
+	for rows.Next() {
+		var t models.PaymentTemplate
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Type, &t.ServiceType, &t.Target, &t.Amount, &t.CreatedAt); err != nil {
+			logger.Error.Printf("[TemplateRepository] Scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[TemplateRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return templates, nil
+}
+
+func (r *templateRepo) GetByID(ctx context.Context, userID, templateID int) (models.PaymentTemplate, error) {
+	query := `
+		SELECT id, user_id, name, type, service_type, target, amount, created_at
+		FROM payment_templates
+		WHERE id = $1 AND user_id = $2
+	`
+	var t models.PaymentTemplate
+	err := r.db.QueryRowContext(ctx, query, templateID, userID).
+		Scan(&t.ID, &t.UserID, &t.Name, &t.Type, &t.ServiceType, &t.Target, &t.Amount, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Warn.Printf("[TemplateRepository] Template not found: id=%d, userID=%d", templateID, userID)
+			return models.PaymentTemplate{}, errs.ErrTemplateNotFound
+		}
+		logger.Error.Printf("[TemplateRepository] GetByID DB error: %v", err)
+		return models.PaymentTemplate{}, errs.ErrInternal
+	}
+
+	return t, nil
+}
+
+func (r *templateRepo) Delete(ctx context.Context, userID, templateID int) error {
+	exec, err := r.db.ExecContext(ctx, `DELETE FROM payment_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+	if err != nil {
+		logger.Error.Printf("[TemplateRepository] Delete failed: id=%d, err=%v", templateID, err)
+		return errs.ErrInternal
+	}
+
+	rows, _ := exec.RowsAffected()
+	if rows == 0 {
+		return errs.ErrTemplateNotFound
+	}
+
+	logger.Info.Printf("[TemplateRepository] Deleted template id=%d for userID=%d", templateID, userID)
+	return nil
+}
+
+// GetRecentRecipients собирает получателей последних переводов из таблицы transactions
+func (r *templateRepo) GetRecentRecipients(ctx context.Context, accountID, limit int) ([]models.RecentRecipient, error) {
+	query := `
+		SELECT
+			u.phone,
+			(ARRAY_AGG(t.amount ORDER BY t.created_at DESC))[1] AS last_amount,
+			COUNT(*) AS transfers_count,
+			MAX(t.created_at) AS last_transfer_at
+		FROM transactions t
+		JOIN accounts a ON a.id = t.account_to
+		JOIN users u ON u.id = a.user_id
+		WHERE t.account_from = $1
+		  AND t.type = 'transfer'
+		GROUP BY u.phone
+		ORDER BY last_transfer_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, accountID, limit)
+	if err != nil {
+		logger.Error.Printf("[TemplateRepository] GetRecentRecipients failed: accountID=%d, err=%v", accountID, err)
+		return nil, errs.ErrInternal
+	}
+	defer rows.Close()
+
+	recipients := make([]models.RecentRecipient, 0)
+	for rows.Next() {
+		var rr models.RecentRecipient
+		if err := rows.Scan(&rr.Phone, &rr.LastAmount, &rr.TransfersCount, &rr.LastTransferAt); err != nil {
+			logger.Error.Printf("[TemplateRepository] Scan error: %v", err)
+			return nil, errs.ErrInternal
+		}
+		recipients = append(recipients, rr)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[TemplateRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
+
+	return recipients, nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type TokenRepository interface {
+	SaveRefresh(ctx context.Context, tokenHash string, session models.RefreshSession, ttl time.Duration) error
+	GetRefresh(ctx context.Context, tokenHash string) (models.RefreshSession, error)
+	MarkRefreshUsed(ctx context.Context, tokenHash string, ttl time.Duration) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error
+	IsFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userID int, before time.Time, ttl time.Duration) error
+	GetUserRevokedBefore(ctx context.Context, userID int) (time.Time, error)
+	RevokeDeviceTokens(ctx context.Context, deviceID int, before time.Time, ttl time.Duration) error
+	GetDeviceRevokedBefore(ctx context.Context, deviceID int) (time.Time, error)
+	RevokeConsentTokens(ctx context.Context, userID int, clientID string, before time.Time, ttl time.Duration) error
+	GetConsentRevokedBefore(ctx context.Context, userID int, clientID string) (time.Time, error)
+	SaveOneTimeToken(ctx context.Context, purpose, tokenHash string, userID int, ttl time.Duration) error
+	ConsumeOneTimeToken(ctx context.Context, purpose, tokenHash string) (int, error)
+}
+
+type redisTokenRepo struct {
+	rdb *redis.Client
+}
+
+func NewTokenRepository(rdb *redis.Client) TokenRepository {
+	return &redisTokenRepo{rdb: rdb}
+}
+
+func refreshKey(tokenHash string) string     { return "refresh:" + tokenHash }
+func refreshUsedKey(tokenHash string) string { return "refresh_used:" + tokenHash }
+func familyRevokedKey(familyID string) string {
+	return "refresh_family_revoked:" + familyID
+}
+func revokedJTIKey(jti string) string    { return "revoked_jti:" + jti }
+func revokedBeforeKey(userID int) string { return "revoked_before:" + strconv.Itoa(userID) }
+func deviceRevokedBeforeKey(deviceID int) string {
+	return "device_revoked_before:" + strconv.Itoa(deviceID)
+}
+func consentRevokedBeforeKey(userID int, clientID string) string {
+	return "consent_revoked_before:" + strconv.Itoa(userID) + ":" + clientID
+}
+func oneTimeTokenKey(purpose, tokenHash string) string {
+	return "one_time:" + purpose + ":" + tokenHash
+}
+
+func (r *redisTokenRepo) SaveRefresh(ctx context.Context, tokenHash string, session models.RefreshSession, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return errs.ErrInternal
+	}
+	if err := r.rdb.Set(ctx, refreshKey(tokenHash), data, ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] SaveRefresh failed for userID=%d: %v", session.UserID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *redisTokenRepo) GetRefresh(ctx context.Context, tokenHash string) (models.RefreshSession, error) {
+	data, err := r.rdb.Get(ctx, refreshKey(tokenHash)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return models.RefreshSession{}, errs.ErrInvalidRefreshToken
+		}
+		logger.Error.Printf("[TokenRepository] GetRefresh failed: %v", err)
+		return models.RefreshSession{}, errs.ErrInternal
+	}
+
+	var session models.RefreshSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		logger.Error.Printf("[TokenRepository] Corrupted refresh session: %v", err)
+		return models.RefreshSession{}, errs.ErrInternal
+	}
+	return session, nil
+}
+
+// MarkRefreshUsed атомарно помечает refresh-токен использованным. false означает, что он уже
+// был использован раньше — то есть токен предъявлен повторно.
+func (r *redisTokenRepo) MarkRefreshUsed(ctx context.Context, tokenHash string, ttl time.Duration) (bool, error) {
+	ok, err := r.rdb.SetNX(ctx, refreshUsedKey(tokenHash), 1, ttl).Result()
+	if err != nil {
+		logger.Error.Printf("[TokenRepository] MarkRefreshUsed failed: %v", err)
+		return false, errs.ErrInternal
+	}
+	return ok, nil
+}
+
+func (r *redisTokenRepo) RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, familyRevokedKey(familyID), 1, ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] RevokeFamily failed: %v", err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *redisTokenRepo) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	return r.exists(ctx, familyRevokedKey(familyID))
+}
+
+func (r *redisTokenRepo) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	if err := r.rdb.Set(ctx, revokedJTIKey(jti), 1, ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] RevokeAccessToken failed: %v", err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *redisTokenRepo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.exists(ctx, revokedJTIKey(jti))
+}
+
+// RevokeUserTokens делает недействительными все токены пользователя, выпущенные до before
+func (r *redisTokenRepo) RevokeUserTokens(ctx context.Context, userID int, before time.Time, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, revokedBeforeKey(userID), before.UnixMilli(), ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] RevokeUserTokens failed for userID=%d: %v", userID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *redisTokenRepo) GetUserRevokedBefore(ctx context.Context, userID int) (time.Time, error) {
+	ts, err := r.rdb.Get(ctx, revokedBeforeKey(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		logger.Error.Printf("[TokenRepository] GetUserRevokedBefore failed for userID=%d: %v", userID, err)
+		return time.Time{}, errs.ErrInternal
+	}
+	return time.UnixMilli(ts), nil
+}
+
+// RevokeDeviceTokens делает недействительными все токены, выпущенные устройству до before
+func (r *redisTokenRepo) RevokeDeviceTokens(ctx context.Context, deviceID int, before time.Time, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, deviceRevokedBeforeKey(deviceID), before.UnixMilli(), ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] RevokeDeviceTokens failed for deviceID=%d: %v", deviceID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *redisTokenRepo) GetDeviceRevokedBefore(ctx context.Context, deviceID int) (time.Time, error) {
+	ts, err := r.rdb.Get(ctx, deviceRevokedBeforeKey(deviceID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		logger.Error.Printf("[TokenRepository] GetDeviceRevokedBefore failed for deviceID=%d: %v", deviceID, err)
+		return time.Time{}, errs.ErrInternal
+	}
+	return time.UnixMilli(ts), nil
+}
+
+// RevokeConsentTokens делает недействительными токены приложения clientID от имени пользователя, выпущенные до before
+func (r *redisTokenRepo) RevokeConsentTokens(ctx context.Context, userID int, clientID string, before time.Time, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, consentRevokedBeforeKey(userID, clientID), before.UnixMilli(), ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] RevokeConsentTokens failed for userID=%d client=%s: %v", userID, clientID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func (r *redisTokenRepo) GetConsentRevokedBefore(ctx context.Context, userID int, clientID string) (time.Time, error) {
+	ts, err := r.rdb.Get(ctx, consentRevokedBeforeKey(userID, clientID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		logger.Error.Printf("[TokenRepository] GetConsentRevokedBefore failed for userID=%d client=%s: %v", userID, clientID, err)
+		return time.Time{}, errs.ErrInternal
+	}
+	return time.UnixMilli(ts), nil
+}
+
+func (r *redisTokenRepo) SaveOneTimeToken(ctx context.Context, purpose, tokenHash string, userID int, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, oneTimeTokenKey(purpose, tokenHash), userID, ttl).Err(); err != nil {
+		logger.Error.Printf("[TokenRepository] SaveOneTimeToken %s failed for userID=%d: %v", purpose, userID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+// ConsumeOneTimeToken атомарно забирает токен, так что предъявить его можно только один раз
+func (r *redisTokenRepo) ConsumeOneTimeToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	userID, err := r.rdb.GetDel(ctx, oneTimeTokenKey(purpose, tokenHash)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, errs.ErrInvalidOneTimeToken
+		}
+		logger.Error.Printf("[TokenRepository] ConsumeOneTimeToken %s failed: %v", purpose, err)
+		return 0, errs.ErrInternal
+	}
+	return userID, nil
+}
+
+func (r *redisTokenRepo) exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.rdb.Exists(ctx, key).Result()
+	if err != nil {
+		logger.Error.Printf("[TokenRepository] EXISTS %s failed: %v", key, err)
+		return false, errs.ErrInternal
+	}
+	return n > 0, nil
+}
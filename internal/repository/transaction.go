@@ -6,11 +6,14 @@ import (
 	"WalletX/pkg/logger"
 	"context"
 	"database/sql"
-	"time"
+	"fmt"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepository interface {
-	CreateTransaction(transaction models.Transaction) (models.Transaction, error)
+	CreateTransaction(ctx context.Context, transaction models.Transaction) (models.Transaction, error)
+	HasTransfer(ctx context.Context, fromAccountID, toAccountID int) (bool, error)
 }
 
 type transactionRepo struct {
@@ -21,13 +24,18 @@ func NewTransactionRepository(db *sql.DB) TransactionRepository {
 	return &transactionRepo{db: db}
 }
 
-func (r *transactionRepo) CreateTransaction(transaction models.Transaction) (models.Transaction, error) {
+func (r *transactionRepo) CreateTransaction(ctx context.Context, transaction models.Transaction) (models.Transaction, error) {
 	query := `
         INSERT INTO transactions (account_from, account_to, amount, type, created_at)
         VALUES ($1, $2, $3, $4, $5)
         RETURNING id, created_at
     `
-	row := r.db.QueryRow(query, transaction.AccountFrom, transaction.AccountTo, transaction.Amount, transaction.Type, transaction.CreatedAt)
+	var row *sql.Row
+	if tx := getTx(ctx); tx != nil {
+		row = tx.QueryRowContext(ctx, query, transaction.AccountFrom, transaction.AccountTo, transaction.Amount, transaction.Type, transaction.CreatedAt)
+	} else {
+		row = r.db.QueryRowContext(ctx, query, transaction.AccountFrom, transaction.AccountTo, transaction.Amount, transaction.Type, transaction.CreatedAt)
+	}
 	err := row.Scan(&transaction.ID, &transaction.CreatedAt)
 	if err != nil {
 		logger.Warn.Printf("[CreateTransaction] failed: from=%d to=%d, err=%v", transaction.AccountFrom, transaction.AccountTo, err)
@@ -37,28 +45,74 @@ func (r *transactionRepo) CreateTransaction(transaction models.Transaction) (mod
 	return transaction, nil
 }
 
-func (r *accountRepo) GetTransactions(ctx context.Context, accountID int, start, end time.Time) ([]models.TransactionHistory, error) {
+// HasTransfer сообщает, переводил ли уже счет fromAccountID деньги на счет toAccountID
+func (r *transactionRepo) HasTransfer(ctx context.Context, fromAccountID, toAccountID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM transactions WHERE account_from = $1 AND account_to = $2 AND type = 'transfer')`,
+		fromAccountID, toAccountID,
+	).Scan(&exists)
+	if err != nil {
+		logger.Error.Printf("[TransactionRepository] HasTransfer failed: from=%d to=%d, err=%v", fromAccountID, toAccountID, err)
+		return false, errs.ErrInternal
+	}
+	return exists, nil
+}
+
+// GetTransactions возвращает входящие и исходящие операции счета по фильтру,
+// от новых к старым, начиная с позиции курсора
+func (r *accountRepo) GetTransactions(ctx context.Context, accountID int, filter models.HistoryFilter) ([]models.TransactionHistory, error) {
 	logger.Info.Printf(
 		"[AccountRepository] Fetching transactions for accountID=%d, period=%s - %s",
-		accountID, start, end,
+		accountID, filter.Start, filter.End,
 	)
 
 	query := `
 		SELECT
+			t.id,
+			t.account_from,
 			t.account_to,
 			t.amount,
 			t.type,
 			t.created_at,
 			u.phone
 		FROM transactions t
-		LEFT JOIN accounts a ON a.id = t.account_to
+		LEFT JOIN accounts a ON t.type = 'transfer'
+			AND a.id = CASE WHEN t.account_from = $1 THEN t.account_to ELSE t.account_from END
 		LEFT JOIN users u ON u.id = a.user_id
-		WHERE t.account_from = $1
+		WHERE ` + accountMovementsCondition + `
 		  AND t.created_at BETWEEN $2 AND $3
-		ORDER BY t.created_at DESC
 	`
+	args := []interface{}{accountID, filter.Start, filter.End}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, accountID, start, end)
+	switch filter.Direction {
+	case models.DirectionIn:
+		query += " AND t.account_from <> $1"
+	case models.DirectionOut:
+		query += " AND t.account_from = $1"
+	}
+	if len(filter.Types) > 0 {
+		query += " AND t.type = ANY(" + arg(pq.Array(filter.Types)) + ")"
+	}
+	if filter.MinAmount != nil {
+		query += " AND t.amount >= " + arg(*filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query += " AND t.amount <= " + arg(*filter.MaxAmount)
+	}
+	if filter.Counterparty != "" {
+		query += " AND u.phone = " + arg(filter.Counterparty)
+	}
+	if filter.CursorTime != nil {
+		query += " AND (t.created_at, t.id) < (" + arg(*filter.CursorTime) + ", " + arg(filter.CursorID) + ")"
+	}
+	query += " ORDER BY t.created_at DESC, t.id DESC LIMIT " + arg(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.Error.Printf("[AccountRepository] Failed to fetch transactions: %v", err)
 		return nil, errs.ErrInternal
@@ -72,6 +126,8 @@ func (r *accountRepo) GetTransactions(ctx context.Context, accountID int, start,
 		var phone sql.NullString
 
 		if err := rows.Scan(
+			&t.ID,
+			&t.AccountFrom,
 			&t.AccountTo,
 			&t.Amount,
 			&t.Type,
@@ -79,17 +135,26 @@ func (r *accountRepo) GetTransactions(ctx context.Context, accountID int, start,
 			&phone,
 		); err != nil {
 			logger.Error.Printf("[AccountRepository] Scan error: %v", err)
-			continue
+			return nil, errs.ErrInternal
 		}
 
-		if t.Type == "transfer" && phone.Valid {
-			t.ToPhone = &phone.String
-		} else {
-			t.ToPhone = nil
+		t.Direction = models.DirectionIn
+		if t.AccountFrom == accountID {
+			t.Direction = models.DirectionOut
+		}
+		if phone.Valid {
+			t.CounterpartyPhone = &phone.String
+			if t.Direction == models.DirectionOut {
+				t.ToPhone = &phone.String
+			}
 		}
 
 		txs = append(txs, t)
 	}
+	if err := rows.Err(); err != nil {
+		logger.Error.Printf("[AccountRepository] Rows error: %v", err)
+		return nil, errs.ErrInternal
+	}
 
 	logger.Info.Printf(
 		"[AccountRepository] Fetched %d transactions for accountID=%d",
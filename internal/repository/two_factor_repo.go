@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID int) (models.TwoFactorState, error)
+	SavePendingSecret(ctx context.Context, userID int, secret string) error
+	Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	Disable(ctx context.Context, userID int) error
+	AcceptStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+}
+
+type twoFactorRepo struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return &twoFactorRepo{db: db}
+}
+
+func (r *twoFactorRepo) Get(ctx context.Context, userID int) (models.TwoFactorState, error) {
+	var (
+		state    models.TwoFactorState
+		secret   sql.NullString
+		lastStep sql.NullInt64
+	)
+	err := r.db.QueryRowContext(ctx,
+		`SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1`, userID,
+	).Scan(&secret, &state.Enabled, &lastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TwoFactorState{}, errs.ErrUserNotFound
+	}
+	if err != nil {
+		logger.Error.Printf("[TwoFactorRepository] Get failed: userID=%d, err=%v", userID, err)
+		return models.TwoFactorState{}, errs.ErrInternal
+	}
+
+	state.Secret = secret.String
+	state.LastStep = lastStep.Int64
+	return state, nil
+}
+
+// SavePendingSecret сохраняет секрет незавершенного подключения; включенную 2FA не трогает
+func (r *twoFactorRepo) SavePendingSecret(ctx context.Context, userID int, secret string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_secret = $2, totp_last_step = NULL WHERE id = $1 AND NOT totp_enabled`,
+		userID, secret,
+	)
+	if err != nil {
+		logger.Error.Printf("[TwoFactorRepository] SavePendingSecret failed: userID=%d, err=%v", userID, err)
+		return errs.ErrInternal
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// Enable включает 2FA с уже сохраненным секретом и записывает коды восстановления.
+// step — шаг кода, которым подтверждено подключение; повторно он не примется.
+func (r *twoFactorRepo) Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE users SET totp_enabled = TRUE, totp_last_step = $2
+			 WHERE id = $1 AND totp_secret IS NOT NULL AND NOT totp_enabled`,
+			userID, step,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrTwoFactorEnabled
+		}
+		return replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes)
+	})
+	if err != nil && !errors.Is(err, errs.ErrTwoFactorEnabled) {
+		logger.Error.Printf("[TwoFactorRepository] Enable failed: userID=%d, err=%v", userID, err)
+		return errs.ErrInternal
+	}
+	return err
+}
+
+// Disable отключает 2FA, удаляя секрет и коды восстановления
+func (r *twoFactorRepo) Disable(ctx context.Context, userID int) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = NULL WHERE id = $1`,
+			userID,
+		); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("[TwoFactorRepository] Disable failed: userID=%d, err=%v", userID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+// AcceptStep запоминает шаг принятого кода. Возвращает false, если код этого или более
+// позднего шага уже использовался — так один и тот же код нельзя предъявить дважды.
+func (r *twoFactorRepo) AcceptStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_last_step = $2
+		 WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`,
+		userID, step,
+	)
+	if err != nil {
+		logger.Error.Printf("[TwoFactorRepository] AcceptStep failed: userID=%d, err=%v", userID, err)
+		return false, errs.ErrInternal
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// UseRecoveryCode гасит код восстановления; false — кода нет или он уже использован
+func (r *twoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE recovery_codes SET used_at = NOW()
+		 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		logger.Error.Printf("[TwoFactorRepository] UseRecoveryCode failed: userID=%d, err=%v", userID, err)
+		return false, errs.ErrInternal
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func (r *twoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	})
+	if err != nil {
+		logger.Error.Printf("[TwoFactorRepository] ReplaceRecoveryCodes failed: userID=%d, err=%v", userID, err)
+		return errs.ErrInternal
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`,
+		userID, pq.Array(codeHashes),
+	)
+	return err
+}
+
+func (r *twoFactorRepo) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"WalletX/internal/handlers/transaction"
+	"WalletX/models"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDB — минимальный драйвер, который запоминает, где выполнялся каждый запрос:
+// в транзакции ("tx") или на отдельном соединении пула ("db"), и чем закончилась транзакция.
+type recordingDB struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (d *recordingDB) record(e string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, e)
+}
+
+func (d *recordingDB) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{db: d}, nil
+}
+func (d *recordingDB) Driver() driver.Driver { return nil }
+
+type recordingConn struct {
+	db   *recordingDB
+	inTx bool
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *recordingConn) Close() error { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordingConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.inTx = true
+	return recordingTx{c}, nil
+}
+
+func (c *recordingConn) where(query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	if c.inTx {
+		return "tx: " + q
+	}
+	return "db: " + q
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.record(c.where(query))
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(c.where(query))
+	switch {
+	case strings.Contains(query, "INSERT INTO receipts"):
+		return nil, errors.New("receipts: constraint violation")
+	case strings.Contains(query, "SELECT balance, is_frozen"):
+		return &recordingRows{cols: []string{"balance", "is_frozen"}, vals: []driver.Value{100.0, false}}, nil
+	}
+	return &recordingRows{}, nil
+}
+
+type recordingTx struct{ c *recordingConn }
+
+func (t recordingTx) Commit() error   { t.c.inTx = false; t.c.db.record("commit"); return nil }
+func (t recordingTx) Rollback() error { t.c.inTx = false; t.c.db.record("rollback"); return nil }
+
+type recordingRows struct {
+	cols []string
+	vals []driver.Value
+	done bool
+}
+
+func (r *recordingRows) Columns() []string { return r.cols }
+func (r *recordingRows) Close() error      { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if r.done || r.vals == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.vals)
+	return nil
+}
+
+func TestFailedReceiptRollsBackBalanceChange(t *testing.T) {
+	rec := &recordingDB{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+
+	tm := transaction.NewTransactionManager(db)
+	accounts := NewAccountRepository(db)
+	receipts := NewReceiptRepository(db)
+
+	err := tm.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		if err := accounts.DecreaseBalance(txCtx, 1, 10); err != nil {
+			return err
+		}
+		if err := accounts.IncreaseBalance(txCtx, 2, 10); err != nil {
+			return err
+		}
+		_, err := receipts.Create(txCtx, models.Receipt{ReceiptNumber: "R-1", TransactionID: 1, Type: "transfer"})
+		return err
+	})
+	if err == nil {
+		t.Fatal("expected receipt insert error")
+	}
+
+	if len(rec.events) == 0 || rec.events[len(rec.events)-1] != "rollback" {
+		t.Fatalf("transaction was not rolled back: %v", rec.events)
+	}
+	for _, e := range rec.events {
+		if strings.HasPrefix(e, "db: ") {
+			t.Errorf("query ran outside the transaction: %s", e)
+		}
+		if e == "commit" {
+			t.Errorf("transaction must not be committed: %v", rec.events)
+		}
+	}
+
+	var updates int
+	for _, e := range rec.events {
+		if strings.HasPrefix(e, "tx: UPDATE accounts") {
+			updates++
+		}
+	}
+	if updates != 2 {
+		t.Fatalf("expected both balance updates inside the transaction, got %d: %v", updates, rec.events)
+	}
+}
@@ -2,42 +2,62 @@ package repository
 
 import (
 	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/pii"
+	"context"
 	"database/sql"
 	"fmt"
 )
 
 type UserProfileRepository interface {
-	GetProfileByID(id int) (models.UserProfileResponse, error)
+	GetProfileByID(ctx context.Context, id int) (models.UserProfileResponse, error)
 	GetBalanceByUserID(userID int) (models.UserBalanceResponse, error)
 }
 
 type userProfileRepo struct {
-	db *sql.DB
+	db  *sql.DB
+	pii *pii.Cipher
 }
 
-func NewUserProfileRepository(db *sql.DB) UserProfileRepository {
+func NewUserProfileRepository(db *sql.DB, cipher *pii.Cipher) UserProfileRepository {
 	return &userProfileRepo{
-		db: db,
+		db:  db,
+		pii: cipher,
 	}
 }
 
-func (r *userProfileRepo) GetProfileByID(id int) (models.UserProfileResponse, error) {
-	var user models.UserProfileResponse
+// sealedProfile — персональные данные профиля в том виде, в каком они прочитаны из базы
+type sealedProfile struct {
+	names         [3]sql.NullString
+	passport      sql.NullString
+	passportIndex sql.NullString
+}
+
+// GetProfileByID возвращает профиль с расшифрованными именами. Значения, записанные открытым
+// текстом или старым ключом, здесь же перешифровываются активным ключом.
+func (r *userProfileRepo) GetProfileByID(ctx context.Context, id int) (models.UserProfileResponse, error) {
+	var (
+		user   models.UserProfileResponse
+		sealed sealedProfile
+	)
 
 	query := `
-        SELECT id, phone, first_name, last_name, middle_name, is_verified
+        SELECT id, phone, first_name, last_name, middle_name, passport_number, passport_index, is_verified, verification_tier
         FROM users
         WHERE id = $1
         LIMIT 1
     `
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 	err := row.Scan(
 		&user.ID,
 		&user.Phone,
-		&user.FirstName,
-		&user.LastName,
-		&user.MiddleName,
+		&sealed.names[0],
+		&sealed.names[1],
+		&sealed.names[2],
+		&sealed.passport,
+		&sealed.passportIndex,
 		&user.IsVerified,
+		&user.VerificationTier,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -46,9 +66,70 @@ func (r *userProfileRepo) GetProfileByID(id int) (models.UserProfileResponse, er
 		return user, fmt.Errorf("failed to scan user: %w", err)
 	}
 
+	stale := sealed.passport.Valid && (r.pii.Stale(sealed.passport.String) || !sealed.passportIndex.Valid)
+	columns := piiColumns("users")
+	for i, field := range []*string{&user.FirstName, &user.LastName, &user.MiddleName} {
+		plaintext, fieldStale, err := decryptPII(r.pii, sealed.names[i], pii.AAD(columns[i], user.ID))
+		if err != nil {
+			return user, fmt.Errorf("failed to decrypt profile: %w", err)
+		}
+		*field = plaintext
+		stale = stale || fieldStale
+	}
+
+	if stale {
+		if err := r.reseal(ctx, user, sealed); err != nil {
+			logger.Error.Printf("[UserProfileRepository] Failed to re-encrypt profile of userID=%d: %v", id, err)
+		}
+	}
 	return user, nil
 }
 
+// reseal перешифровывает персональные данные пользователя активным ключом и заполняет слепой индекс
+// паспорта. Запись обновляется, только если с момента чтения ее не изменили (например, одобрение KYC
+// не перенесло в профиль новые данные): иначе перешифрованные старые значения затерли бы новые.
+func (r *userProfileRepo) reseal(ctx context.Context, user models.UserProfileResponse, old sealedProfile) error {
+	columns := piiColumns("users")
+	values := make([]interface{}, 0, 11)
+	values = append(values, user.ID)
+	for i, field := range []string{user.FirstName, user.LastName, user.MiddleName} {
+		sealed, err := encryptPII(r.pii, field, pii.AAD(columns[i], user.ID))
+		if err != nil {
+			return err
+		}
+		values = append(values, sealed)
+	}
+
+	passport := old.passport
+	var passportIndex sql.NullString
+	if passport.Valid {
+		aad := pii.AAD(columns[3], user.ID)
+		plaintext, err := r.pii.Decrypt(passport.String, aad)
+		if err != nil {
+			return err
+		}
+		if passport.String, err = r.pii.Rewrap(passport.String, aad); err != nil {
+			return err
+		}
+		passportIndex = sql.NullString{String: r.pii.BlindIndex(plaintext), Valid: true}
+	}
+	values = append(values, passport, passportIndex)
+	values = append(values, old.names[0], old.names[1], old.names[2], old.passport, old.passportIndex)
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users
+		 SET first_name = $2, last_name = $3, middle_name = $4, passport_number = $5, passport_index = $6
+		 WHERE id = $1
+		   AND first_name IS NOT DISTINCT FROM $7
+		   AND last_name IS NOT DISTINCT FROM $8
+		   AND middle_name IS NOT DISTINCT FROM $9
+		   AND passport_number IS NOT DISTINCT FROM $10
+		   AND passport_index IS NOT DISTINCT FROM $11`,
+		values...,
+	)
+	return err
+}
+
 func (r *userProfileRepo) GetBalanceByUserID(userID int) (models.UserBalanceResponse, error) {
 	var balance models.UserBalanceResponse
 
@@ -4,10 +4,16 @@ import (
 	"WalletX/internal/handlers/transaction"
 	"WalletX/internal/repository"
 	"WalletX/models"
+	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"WalletX/pkg/tracing"
 	"context"
 	"errors"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type AccountService struct {
@@ -41,20 +47,54 @@ type PaymentService struct {
 	AccountRepo     repository.AccountRepository
 	TransactionRepo repository.TransactionRepository
 	ServiceRepo     repository.ServicesRepository
+	ReceiptRepo     repository.ReceiptRepository
 	TM              transaction.TransactionManager
+	StepUp          *StepUpService
 }
 
-func NewPaymentService(accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, serviceRepo repository.ServicesRepository, tm transaction.TransactionManager) *PaymentService {
+func NewPaymentService(accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, serviceRepo repository.ServicesRepository, receiptRepo repository.ReceiptRepository, tm transaction.TransactionManager, stepUp *StepUpService) *PaymentService {
 	return &PaymentService{
 		AccountRepo:     accountRepo,
 		TransactionRepo: transactionRepo,
 		ServiceRepo:     serviceRepo,
+		ReceiptRepo:     receiptRepo,
 		TM:              tm,
+		StepUp:          stepUp,
 	}
 }
 
-func (s *PaymentService) Pay(ctx context.Context, userID, toID int, amount float64, transactionType string) error {
-	return s.TM.WithinTransaction(ctx, func(txCtx context.Context) error {
+// errInsufficientBalance — отказ платежа из-за нехватки средств; текст ошибки виден клиенту в details
+var errInsufficientBalance = errors.New("insufficient balance")
+
+func (s *PaymentService) Pay(ctx context.Context, userID, toID int, amount float64, transactionType, subscriberAccount string) (models.Receipt, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "PaymentService.Pay", trace.WithAttributes(
+		attribute.Int("user.id", userID),
+		attribute.Int("account.to", toID),
+		attribute.String("payment.type", transactionType),
+		attribute.Float64("amount", amount),
+	))
+	receipt, err := s.pay(ctx, userID, toID, amount, transactionType, subscriberAccount)
+	outcome := moneyOutcome(err)
+	span.SetAttributes(attribute.String("outcome", outcome))
+	tracing.End(span, err)
+
+	metrics.ObserveMoneyOperation("payment", transactionType, outcome, amount)
+	return receipt, err
+}
+
+func (s *PaymentService) pay(ctx context.Context, userID, toID int, amount float64, transactionType, subscriberAccount string) (models.Receipt, error) {
+	if amount <= 0 {
+		logger.Warn.Printf("[PaymentService] Invalid payment amount: %.2f", amount)
+		return models.Receipt{}, errs.ErrInvalidAmount
+	}
+
+	op := StepUpOperation{Action: models.StepUpActionPayment, Amount: amount, ToAccountID: toID, Reference: subscriberAccount}
+	if err := s.StepUp.Authorize(ctx, userID, op); err != nil {
+		return models.Receipt{}, err
+	}
+
+	var receipt models.Receipt
+	err := s.TM.WithinTransaction(ctx, func(txCtx context.Context) error {
 
 		from, err := s.AccountRepo.GetByUserID(txCtx, userID)
 		if err != nil {
@@ -70,9 +110,14 @@ func (s *PaymentService) Pay(ctx context.Context, userID, toID int, amount float
 
 		logger.Info.Printf("[PaymentService] paying from %d to %d with amount %.2f", from.ID, to.ID, amount)
 
+		if from.IsFrozen {
+			logger.Warn.Printf("[PaymentService] account %d is frozen", from.ID)
+			return errs.ErrAccountFrozen
+		}
+
 		if from.Balance < amount {
 			logger.Warn.Printf("[PaymentService] insufficient balance: have=%.2f need=%.2f", from.Balance, amount)
-			return errors.New("insufficient balance")
+			return errInsufficientBalance
 		}
 
 		err = s.AccountRepo.DecreaseBalance(txCtx, from.ID, amount) // Используем from.ID
@@ -95,13 +140,29 @@ func (s *PaymentService) Pay(ctx context.Context, userID, toID int, amount float
 			CreatedAt:   time.Now(),
 		}
 
-		_, err = s.TransactionRepo.CreateTransaction(transaction)
+		created, err := s.TransactionRepo.CreateTransaction(txCtx, transaction)
 		if err != nil {
 			logger.Error.Printf("[PaymentService] Failed to create transaction: %v", err)
 			return err
 		}
 
+		service, err := s.ServiceRepo.GetByID(toID)
+		if err != nil {
+			logger.Warn.Printf("[PaymentService] service details not found for id=%d: %v", toID, err)
+			service = &models.Services{Name: transactionType}
+		}
+
+		receipt, err = issueReceipt(txCtx, s.ReceiptRepo, created, service, subscriberAccount)
+		if err != nil {
+			return err
+		}
+
 		logger.Info.Printf("[PaymentService] SUCCESS transfer from=%d to=%d amount=%.2f type=%s", from.ID, to.ID, amount, transactionType)
 		return nil
 	})
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	return receipt, nil
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultAdminUserLimit = 20
+	maxFreezeReasonLength = 255
+)
+
+// AdminService — операции поддержки и администраторов. Права проверяются на уровне маршрутов,
+// здесь только правила самих операций; каждое изменение пишется в лог с id исполнителя.
+type AdminService struct {
+	Repo   repository.AdminRepository
+	Users  repository.UserRepository
+	Tokens *TokenService
+}
+
+func NewAdminService(repo repository.AdminRepository, users repository.UserRepository, tokens *TokenService) *AdminService {
+	return &AdminService{Repo: repo, Users: users, Tokens: tokens}
+}
+
+// FindUsers ищет пользователей по точному номеру телефона или по его началу
+func (s *AdminService) FindUsers(ctx context.Context, phone string) ([]models.AdminUser, error) {
+	phone = strings.TrimSpace(phone)
+	filter := models.AdminUserFilter{Limit: defaultAdminUserLimit}
+	switch {
+	case phone == "":
+		return nil, fmt.Errorf("%w: phone is required", errs.ErrValidationFailed)
+	case strings.HasSuffix(phone, "*"):
+		filter.PhonePrefix = strings.TrimSuffix(phone, "*")
+		if strings.ContainsAny(filter.PhonePrefix, "%_\\*") || len(filter.PhonePrefix) < 4 {
+			return nil, fmt.Errorf("%w: phone prefix must have at least 4 characters", errs.ErrValidationFailed)
+		}
+	default:
+		filter.Phone = phone
+	}
+	return s.Repo.FindUsers(ctx, filter)
+}
+
+func (s *AdminService) GetUser(ctx context.Context, userID int) (models.AdminUser, error) {
+	users, err := s.Repo.FindUsers(ctx, models.AdminUserFilter{UserID: userID, Limit: 1})
+	if err != nil {
+		return models.AdminUser{}, err
+	}
+	if len(users) == 0 {
+		return models.AdminUser{}, errs.ErrUserNotFound
+	}
+	return users[0], nil
+}
+
+// SetRole меняет роль пользователя и завершает все его сессии, чтобы новые права
+// (или их отзыв) вступили в силу сразу, а не после истечения access-токенов.
+// Менять роль самому себе нельзя, чтобы последний администратор не лишился доступа.
+func (s *AdminService) SetRole(ctx context.Context, actorID, userID int, role string) error {
+	if !models.ValidRole(role) {
+		return fmt.Errorf("%w: unknown role %q", errs.ErrValidationFailed, role)
+	}
+	if actorID == userID {
+		return fmt.Errorf("%w: cannot change your own role", errs.ErrValidationFailed)
+	}
+
+	if err := s.Users.SetRole(userID, role); err != nil {
+		return err
+	}
+	if err := s.Tokens.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[AdminService] userID=%d set role of userID=%d to %s", actorID, userID, role)
+	return nil
+}
+
+// FreezeAccount запрещает списания со счета. Причина обязательна и сохраняется вместе со временем заморозки.
+func (s *AdminService) FreezeAccount(ctx context.Context, actorID, accountID int, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxFreezeReasonLength {
+		return fmt.Errorf("%w: reason is required (up to %d characters)", errs.ErrValidationFailed, maxFreezeReasonLength)
+	}
+
+	if err := s.Repo.SetAccountFrozen(ctx, accountID, true, reason); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[AdminService] userID=%d froze accountID=%d: %s", actorID, accountID, reason)
+	return nil
+}
+
+func (s *AdminService) UnfreezeAccount(ctx context.Context, actorID, accountID int) error {
+	if err := s.Repo.SetAccountFrozen(ctx, accountID, false, ""); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[AdminService] userID=%d unfroze accountID=%d", actorID, accountID)
+	return nil
+}
+
+// SearchTransactions ищет операции по всем счетам с той же постраничной навигацией, что и история пользователя
+func (s *AdminService) SearchTransactions(ctx context.Context, filter models.TransactionSearchFilter) (models.AdminTransactionPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+	if filter.Limit > maxHistoryLimit {
+		filter.Limit = maxHistoryLimit
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return models.AdminTransactionPage{}, errs.ErrValidationFailed
+	}
+	if filter.Direction != "" && filter.AccountID == 0 {
+		return models.AdminTransactionPage{}, fmt.Errorf("%w: direction requires account_id", errs.ErrValidationFailed)
+	}
+
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	items, err := s.Repo.SearchTransactions(ctx, filter)
+	if err != nil {
+		return models.AdminTransactionPage{}, err
+	}
+
+	page := models.AdminTransactionPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const analyticsCounterpartiesLimit = 10
+
+type AnalyticsService struct {
+	Repo        repository.AnalyticsRepository
+	AccountRepo repository.AccountRepository
+}
+
+func NewAnalyticsService(repo repository.AnalyticsRepository, accountRepo repository.AccountRepository) *AnalyticsService {
+	return &AnalyticsService{
+		Repo:        repo,
+		AccountRepo: accountRepo,
+	}
+}
+
+// changePercent возвращает изменение относительно прошлого периода; nil, если сравнивать не с чем
+func changePercent(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	v := math.Round((current-previous)/previous*10000) / 100
+	return &v
+}
+
+// GetSpending считает траты и поступления за период и сравнивает их с предыдущим периодом той же длины
+func (s *AnalyticsService) GetSpending(ctx context.Context, userID int, start, end time.Time, period string) (models.SpendingAnalytics, error) {
+	switch period {
+	case "":
+		period = models.AnalyticsPeriodDay
+	case models.AnalyticsPeriodDay, models.AnalyticsPeriodWeek, models.AnalyticsPeriodMonth:
+	default:
+		return models.SpendingAnalytics{}, fmt.Errorf("%w: period must be day, week or month", errs.ErrValidationFailed)
+	}
+	if !end.After(start) {
+		return models.SpendingAnalytics{}, fmt.Errorf("%w: end must be after start", errs.ErrValidationFailed)
+	}
+
+	account, err := s.AccountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return models.SpendingAnalytics{}, err
+	}
+
+	result := models.SpendingAnalytics{From: start, To: end, Period: period}
+
+	if result.Totals, err = s.Repo.GetTotals(ctx, account.ID, start, end); err != nil {
+		return models.SpendingAnalytics{}, err
+	}
+
+	prevEnd := start.Add(-time.Nanosecond)
+	prevStart := start.Add(-end.Sub(start))
+	previous, err := s.Repo.GetTotals(ctx, account.ID, prevStart, prevEnd)
+	if err != nil {
+		return models.SpendingAnalytics{}, err
+	}
+	result.Previous = models.AnalyticsComparison{
+		From:             prevStart,
+		To:               prevEnd,
+		Totals:           previous,
+		InChangePercent:  changePercent(result.Totals.In, previous.In),
+		OutChangePercent: changePercent(result.Totals.Out, previous.Out),
+	}
+
+	if result.ByPeriod, err = s.Repo.GetByPeriod(ctx, account.ID, start, end, period); err != nil {
+		return models.SpendingAnalytics{}, err
+	}
+	if result.ByCategory, err = s.Repo.GetByCategory(ctx, account.ID, start, end); err != nil {
+		return models.SpendingAnalytics{}, err
+	}
+	if result.ByCounterparty, err = s.Repo.GetByCounterparty(ctx, account.ID, start, end, analyticsCounterpartiesLimit); err != nil {
+		return models.SpendingAnalytics{}, err
+	}
+
+	logger.Info.Printf("[AnalyticsService] Built %s analytics for accountID=%d", period, account.ID)
+	return result, nil
+}
@@ -1,23 +1,87 @@
 package service
 
 import (
+	"WalletX/config"
 	"WalletX/internal/repository"
 	"WalletX/models"
 	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"WalletX/pkg/passwordpolicy"
+	"context"
+	"errors"
 	"regexp"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 var phoneRegex = regexp.MustCompile(`^\+\d{12}$`)
 
+const (
+	oneTimePurposeRegistration = "registration"
+	registrationTokenTTL       = 15 * time.Minute
+	oneTimePurposeLogin2FA     = "login_2fa"
+	mfaTokenTTL                = 5 * time.Minute
+)
+
 type UserService struct {
-	Repo repository.UserRepository
+	Repo      repository.UserRepository
+	OTP       *OTPService
+	Tokens    *TokenService
+	StepUp    *StepUpService
+	TwoFactor *TwoFactorService
+	Devices   *DeviceService
+}
+
+func NewUserService(repo repository.UserRepository, otp *OTPService, tokens *TokenService, stepUp *StepUpService, twoFactor *TwoFactorService, devices *DeviceService) *UserService {
+	return &UserService{Repo: repo, OTP: otp, Tokens: tokens, StepUp: stepUp, TwoFactor: twoFactor, Devices: devices}
 }
 
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{Repo: repo}
+// lockoutPolicy — настройки блокировки из config.json с разумными значениями по умолчанию
+type lockoutPolicy struct {
+	maxAttempts int
+	window      time.Duration
+	baseLock    time.Duration
+	maxLock     time.Duration
+}
+
+func currentLockoutPolicy() lockoutPolicy {
+	cfg := config.AppSettings.AuthParams.Lockout
+	p := lockoutPolicy{
+		maxAttempts: cfg.MaxAttempts,
+		window:      time.Duration(cfg.AttemptWindowMinutes) * time.Minute,
+		baseLock:    time.Duration(cfg.BaseLockMinutes) * time.Minute,
+		maxLock:     time.Duration(cfg.MaxLockMinutes) * time.Minute,
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = 3
+	}
+	if p.window <= 0 {
+		p.window = 15 * time.Minute
+	}
+	if p.baseLock <= 0 {
+		p.baseLock = 15 * time.Minute
+	}
+	if p.maxLock <= 0 {
+		p.maxLock = 24 * time.Hour
+	}
+	if p.maxLock < p.baseLock {
+		p.maxLock = p.baseLock
+	}
+	return p
+}
+
+// lockDuration удваивает блокировку за каждую предыдущую блокировку подряд
+func (p lockoutPolicy) lockDuration(previousLocks int) time.Duration {
+	d := p.baseLock
+	for i := 0; i < previousLocks && d < p.maxLock; i++ {
+		d *= 2
+	}
+	if d > p.maxLock {
+		d = p.maxLock
+	}
+	return d
 }
 
 func (s *UserService) GetByPhone(phone string) (models.User, error) {
@@ -53,10 +117,32 @@ func (s *UserService) RegisterUser(phone string) (models.User, error) {
 	return created, nil
 }
 
-func (s *UserService) SetPassword(userID int, password string) error {
-	if len(password) != 8 {
-		logger.Warn.Printf("SetPassword: weak password for userID=%d", userID)
-		return errs.ErrWeakPassword
+func currentPasswordPolicy() passwordpolicy.Policy {
+	return passwordpolicy.New(config.AppSettings.AuthParams.PasswordPolicy)
+}
+
+func validatePassword(password string) error {
+	return currentPasswordPolicy().Validate(password)
+}
+
+// storePassword сохраняет новый пароль, не давая повторить один из history_size последних
+func (s *UserService) storePassword(userID int, password string) error {
+	policy := currentPasswordPolicy()
+
+	keep := policy.HistorySize()
+	if keep > 0 {
+		history, err := s.Repo.GetPasswordHistory(userID, keep)
+		if err != nil {
+			return err
+		}
+		for _, old := range history {
+			if bcrypt.CompareHashAndPassword([]byte(old), []byte(password)) == nil {
+				logger.Warn.Printf("SetPassword: reused password for userID=%d", userID)
+				return errs.ErrPasswordReused
+			}
+		}
+	} else {
+		keep = 1
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -65,62 +151,257 @@ func (s *UserService) SetPassword(userID int, password string) error {
 		return errs.ErrInternal
 	}
 
-	if err := s.Repo.UpdatePassword(userID, string(hash)); err != nil {
+	if err := s.Repo.UpdatePassword(userID, string(hash), keep); err != nil {
 		logger.Error.Printf("SetPassword: failed to update DB: %v", err)
 		return errs.ErrInternal
 	}
+	return nil
+}
+
+// IssueRegistrationToken выдается после подтверждения номера и разрешает один раз задать пароль
+func (s *UserService) IssueRegistrationToken(ctx context.Context, userID int) (models.RegisterResponse, error) {
+	token, err := s.Tokens.IssueOneTimeToken(ctx, oneTimePurposeRegistration, userID, registrationTokenTTL)
+	if err != nil {
+		return models.RegisterResponse{}, err
+	}
+	return models.RegisterResponse{
+		UserID:            userID,
+		RegistrationToken: token,
+		ExpiresIn:         int(registrationTokenTTL.Seconds()),
+	}, nil
+}
+
+// SetPassword задает пароль при регистрации. Пользователь определяется только по токену регистрации.
+func (s *UserService) SetPassword(ctx context.Context, registrationToken, password string) (int, error) {
+	if err := validatePassword(password); err != nil {
+		logger.Warn.Printf("SetPassword: weak password")
+		return 0, err
+	}
+
+	userID, err := s.Tokens.ConsumeOneTimeToken(ctx, oneTimePurposeRegistration, registrationToken)
+	if err != nil {
+		logger.Warn.Printf("SetPassword: invalid registration token: %v", err)
+		return 0, err
+	}
+
+	if err := s.storePassword(userID, password); err != nil {
+		return 0, err
+	}
 
 	logger.Info.Printf("SetPassword: success for userID=%d", userID)
-	return nil
+	return userID, nil
 }
 
 func (s *UserService) Login(phone, password string) (*models.User, error) {
 	user, err := s.Repo.GetByPhone(phone)
 	if err != nil {
 		logger.Warn.Printf("Login failed: user not found for phone %s", phone)
+		metrics.LoginFailures.WithLabelValues(metrics.LoginUnknownUser).Inc()
 		return nil, errs.ErrUserNotFound
 	}
 
-	if user.DeviceID {
-		logger.Warn.Printf("Login blocked: user %d is blocked", user.ID)
-		return nil, errs.ErrUserBlocked
+	now := time.Now()
+	if err := checkLoginAllowed(user, now); err != nil {
+		return nil, err
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
-		// Прибавляем попытку
-		if err := s.Repo.IncrementPasswordAttempts(user.ID); err != nil {
+		metrics.LoginFailures.WithLabelValues(metrics.LoginWrongPassword).Inc()
+		if err := registerFailedLogin(s.Repo, user, now); err != nil {
 			return nil, err
 		}
-		user.PasswordAttempts++
-
-		if user.PasswordAttempts >= 3 {
-			if err := s.Repo.BlockUser(user.ID); err != nil {
-				return nil, err
-			}
-			logger.Warn.Printf("Login blocked: user %d reached max attempts", user.ID)
-			return nil, errs.ErrUserBlocked
-		}
-
 		logger.Warn.Printf("Login failed: wrong password for user %d", user.ID)
 		return nil, errs.ErrWrongPassword
 	}
 
-	s.Repo.ResetPasswordAttempts(user.ID)
+	s.Repo.ResetLoginFailures(user.ID)
+
+	if err := checkPasswordExpired(user, now); err != nil {
+		return nil, err
+	}
+
 	logger.Info.Printf("User %d logged in successfully", user.ID)
 	return &user, nil
 }
 
-func (s *UserService) VerifyUser(userID int, firstName, lastName, middleName, passport string) error {
-	if firstName == "" || lastName == "" || middleName == "" || passport == "" {
-		logger.Warn.Printf("VerifyUser: required fields missing, userID=%d", userID)
-		return errs.ErrRequiredFields
+// checkLoginAllowed отклоняет вход заблокированного администратором или временно заблокированного пользователя
+func checkLoginAllowed(user models.User, now time.Time) error {
+	if user.IsBlocked {
+		logger.Warn.Printf("Login blocked: user %d is blocked", user.ID)
+		metrics.LoginFailures.WithLabelValues(metrics.LoginBlocked).Inc()
+		return errs.ErrUserBlocked
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		logger.Warn.Printf("Login rejected: user %d is locked until %s", user.ID, user.LockedUntil.Format(time.RFC3339))
+		metrics.LoginFailures.WithLabelValues(metrics.LoginLocked).Inc()
+		return &errs.LockedError{Until: *user.LockedUntil}
+	}
+	return nil
+}
+
+// checkPasswordExpired отклоняет вход с истекшим паролем: его нужно сменить, прежде чем получить токены
+func checkPasswordExpired(user models.User, now time.Time) error {
+	if days := currentPasswordPolicy().ExpiryDays(); days > 0 && user.PasswordChangedAt != nil &&
+		now.After(user.PasswordChangedAt.AddDate(0, 0, days)) {
+		logger.Warn.Printf("Login rejected: password of user %d has expired", user.ID)
+		return errs.ErrPasswordExpired
 	}
+	return nil
+}
 
-	if err := s.Repo.UpdateVerification(userID, firstName, lastName, middleName, passport); err != nil {
-		logger.Error.Printf("VerifyUser: failed to update DB: %v", err)
-		return errs.ErrInternal
+// LoginWithDeviceKey — вход без пароля по подписи challenge ключом устройства.
+// Неверная подпись учитывается в блокировке так же, как неверный пароль.
+func (s *UserService) LoginWithDeviceKey(ctx context.Context, req models.DeviceLoginRequest) (int, error) {
+	user, err := s.Repo.GetByPhone(req.Phone)
+	if err != nil {
+		logger.Warn.Printf("Device key login failed: user not found for phone %s", req.Phone)
+		metrics.LoginFailures.WithLabelValues(metrics.LoginUnknownUser).Inc()
+		return 0, errs.ErrInvalidSignature
+	}
+
+	now := time.Now()
+	if err := checkLoginAllowed(user, now); err != nil {
+		return 0, err
+	}
+
+	if err := s.Devices.VerifyLoginSignature(ctx, user.ID, req.DeviceID, req.Challenge, req.Signature); err != nil {
+		if errors.Is(err, errs.ErrInvalidSignature) {
+			metrics.LoginFailures.WithLabelValues(metrics.LoginInvalidSignature).Inc()
+			if lockErr := registerFailedLogin(s.Repo, user, now); lockErr != nil {
+				return 0, lockErr
+			}
+		}
+		return 0, err
+	}
+
+	s.Repo.ResetLoginFailures(user.ID)
+
+	if err := checkPasswordExpired(user, now); err != nil {
+		return 0, err
+	}
+
+	logger.Info.Printf("User %d authenticated with device key", user.ID)
+	return user.ID, nil
+}
+
+// registerFailedLogin учитывает неудачную попытку входа и блокирует учетную запись,
+// если попыток стало max_attempts. Возвращает LockedError, если блокировка наступила.
+// Неверный пароль при смене пароля или отключении 2FA учитывается так же, как при входе.
+func registerFailedLogin(repo repository.UserRepository, user models.User, now time.Time) error {
+	policy := currentLockoutPolicy()
+
+	// Прибавляем попытку
+	attempts, err := repo.RegisterFailedLogin(user.ID, policy.window)
+	if err != nil {
+		return err
+	}
+
+	if attempts >= policy.maxAttempts {
+		until := now.Add(policy.lockDuration(user.LockCount))
+		if err := repo.LockUser(user.ID, until); err != nil {
+			return err
+		}
+		logger.Warn.Printf("Login locked: user %d reached max attempts, locked until %s", user.ID, until.Format(time.RFC3339))
+		metrics.LoginLockouts.Inc()
+		return &errs.LockedError{Until: until}
+	}
+	return nil
+}
+
+// StartTwoFactorLogin вызывается после верного пароля, если у пользователя включена 2FA:
+// вместо токенов выдается одноразовый mfa_token для второго шага входа
+func (s *UserService) StartTwoFactorLogin(ctx context.Context, userID int) (models.TwoFactorChallenge, error) {
+	token, err := s.Tokens.IssueOneTimeToken(ctx, oneTimePurposeLogin2FA, userID, mfaTokenTTL)
+	if err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+	return models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		MFAToken:          token,
+		ExpiresIn:         int(mfaTokenTTL.Seconds()),
+	}, nil
+}
+
+// CompleteTwoFactorLogin завершает вход вторым фактором. mfa_token одноразовый: после неверного
+// кода вход начинается заново с пароля, а ошибка считается неудачной попыткой входа.
+func (s *UserService) CompleteTwoFactorLogin(ctx context.Context, mfaToken, code, recoveryCode string) (int, error) {
+	userID, err := s.Tokens.ConsumeOneTimeToken(ctx, oneTimePurposeLogin2FA, mfaToken)
+	if err != nil {
+		logger.Warn.Printf("Login 2FA: invalid mfa token: %v", err)
+		return 0, err
+	}
+
+	user, err := s.Repo.GetByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if err := checkLoginAllowed(user, now); err != nil {
+		return 0, err
+	}
+
+	if err := s.TwoFactor.Verify(ctx, userID, code, recoveryCode); err != nil {
+		if errors.Is(err, errs.ErrInvalidCode) {
+			metrics.LoginFailures.WithLabelValues(metrics.LoginInvalidCode).Inc()
+			if lockErr := registerFailedLogin(s.Repo, user, now); lockErr != nil {
+				return 0, lockErr
+			}
+		}
+		logger.Warn.Printf("Login 2FA failed for user %d: %v", userID, err)
+		return 0, err
+	}
+
+	s.Repo.ResetLoginFailures(userID)
+	logger.Info.Printf("User %d passed 2FA", userID)
+	return userID, nil
+}
+
+// RequestUnlock отправляет SMS-код для снятия временной блокировки. Чтобы не раскрывать,
+// существует ли номер и заблокирован ли он, ошибки «не найден» наружу не возвращаются,
+// а лимиты отправки учитываются и тогда, когда код не отправляется.
+func (s *UserService) RequestUnlock(ctx context.Context, phone string) error {
+	user, err := s.Repo.GetByPhone(phone)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			logger.Warn.Printf("RequestUnlock: unknown phone %s", phone)
+			return s.OTP.AllowSend(ctx, OTPPurposeUnlock, phone)
+		}
+		return err
+	}
+
+	if user.IsBlocked || user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+		logger.Info.Printf("RequestUnlock: user %d is not temporarily locked", user.ID)
+		return s.OTP.AllowSend(ctx, OTPPurposeUnlock, phone)
+	}
+
+	return s.OTP.Send(ctx, OTPPurposeUnlock, phone, phone, "WalletX: your unlock code is %s. Do not share it with anyone.")
+}
+
+// ConfirmUnlock снимает временную блокировку по SMS-коду
+func (s *UserService) ConfirmUnlock(ctx context.Context, phone, code string) error {
+	if err := s.OTP.Verify(ctx, OTPPurposeUnlock, phone, code); err != nil {
+		logger.Warn.Printf("ConfirmUnlock: code rejected for %s: %v", phone, err)
+		return err
+	}
+
+	user, err := s.Repo.GetByPhone(phone)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.UnlockUser(user.ID); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("ConfirmUnlock: user %d unlocked by SMS code", user.ID)
+	return nil
+}
+
+// Unblock снимает любую блокировку пользователя по решению администратора
+func (s *UserService) Unblock(userID int) error {
+	if err := s.Repo.UnblockUser(userID); err != nil {
+		return err
 	}
 
-	logger.Info.Printf("VerifyUser: success for userID=%d", userID)
+	logger.Info.Printf("Unblock: user %d unblocked by administrator", userID)
 	return nil
 }
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"WalletX/config"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const defaultDeviceChallengeTTL = 2 * time.Minute
+
+func deviceChallengeTTL() time.Duration {
+	seconds := config.AppSettings.AuthParams.DeviceKeys.ChallengeTTLSeconds
+	if seconds <= 0 {
+		return defaultDeviceChallengeTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func deviceLoginPurpose(deviceID int) string {
+	return "device_login:" + strconv.Itoa(deviceID)
+}
+
+// RegisterKey привязывает открытый ключ Ed25519 к устройству текущей сессии. Ключ становится
+// способом входа, поэтому нужен токен подтверждения действия security.
+func (s *DeviceService) RegisterKey(ctx context.Context, userID, deviceID int, publicKey string) error {
+	if deviceID == 0 {
+		return errs.ErrDeviceNotFound
+	}
+	if _, err := utils.ParseDevicePublicKey(publicKey); err != nil {
+		return errs.ErrInvalidDeviceKey
+	}
+	if err := s.StepUp.Authorize(ctx, userID, StepUpOperation{Action: models.StepUpActionSecurity}); err != nil {
+		return err
+	}
+
+	return s.Repo.SetPublicKey(ctx, userID, deviceID, publicKey)
+}
+
+// PublicKey возвращает ключ устройства или пустую строку, если ключ не зарегистрирован
+func (s *DeviceService) PublicKey(ctx context.Context, deviceID int) (string, error) {
+	device, err := s.Repo.GetByID(ctx, deviceID)
+	if errors.Is(err, errs.ErrDeviceNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if device.RevokedAt != nil {
+		return "", nil
+	}
+	return device.PublicKey, nil
+}
+
+// IssueLoginChallenge выдает строку для подписи при входе по ключу. Для неизвестного телефона
+// или устройства без ключа тоже возвращается challenge, чтобы ответ не раскрывал, какие
+// устройства зарегистрированы; войти по нему нельзя.
+func (s *DeviceService) IssueLoginChallenge(ctx context.Context, phone, deviceID string) (models.DeviceChallengeResponse, error) {
+	ttl := deviceChallengeTTL()
+	resp := models.DeviceChallengeResponse{ExpiresIn: int(ttl.Seconds())}
+
+	device, err := s.keyDevice(ctx, phone, deviceID)
+	if err != nil {
+		return models.DeviceChallengeResponse{}, err
+	}
+	if device == nil {
+		if resp.Challenge, err = utils.GenerateRandomToken(32); err != nil {
+			return models.DeviceChallengeResponse{}, errs.ErrInternal
+		}
+		return resp, nil
+	}
+
+	resp.Challenge, err = s.Tokens.IssueOneTimeToken(ctx, deviceLoginPurpose(device.ID), device.UserID, ttl)
+	if err != nil {
+		return models.DeviceChallengeResponse{}, err
+	}
+	return resp, nil
+}
+
+// keyDevice находит активное устройство с ключом; nil, если такого нет
+func (s *DeviceService) keyDevice(ctx context.Context, phone, deviceID string) (*models.Device, error) {
+	user, err := s.Users.GetByPhone(phone)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.Repo.GetByDeviceID(ctx, user.ID, truncate(deviceID, maxDeviceIDLength))
+	if errors.Is(err, errs.ErrDeviceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if device.RevokedAt != nil || device.PublicKey == "" {
+		return nil, nil
+	}
+	return &device, nil
+}
+
+// VerifyLoginSignature гасит challenge и проверяет, что он подписан ключом устройства пользователя
+func (s *DeviceService) VerifyLoginSignature(ctx context.Context, userID int, deviceID, challenge, signature string) error {
+	device, err := s.Repo.GetByDeviceID(ctx, userID, truncate(deviceID, maxDeviceIDLength))
+	if errors.Is(err, errs.ErrDeviceNotFound) {
+		return errs.ErrInvalidSignature
+	}
+	if err != nil {
+		return err
+	}
+	if device.RevokedAt != nil || device.PublicKey == "" {
+		return errs.ErrInvalidSignature
+	}
+
+	owner, err := s.Tokens.ConsumeOneTimeToken(ctx, deviceLoginPurpose(device.ID), challenge)
+	if errors.Is(err, errs.ErrInvalidOneTimeToken) || (err == nil && owner != userID) {
+		return errs.ErrInvalidSignature
+	}
+	if err != nil {
+		return err
+	}
+
+	if !utils.VerifyDeviceSignature(device.PublicKey, utils.DeviceLoginMessage(challenge), signature) {
+		logger.Warn.Printf("[DeviceService] Invalid login signature for device id=%d, userID=%d", device.ID, userID)
+		return errs.ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/notify"
+	"WalletX/pkg/utils"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	maxDeviceIDLength   = 128
+	maxDeviceNameLength = 100
+	maxPlatformLength   = 50
+)
+
+type DeviceService struct {
+	Repo     repository.DeviceRepository
+	Users    repository.UserRepository
+	Tokens   *TokenService
+	Notifier notify.Sender
+	StepUp   *StepUpService
+}
+
+func NewDeviceService(repo repository.DeviceRepository, users repository.UserRepository, tokens *TokenService, notifier notify.Sender, stepUp *StepUpService) *DeviceService {
+	return &DeviceService{
+		Repo:     repo,
+		Users:    users,
+		Tokens:   tokens,
+		Notifier: notifier,
+		StepUp:   stepUp,
+	}
+}
+
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
+
+// Register запоминает устройство, с которого выполнен вход. Если клиент не передал device_id,
+// он генерируется и должен сохраняться клиентом для следующих входов.
+// О входе с нового устройства пользователь получает уведомление.
+func (s *DeviceService) Register(ctx context.Context, userID int, info models.DeviceInfo, ip string) (models.Device, error) {
+	device := models.Device{
+		UserID:   userID,
+		DeviceID: truncate(info.DeviceID, maxDeviceIDLength),
+		Name:     truncate(info.DeviceName, maxDeviceNameLength),
+		Platform: strings.ToLower(truncate(info.Platform, maxPlatformLength)),
+		LastIP:   ip,
+	}
+	if device.DeviceID == "" {
+		generated, err := utils.GenerateRandomToken(16)
+		if err != nil {
+			return models.Device{}, err
+		}
+		device.DeviceID = generated
+	}
+
+	device, isNew, err := s.Repo.Upsert(ctx, device)
+	if err != nil {
+		return models.Device{}, err
+	}
+
+	if isNew {
+		s.notifyNewDevice(ctx, device)
+	}
+	return device, nil
+}
+
+// notifyNewDevice уведомляет о входе с нового устройства. Самое первое устройство
+// пользователя (вход сразу после регистрации) не считается подозрительным.
+func (s *DeviceService) notifyNewDevice(ctx context.Context, device models.Device) {
+	devices, err := s.Repo.GetActiveByUserID(ctx, device.UserID)
+	if err != nil || len(devices) <= 1 {
+		return
+	}
+
+	user, err := s.Users.GetByID(device.UserID)
+	if err != nil {
+		logger.Warn.Printf("[DeviceService] Cannot notify userID=%d about new device: %v", device.UserID, err)
+		return
+	}
+
+	name := device.Name
+	if name == "" {
+		name = "unknown device"
+	}
+	if device.Platform != "" {
+		name = fmt.Sprintf("%s (%s)", name, device.Platform)
+	}
+	message := fmt.Sprintf("WalletX: new sign-in from %s, IP %s. If it wasn't you, remove the device in the app and change your password.",
+		name, device.LastIP)
+
+	if err := s.Notifier.Send(ctx, user.Phone, "new_device", message); err != nil {
+		logger.Warn.Printf("[DeviceService] Failed to send new device notification to userID=%d: %v", device.UserID, err)
+	}
+}
+
+// List возвращает активные устройства пользователя; текущее устройство помечается флагом current
+func (s *DeviceService) List(ctx context.Context, userID, currentDeviceID int) ([]models.Device, error) {
+	devices, err := s.Repo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		devices[i].Current = devices[i].ID == currentDeviceID
+	}
+	return devices, nil
+}
+
+// Revoke удаляет устройство из активных и отзывает все его токены
+func (s *DeviceService) Revoke(ctx context.Context, userID, deviceID int) error {
+	if err := s.Repo.Revoke(ctx, userID, deviceID); err != nil {
+		return err
+	}
+	return s.Tokens.RevokeDevice(ctx, deviceID)
+}
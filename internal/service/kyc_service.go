@@ -0,0 +1,267 @@
+package service
+
+import (
+	"WalletX/config"
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/storage"
+	"WalletX/pkg/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultKYCMaxFileSize = 10 << 20
+	kycQueueLimit         = 50
+	maxKYCNameLength      = 100
+	maxPassportLength     = 50
+	maxRejectReasonLength = 255
+)
+
+// kycContentTypes — какие форматы принимаются для каждого вида документа. Тип определяется
+// по содержимому файла, а не по заголовкам запроса.
+var kycContentTypes = map[string][]string{
+	models.KYCDocPassportFront: {"image/jpeg", "image/png", "application/pdf"},
+	models.KYCDocPassportBack:  {"image/jpeg", "image/png", "application/pdf"},
+	models.KYCDocSelfie:        {"image/jpeg", "image/png"},
+}
+
+var kycExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// KYCService — проверка личности: пользователь отправляет данные паспорта и сканы документов,
+// сотрудник поддержки одобряет или отклоняет заявку. Уровень верификации меняется только при одобрении.
+type KYCService struct {
+	Repo  repository.KYCRepository
+	Files storage.FileStore
+}
+
+func NewKYCService(repo repository.KYCRepository, files storage.FileStore) *KYCService {
+	return &KYCService{Repo: repo, Files: files}
+}
+
+// KYCMaxFileSize — предельный размер одного файла заявки
+func KYCMaxFileSize() int64 {
+	if mb := config.AppSettings.KYCParams.MaxFileSizeMB; mb > 0 {
+		return int64(mb) << 20
+	}
+	return defaultKYCMaxFileSize
+}
+
+// Submit принимает заявку: проверяет поля и файлы, сохраняет файлы в хранилище и создает заявку.
+// Если заявку создать не удалось, сохраненные файлы удаляются.
+func (s *KYCService) Submit(ctx context.Context, userID int, sub models.KYCSubmission) (models.KYCApplication, error) {
+	sub.FirstName = strings.TrimSpace(sub.FirstName)
+	sub.LastName = strings.TrimSpace(sub.LastName)
+	sub.MiddleName = strings.TrimSpace(sub.MiddleName)
+	sub.PassportNumber = strings.ToUpper(strings.TrimSpace(sub.PassportNumber))
+	if sub.FirstName == "" || sub.LastName == "" || sub.MiddleName == "" || sub.PassportNumber == "" {
+		return models.KYCApplication{}, errs.ErrRequiredFields
+	}
+	for _, name := range []string{sub.FirstName, sub.LastName, sub.MiddleName} {
+		if utf8.RuneCountInString(name) > maxKYCNameLength {
+			return models.KYCApplication{}, fmt.Errorf("%w: name is too long", errs.ErrValidationFailed)
+		}
+	}
+	if len(sub.PassportNumber) > maxPassportLength {
+		return models.KYCApplication{}, fmt.Errorf("%w: passport number is too long", errs.ErrValidationFailed)
+	}
+
+	docs, err := checkKYCFiles(sub.Files)
+	if err != nil {
+		return models.KYCApplication{}, err
+	}
+
+	tier, err := s.Repo.GetTier(ctx, userID)
+	if err != nil {
+		return models.KYCApplication{}, err
+	}
+	if tier >= models.TierVerified {
+		return models.KYCApplication{}, errs.ErrAlreadyVerified
+	}
+	latest, err := s.Repo.Latest(ctx, userID)
+	if err == nil && latest.Status == models.KYCStatusPending {
+		return models.KYCApplication{}, errs.ErrKYCPending
+	}
+	if err != nil && !errors.Is(err, errs.ErrKYCNotFound) {
+		return models.KYCApplication{}, err
+	}
+
+	saved := make([]string, 0, len(docs))
+	cleanup := func() {
+		for _, key := range saved {
+			if err := s.Files.Delete(ctx, key); err != nil {
+				logger.Error.Printf("[KYCService] Failed to delete %s after failed submission: %v", key, err)
+			}
+		}
+	}
+	for i, file := range sub.Files {
+		name, err := utils.GenerateRandomToken(16)
+		if err != nil {
+			cleanup()
+			return models.KYCApplication{}, errs.ErrInternal
+		}
+		key := fmt.Sprintf("kyc/%d/%s%s", userID, name, kycExtensions[docs[i].ContentType])
+		if err := s.Files.Save(ctx, key, bytes.NewReader(file.Content)); err != nil {
+			logger.Error.Printf("[KYCService] Failed to store %s for userID=%d: %v", file.Kind, userID, err)
+			cleanup()
+			return models.KYCApplication{}, errs.ErrInternal
+		}
+		saved = append(saved, key)
+		docs[i].StorageKey = key
+	}
+
+	app, err := s.Repo.Create(ctx, models.KYCApplication{
+		UserID:         userID,
+		FirstName:      sub.FirstName,
+		LastName:       sub.LastName,
+		MiddleName:     sub.MiddleName,
+		PassportNumber: sub.PassportNumber,
+		Documents:      docs,
+	})
+	if err != nil {
+		cleanup()
+		return models.KYCApplication{}, err
+	}
+
+	logger.Info.Printf("[KYCService] Application %d submitted by userID=%d with %d documents", app.ID, userID, len(docs))
+	return app, nil
+}
+
+// checkKYCFiles проверяет набор файлов: каждый вид не больше одного раза, лицевая сторона паспорта
+// и селфи обязательны, формат и размер допустимы
+func checkKYCFiles(files []models.KYCUpload) ([]models.KYCDocument, error) {
+	seen := make(map[string]bool)
+	docs := make([]models.KYCDocument, 0, len(files))
+	for _, file := range files {
+		allowed, ok := kycContentTypes[file.Kind]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown document kind %q", errs.ErrInvalidDocument, file.Kind)
+		}
+		if seen[file.Kind] {
+			return nil, fmt.Errorf("%w: %s uploaded twice", errs.ErrInvalidDocument, file.Kind)
+		}
+		seen[file.Kind] = true
+
+		if len(file.Content) == 0 {
+			return nil, fmt.Errorf("%w: %s is empty", errs.ErrInvalidDocument, file.Kind)
+		}
+		if int64(len(file.Content)) > KYCMaxFileSize() {
+			return nil, fmt.Errorf("%w: %s is larger than %d bytes", errs.ErrInvalidDocument, file.Kind, KYCMaxFileSize())
+		}
+		contentType := http.DetectContentType(file.Content)
+		if !contains(allowed, contentType) {
+			return nil, fmt.Errorf("%w: %s must be one of %s", errs.ErrInvalidDocument, file.Kind, strings.Join(allowed, ", "))
+		}
+
+		docs = append(docs, models.KYCDocument{Kind: file.Kind, ContentType: contentType, Size: int64(len(file.Content))})
+	}
+
+	for _, kind := range []string{models.KYCDocPassportFront, models.KYCDocSelfie} {
+		if !seen[kind] {
+			return nil, fmt.Errorf("%w: %s is required", errs.ErrInvalidDocument, kind)
+		}
+	}
+	return docs, nil
+}
+
+// Status возвращает статус проверки пользователя по его последней заявке
+func (s *KYCService) Status(ctx context.Context, userID int) (models.KYCStatusResponse, error) {
+	tier, err := s.Repo.GetTier(ctx, userID)
+	if err != nil {
+		return models.KYCStatusResponse{}, err
+	}
+
+	app, err := s.Repo.Latest(ctx, userID)
+	if errors.Is(err, errs.ErrKYCNotFound) {
+		status := models.KYCStatusNotStarted
+		if tier >= models.TierVerified {
+			// пользователь подтвержден до появления заявок
+			status = models.KYCStatusApproved
+		}
+		return models.KYCStatusResponse{Status: status, Tier: tier}, nil
+	}
+	if err != nil {
+		return models.KYCStatusResponse{}, err
+	}
+
+	return models.KYCStatusResponse{
+		Status:        app.Status,
+		Tier:          tier,
+		ApplicationID: app.ID,
+		RejectReason:  app.RejectReason,
+		SubmittedAt:   &app.SubmittedAt,
+		ReviewedAt:    app.ReviewedAt,
+	}, nil
+}
+
+// Queue возвращает заявки, ожидающие проверки, в порядке поступления
+func (s *KYCService) Queue(ctx context.Context) ([]models.KYCApplication, error) {
+	return s.Repo.ListPending(ctx, kycQueueLimit)
+}
+
+func (s *KYCService) Get(ctx context.Context, id int) (models.KYCApplication, error) {
+	return s.Repo.Get(ctx, id)
+}
+
+// OpenDocument открывает файл документа заявки. Закрыть его должен вызывающий.
+func (s *KYCService) OpenDocument(ctx context.Context, applicationID, documentID int) (models.KYCDocument, io.ReadCloser, error) {
+	doc, err := s.Repo.GetDocument(ctx, applicationID, documentID)
+	if err != nil {
+		return models.KYCDocument{}, nil, err
+	}
+
+	file, err := s.Files.Open(ctx, doc.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logger.Error.Printf("[KYCService] File of document %d is missing in storage", doc.ID)
+			return models.KYCDocument{}, nil, errs.ErrKYCNotFound
+		}
+		logger.Error.Printf("[KYCService] Failed to open document %d: %v", doc.ID, err)
+		return models.KYCDocument{}, nil, errs.ErrInternal
+	}
+	return doc, file, nil
+}
+
+// Approve одобряет заявку: данные паспорта переносятся в профиль, пользователь получает уровень TierVerified
+func (s *KYCService) Approve(ctx context.Context, reviewerID, id int) (models.KYCApplication, error) {
+	app, err := s.Repo.Approve(ctx, id, reviewerID)
+	if err != nil {
+		logger.Warn.Printf("[KYCService] Reviewer %d failed to approve application %d: %v", reviewerID, id, err)
+		return models.KYCApplication{}, err
+	}
+
+	logger.Info.Printf("[KYCService] Reviewer %d approved application %d of userID=%d", reviewerID, id, app.UserID)
+	return app, nil
+}
+
+// Reject отклоняет заявку с причиной, которую увидит пользователь; после этого можно подать новую
+func (s *KYCService) Reject(ctx context.Context, reviewerID, id int, reason string) (models.KYCApplication, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return models.KYCApplication{}, fmt.Errorf("%w: reason is required", errs.ErrValidationFailed)
+	}
+	if utf8.RuneCountInString(reason) > maxRejectReasonLength {
+		return models.KYCApplication{}, fmt.Errorf("%w: reason is too long", errs.ErrValidationFailed)
+	}
+
+	app, err := s.Repo.Reject(ctx, id, reviewerID, reason)
+	if err != nil {
+		logger.Warn.Printf("[KYCService] Reviewer %d failed to reject application %d: %v", reviewerID, id, err)
+		return models.KYCApplication{}, err
+	}
+
+	logger.Info.Printf("[KYCService] Reviewer %d rejected application %d of userID=%d", reviewerID, id, app.UserID)
+	return app, nil
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	oauthCodeTTL          = 5 * time.Minute
+	pkceMethodS256        = "S256"
+	pkceMinLength         = 43
+	pkceMaxLength         = 128
+	maxClientNameLength   = 100
+	maxClientRedirectURIs = 10
+)
+
+// OAuthService реализует OAuth 2.0 для партнерских приложений: код авторизации с PKCE (RFC 7636),
+// client_credentials и обновление токенов. Токены приложений несут client_id и области доступа
+// и принимаются только маршрутами, которые требуют соответствующую область.
+type OAuthService struct {
+	Repo   repository.OAuthRepository
+	Codes  repository.OAuthCodeRepository
+	Tokens *TokenService
+}
+
+func NewOAuthService(repo repository.OAuthRepository, codes repository.OAuthCodeRepository, tokens *TokenService) *OAuthService {
+	return &OAuthService{Repo: repo, Codes: codes, Tokens: tokens}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		// без TLS — только для локальной разработки
+		return u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1"
+	default:
+		// собственная схема мобильного приложения, например com.partner.app:/callback
+		return true
+	}
+}
+
+// CreateClient регистрирует приложение. Для конфиденциального клиента генерируется секрет,
+// он возвращается один раз. client_credentials доступен только конфиденциальным клиентам.
+func (s *OAuthService) CreateClient(ctx context.Context, req models.CreateOAuthClientRequest) (models.CreateOAuthClientResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxClientNameLength {
+		return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: name is required (up to %d characters)", errs.ErrValidationFailed, maxClientNameLength)
+	}
+	if len(req.GrantTypes) == 0 {
+		return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: at least one grant type is required", errs.ErrValidationFailed)
+	}
+	for _, grant := range req.GrantTypes {
+		if grant != models.GrantAuthorizationCode && grant != models.GrantClientCredentials {
+			return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: unsupported grant type %q", errs.ErrValidationFailed, grant)
+		}
+	}
+	if contains(req.GrantTypes, models.GrantClientCredentials) && !req.Confidential {
+		return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: client_credentials requires a confidential client", errs.ErrValidationFailed)
+	}
+	if contains(req.GrantTypes, models.GrantAuthorizationCode) {
+		if len(req.RedirectURIs) == 0 || len(req.RedirectURIs) > maxClientRedirectURIs {
+			return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: 1 to %d redirect URIs are required", errs.ErrValidationFailed, maxClientRedirectURIs)
+		}
+	}
+	for _, uri := range req.RedirectURIs {
+		if !validRedirectURI(uri) {
+			return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: invalid redirect URI %q", errs.ErrValidationFailed, uri)
+		}
+	}
+	scopes := models.ParseScopes(strings.Join(req.Scopes, " "))
+	if len(scopes) == 0 {
+		return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: at least one scope is required", errs.ErrValidationFailed)
+	}
+	for _, scope := range scopes {
+		if _, ok := models.LookupScope(scope); !ok {
+			return models.CreateOAuthClientResponse{}, fmt.Errorf("%w: unknown scope %q", errs.ErrValidationFailed, scope)
+		}
+	}
+
+	clientID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return models.CreateOAuthClientResponse{}, errs.ErrInternal
+	}
+	client := models.OAuthClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       scopes,
+		GrantTypes:   req.GrantTypes,
+	}
+	if client.RedirectURIs == nil {
+		client.RedirectURIs = []string{}
+	}
+
+	var secret string
+	if req.Confidential {
+		if secret, err = utils.GenerateRandomToken(32); err != nil {
+			return models.CreateOAuthClientResponse{}, errs.ErrInternal
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	created, err := s.Repo.CreateClient(ctx, client)
+	if err != nil {
+		return models.CreateOAuthClientResponse{}, err
+	}
+	return models.CreateOAuthClientResponse{Client: created, ClientSecret: secret}, nil
+}
+
+func (s *OAuthService) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	return s.Repo.ListClients(ctx)
+}
+
+// authenticateClient проверяет client_id и секрет. Публичный клиент секрета не имеет и не должен его передавать.
+func (s *OAuthService) authenticateClient(ctx context.Context, clientID, secret string) (models.OAuthClient, error) {
+	invalid := errs.NewOAuthError(errs.OAuthInvalidClient, "client authentication failed")
+	if clientID == "" {
+		return models.OAuthClient{}, invalid
+	}
+
+	client, err := s.Repo.GetClient(ctx, clientID)
+	if errors.Is(err, errs.ErrClientNotFound) {
+		return models.OAuthClient{}, invalid
+	}
+	if err != nil {
+		return models.OAuthClient{}, err
+	}
+	if !client.IsActive {
+		return models.OAuthClient{}, invalid
+	}
+
+	if client.Confidential {
+		if secret == "" || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashToken(secret))) != 1 {
+			logger.Warn.Printf("[OAuthService] Wrong secret for client %s", clientID)
+			return models.OAuthClient{}, invalid
+		}
+	} else if secret != "" {
+		return models.OAuthClient{}, invalid
+	}
+	return client, nil
+}
+
+// validateAuthorize проверяет запрос авторизации и возвращает клиента и запрошенные области
+func (s *OAuthService) validateAuthorize(ctx context.Context, req models.OAuthAuthorizeRequest) (models.OAuthClient, []string, error) {
+	if req.ResponseType != "code" {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthUnsupportedResponseType, "only response_type=code is supported")
+	}
+
+	client, err := s.Repo.GetClient(ctx, req.ClientID)
+	if errors.Is(err, errs.ErrClientNotFound) || (err == nil && !client.IsActive) {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthInvalidClient, "unknown client")
+	}
+	if err != nil {
+		return models.OAuthClient{}, nil, err
+	}
+	if !contains(client.GrantTypes, models.GrantAuthorizationCode) {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthUnauthorizedClient, "client is not allowed to use the authorization code grant")
+	}
+	if !contains(client.RedirectURIs, req.RedirectURI) {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthInvalidRequest, "redirect_uri is not registered for the client")
+	}
+
+	if req.CodeChallengeMethod != pkceMethodS256 {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+	if len(req.CodeChallenge) != 43 {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthInvalidRequest, "invalid code_challenge")
+	}
+
+	scopes := models.ParseScopes(req.Scope)
+	if len(scopes) == 0 {
+		return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthInvalidScope, "scope is required")
+	}
+	for _, scope := range scopes {
+		info, ok := models.LookupScope(scope)
+		if !ok || !info.UserData || !contains(client.Scopes, scope) {
+			return models.OAuthClient{}, nil, errs.NewOAuthError(errs.OAuthInvalidScope, fmt.Sprintf("scope %q is not allowed for the client", scope))
+		}
+	}
+	return client, scopes, nil
+}
+
+// ConsentScreen возвращает данные для экрана согласия: приложение, запрошенные области и было ли согласие уже дано
+func (s *OAuthService) ConsentScreen(ctx context.Context, userID int, req models.OAuthAuthorizeRequest) (models.OAuthConsentScreen, error) {
+	client, scopes, err := s.validateAuthorize(ctx, req)
+	if err != nil {
+		return models.OAuthConsentScreen{}, err
+	}
+
+	screen := models.OAuthConsentScreen{ClientID: client.ClientID, ClientName: client.Name, Scopes: make([]models.OAuthScope, 0, len(scopes))}
+	for _, scope := range scopes {
+		info, _ := models.LookupScope(scope)
+		screen.Scopes = append(screen.Scopes, info)
+	}
+
+	granted, err := s.Repo.GetConsent(ctx, userID, client.ID)
+	if err != nil && !errors.Is(err, errs.ErrConsentNotFound) {
+		return models.OAuthConsentScreen{}, err
+	}
+	screen.AlreadyGranted = err == nil
+	for _, scope := range scopes {
+		if !contains(granted, scope) {
+			screen.AlreadyGranted = false
+		}
+	}
+	return screen, nil
+}
+
+// Authorize записывает решение пользователя и возвращает адрес, на который нужно вернуть его в приложение:
+// с кодом авторизации при согласии или с error=access_denied при отказе
+func (s *OAuthService) Authorize(ctx context.Context, userID int, req models.OAuthAuthorizeRequest) (string, error) {
+	client, scopes, err := s.validateAuthorize(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	redirect, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return "", errs.NewOAuthError(errs.OAuthInvalidRequest, "invalid redirect_uri")
+	}
+	query := redirect.Query()
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+
+	if !req.Approve {
+		logger.Info.Printf("[OAuthService] userID=%d denied access to client %s", userID, client.ClientID)
+		query.Set("error", errs.OAuthAccessDenied)
+		redirect.RawQuery = query.Encode()
+		return redirect.String(), nil
+	}
+
+	if err := s.Repo.SaveConsent(ctx, userID, client.ID, scopes); err != nil {
+		return "", err
+	}
+
+	code, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", errs.ErrInternal
+	}
+	data := models.OAuthAuthorizationCode{
+		UserID:        userID,
+		ClientID:      client.ClientID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+	}
+	if err := s.Codes.Save(ctx, hashToken(code), data, oauthCodeTTL); err != nil {
+		return "", err
+	}
+
+	logger.Info.Printf("[OAuthService] userID=%d granted %q to client %s", userID, data.Scope, client.ClientID)
+	query.Set("code", code)
+	redirect.RawQuery = query.Encode()
+	return redirect.String(), nil
+}
+
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < pkceMinLength || len(verifier) > pkceMaxLength {
+		return false
+	}
+	for _, c := range verifier {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("-._~", c)) {
+			return false
+		}
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// Token — точка выдачи токенов (RFC 6749, раздел 3.2)
+func (s *OAuthService) Token(ctx context.Context, req models.OAuthTokenRequest) (models.OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return models.OAuthTokenResponse{}, err
+	}
+
+	var pair models.TokenPair
+	switch req.GrantType {
+	case models.GrantAuthorizationCode:
+		pair, err = s.exchangeCode(ctx, client, req)
+	case models.GrantRefreshToken:
+		pair, err = s.Tokens.RefreshClient(ctx, req.RefreshToken, client.ClientID)
+		if errors.Is(err, errs.ErrInvalidRefreshToken) || errors.Is(err, errs.ErrTokenRevoked) {
+			err = errs.NewOAuthError(errs.OAuthInvalidGrant, err.Error())
+		}
+	case models.GrantClientCredentials:
+		pair, err = s.clientCredentials(client, req.Scope)
+	default:
+		err = errs.NewOAuthError(errs.OAuthUnsupportedGrantType, fmt.Sprintf("grant_type %q is not supported", req.GrantType))
+	}
+	if err != nil {
+		return models.OAuthTokenResponse{}, err
+	}
+
+	return models.OAuthTokenResponse{
+		AccessToken:  pair.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    pair.ExpiresIn,
+		RefreshToken: pair.RefreshToken,
+		Scope:        pair.Scope,
+	}, nil
+}
+
+// exchangeCode обменивает код авторизации на токены. Код одноразовый, выдан этому клиенту
+// для этого redirect_uri, а code_verifier должен соответствовать code_challenge.
+func (s *OAuthService) exchangeCode(ctx context.Context, client models.OAuthClient, req models.OAuthTokenRequest) (models.TokenPair, error) {
+	invalid := errs.NewOAuthError(errs.OAuthInvalidGrant, "invalid authorization code")
+	if !contains(client.GrantTypes, models.GrantAuthorizationCode) {
+		return models.TokenPair{}, errs.NewOAuthError(errs.OAuthUnauthorizedClient, "client is not allowed to use the authorization code grant")
+	}
+	if req.Code == "" {
+		return models.TokenPair{}, invalid
+	}
+
+	code, err := s.Codes.Consume(ctx, hashToken(req.Code))
+	if errors.Is(err, errs.ErrInvalidOneTimeToken) {
+		return models.TokenPair{}, invalid
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI {
+		logger.Warn.Printf("[OAuthService] Authorization code presented by client %s does not match its issue", client.ClientID)
+		return models.TokenPair{}, invalid
+	}
+	if !verifyPKCE(code.CodeChallenge, req.CodeVerifier) {
+		logger.Warn.Printf("[OAuthService] PKCE verification failed for client %s", client.ClientID)
+		return models.TokenPair{}, invalid
+	}
+
+	// согласие могли отозвать, пока код ждал обмена
+	granted, err := s.Repo.GetConsent(ctx, code.UserID, client.ID)
+	if errors.Is(err, errs.ErrConsentNotFound) {
+		return models.TokenPair{}, invalid
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	for _, scope := range models.ParseScopes(code.Scope) {
+		if !contains(granted, scope) {
+			return models.TokenPair{}, invalid
+		}
+	}
+
+	return s.Tokens.IssueClientPair(ctx, code.UserID, client.ClientID, code.Scope)
+}
+
+// clientCredentials выдает токен самого приложения. Области с доступом к данным пользователей так не выдаются.
+func (s *OAuthService) clientCredentials(client models.OAuthClient, rawScope string) (models.TokenPair, error) {
+	if !client.Confidential || !contains(client.GrantTypes, models.GrantClientCredentials) {
+		return models.TokenPair{}, errs.NewOAuthError(errs.OAuthUnauthorizedClient, "client is not allowed to use the client credentials grant")
+	}
+
+	var scopes []string
+	requested := models.ParseScopes(rawScope)
+	if len(requested) == 0 {
+		for _, scope := range client.Scopes {
+			if info, ok := models.LookupScope(scope); ok && !info.UserData {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	for _, scope := range requested {
+		info, ok := models.LookupScope(scope)
+		if !ok || info.UserData || !contains(client.Scopes, scope) {
+			return models.TokenPair{}, errs.NewOAuthError(errs.OAuthInvalidScope, fmt.Sprintf("scope %q is not available with client credentials", scope))
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return models.TokenPair{}, errs.NewOAuthError(errs.OAuthInvalidScope, "no scopes are available with client credentials")
+	}
+
+	return s.Tokens.IssueClientToken(client.ClientID, strings.Join(scopes, " "))
+}
+
+func (s *OAuthService) ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error) {
+	return s.Repo.ListConsents(ctx, userID)
+}
+
+// RevokeConsent удаляет согласие и отзывает все токены, выданные по нему приложению
+func (s *OAuthService) RevokeConsent(ctx context.Context, userID int, clientID string) error {
+	if err := s.Repo.DeleteConsent(ctx, userID, clientID); err != nil {
+		return err
+	}
+	if err := s.Tokens.RevokeConsent(ctx, userID, clientID); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[OAuthService] userID=%d revoked consent for client %s", userID, clientID)
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"WalletX/pkg/notify"
+	"WalletX/pkg/utils"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+)
+
+const (
+	otpTTL         = 5 * time.Minute
+	otpMaxAttempts = 5
+	// неверные вводы считаются по purpose и subject независимо от повторных отправок
+	otpFailureWindow = time.Hour
+	otpSendCooldown  = time.Minute
+	otpSendWindow    = time.Hour
+	otpMaxSends      = 5
+)
+
+// Назначения одноразовых кодов
+const (
+	OTPPurposeUnlock        = "unlock"
+	OTPPurposePasswordReset = "password_reset"
+)
+
+// OTPService выдает и проверяет одноразовые SMS-коды. В хранилище попадает только хеш кода.
+// Отправлять код можно не чаще раза в otpSendCooldown и не больше otpMaxSends раз за otpSendWindow,
+// после otpMaxAttempts вводов за otpFailureWindow проверка отклоняется, сколько бы кодов ни было выслано.
+type OTPService struct {
+	Repo   repository.OTPRepository
+	Sender notify.Sender
+}
+
+func NewOTPService(repo repository.OTPRepository, sender notify.Sender) *OTPService {
+	return &OTPService{Repo: repo, Sender: sender}
+}
+
+// AllowSend проверяет и учитывает лимиты отправки для subject. Сценарии без входа вызывают его
+// и тогда, когда код не отправляется (неизвестный номер), чтобы ответ не выдавал, зарегистрирован ли номер.
+func (s *OTPService) AllowSend(ctx context.Context, purpose, subject string) error {
+	ok, sends, err := s.Repo.ReserveSend(ctx, purpose, subject, otpSendCooldown, otpSendWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrCodeRecentlySent
+	}
+	if sends > otpMaxSends {
+		logger.Warn.Printf("[OTPService] Too many %s codes requested for %s", purpose, subject)
+		return errs.ErrTooManyAttempts
+	}
+	return nil
+}
+
+// Send генерирует код и отправляет его на phone. message должен содержать %s для кода.
+func (s *OTPService) Send(ctx context.Context, purpose, subject, phone, message string) error {
+	if err := s.AllowSend(ctx, purpose, subject); err != nil {
+		return err
+	}
+
+	code := utils.GenerateCode()
+	if err := s.Repo.Save(ctx, purpose, subject, hashToken(code), otpTTL); err != nil {
+		return err
+	}
+
+	if err := s.Sender.Send(ctx, phone, purpose, fmt.Sprintf(message, code)); err != nil {
+		logger.Error.Printf("[OTPService] Failed to send %s code: %v", purpose, err)
+		return errs.ErrInternal
+	}
+	metrics.SMSCodesSent.WithLabelValues(purpose).Inc()
+	return nil
+}
+
+// Verify проверяет код; верный код можно использовать только один раз
+func (s *OTPService) Verify(ctx context.Context, purpose, subject, code string) error {
+	attempts, err := s.Repo.IncrementAttempts(ctx, purpose, subject, otpFailureWindow)
+	if err != nil {
+		return err
+	}
+	if attempts > otpMaxAttempts {
+		logger.Warn.Printf("[OTPService] Too many %s code attempts for %s", purpose, subject)
+		return errs.ErrTooManyAttempts
+	}
+
+	stored, err := s.Repo.Get(ctx, purpose, subject)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(hashToken(code))) != 1 {
+		return errs.ErrInvalidCode
+	}
+
+	return s.Repo.Delete(ctx, purpose, subject)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	oneTimePurposePasswordReset = "password_reset"
+	passwordResetTokenTTL       = 10 * time.Minute
+)
+
+// RequestPasswordReset отправляет SMS-код для восстановления пароля.
+// Для неизвестного номера ничего не отправляется, но лимиты отправки учитываются так же.
+func (s *UserService) RequestPasswordReset(ctx context.Context, phone string) error {
+	user, err := s.Repo.GetByPhone(phone)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			logger.Warn.Printf("RequestPasswordReset: unknown phone %s", phone)
+			return s.OTP.AllowSend(ctx, OTPPurposePasswordReset, phone)
+		}
+		return err
+	}
+
+	logger.Info.Printf("RequestPasswordReset: sending code to user %d", user.ID)
+	return s.OTP.Send(ctx, OTPPurposePasswordReset, phone, phone, "WalletX: your password reset code is %s. Do not share it with anyone.")
+}
+
+// VerifyPasswordReset проверяет SMS-код и выдает одноразовый токен для установки нового пароля
+func (s *UserService) VerifyPasswordReset(ctx context.Context, phone, code string) (models.PasswordResetTokenResponse, error) {
+	if err := s.OTP.Verify(ctx, OTPPurposePasswordReset, phone, code); err != nil {
+		logger.Warn.Printf("VerifyPasswordReset: code rejected for %s: %v", phone, err)
+		return models.PasswordResetTokenResponse{}, err
+	}
+
+	user, err := s.Repo.GetByPhone(phone)
+	if err != nil {
+		return models.PasswordResetTokenResponse{}, err
+	}
+
+	token, err := s.Tokens.IssueOneTimeToken(ctx, oneTimePurposePasswordReset, user.ID, passwordResetTokenTTL)
+	if err != nil {
+		return models.PasswordResetTokenResponse{}, err
+	}
+	return models.PasswordResetTokenResponse{
+		ResetToken: token,
+		ExpiresIn:  int(passwordResetTokenTTL.Seconds()),
+	}, nil
+}
+
+// ResetPassword задает новый пароль по токену сброса. Владение номером подтверждено,
+// поэтому временная блокировка снимается, а все сессии завершаются.
+func (s *UserService) ResetPassword(ctx context.Context, resetToken, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	userID, err := s.Tokens.ConsumeOneTimeToken(ctx, oneTimePurposePasswordReset, resetToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storePassword(userID, password); err != nil {
+		return err
+	}
+	if err := s.Repo.UnlockUser(userID); err != nil {
+		return err
+	}
+	if err := s.Tokens.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("ResetPassword: password reset for userID=%d", userID)
+	return nil
+}
+
+// ChangePassword меняет пароль авторизованного пользователя и завершает все его сессии
+func (s *UserService) ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
+	user, err := s.Repo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := checkLoginAllowed(user, now); err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)) != nil {
+		metrics.LoginFailures.WithLabelValues(metrics.LoginWrongPassword).Inc()
+		if err := registerFailedLogin(s.Repo, user, now); err != nil {
+			return err
+		}
+		logger.Warn.Printf("ChangePassword: wrong current password for userID=%d", userID)
+		return errs.ErrWrongPassword
+	}
+	if oldPassword == newPassword {
+		return errs.ErrSamePassword
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.StepUp.Authorize(ctx, userID, StepUpOperation{Action: models.StepUpActionSecurity}); err != nil {
+		return err
+	}
+
+	if err := s.storePassword(userID, newPassword); err != nil {
+		return err
+	}
+	if err := s.Tokens.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("ChangePassword: password changed for userID=%d", userID)
+	return nil
+}
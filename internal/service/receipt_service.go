@@ -0,0 +1,59 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/logger"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const receiptStatusCompleted = "completed"
+
+type ReceiptService struct {
+	Repo repository.ReceiptRepository
+}
+
+func NewReceiptService(repo repository.ReceiptRepository) *ReceiptService {
+	return &ReceiptService{Repo: repo}
+}
+
+func (s *ReceiptService) GetByNumber(ctx context.Context, userID int, receiptNumber string) (models.Receipt, error) {
+	return s.Repo.GetByNumber(ctx, strings.ToUpper(strings.TrimSpace(receiptNumber)), userID)
+}
+
+// receiptNumber строит читаемый номер чека из даты и id транзакции, поэтому он уникален
+func receiptNumber(tx models.Transaction) string {
+	return fmt.Sprintf("WX-%s-%08d", tx.CreatedAt.Format("20060102"), tx.ID)
+}
+
+// issueReceipt выписывает чек по завершенной транзакции. service передается только для платежей за услуги.
+func issueReceipt(ctx context.Context, repo repository.ReceiptRepository, tx models.Transaction, service *models.Services, subscriberAccount string) (models.Receipt, error) {
+	receipt := models.Receipt{
+		ReceiptNumber:  receiptNumber(tx),
+		TransactionID:  tx.ID,
+		Type:           tx.Type,
+		Status:         receiptStatusCompleted,
+		PayerAccountID: tx.AccountFrom,
+		PayeeAccountID: tx.AccountTo,
+		Amount:         tx.Amount,
+		Fee:            0,
+		Total:          tx.Amount,
+		CreatedAt:      tx.CreatedAt,
+	}
+	if service != nil {
+		receipt.ServiceName = &service.Name
+		receipt.ServiceDescription = &service.Description
+	}
+	if subscriberAccount != "" {
+		receipt.SubscriberAccount = &subscriberAccount
+	}
+
+	created, err := repo.Create(ctx, receipt)
+	if err != nil {
+		logger.Error.Printf("[ReceiptService] Failed to issue receipt for transactionID=%d: %v", tx.ID, err)
+		return models.Receipt{}, err
+	}
+	return created, nil
+}
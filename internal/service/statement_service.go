@@ -0,0 +1,74 @@
+package service
+
+import (
+	"WalletX/config"
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/statement"
+	"context"
+	"time"
+)
+
+const defaultCurrency = "TJS"
+
+type StatementService struct {
+	Repo        repository.StatementRepository
+	AccountRepo repository.AccountRepository
+}
+
+func NewStatementService(repo repository.StatementRepository, accountRepo repository.AccountRepository) *StatementService {
+	return &StatementService{
+		Repo:        repo,
+		AccountRepo: accountRepo,
+	}
+}
+
+// Export пишет выписку пользователя за период в w, передавая операции по мере чтения из БД
+func (s *StatementService) Export(ctx context.Context, userID int, start, end time.Time, w statement.Writer) error {
+	account, err := s.AccountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	st, err := s.Repo.GetSummary(ctx, account.ID, start, end)
+	if err != nil {
+		return err
+	}
+	st.Currency = config.AppSettings.AppParams.Currency
+	if st.Currency == "" {
+		st.Currency = defaultCurrency
+	}
+	st.GeneratedAt = time.Now()
+
+	if limited, ok := w.(statement.Limited); ok && st.CountIn+st.CountOut > limited.MaxEntries() {
+		logger.Warn.Printf("[StatementService] Statement too large for accountID=%d: %d entries, limit %d",
+			account.ID, st.CountIn+st.CountOut, limited.MaxEntries())
+		return errs.ErrStatementTooLarge
+	}
+
+	if err := w.Begin(st); err != nil {
+		return err
+	}
+
+	balance := st.OpeningBalance
+	count := 0
+	err = s.Repo.StreamEntries(ctx, account.ID, start, end, func(e models.StatementEntry) error {
+		if e.Direction == models.DirectionOut {
+			balance -= e.Amount
+		} else {
+			balance += e.Amount
+		}
+		e.BalanceAfter = balance
+		count++
+		return w.Entry(e)
+	})
+	if err != nil {
+		logger.Error.Printf("[StatementService] Failed to write statement for accountID=%d: %v", account.ID, err)
+		return err
+	}
+
+	logger.Info.Printf("[StatementService] Exported %d entries for accountID=%d", count, account.ID)
+	return w.End()
+}
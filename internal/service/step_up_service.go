@@ -0,0 +1,314 @@
+package service
+
+import (
+	"WalletX/config"
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/passwordpolicy"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	stepUpTokenTTL = 5 * time.Minute
+	// purpose счетчика неверных PIN-кодов в OTPRepository
+	pinAttemptsPurpose = "transaction_pin"
+
+	defaultPinLength      = 4
+	defaultMaxPinAttempts = 5
+	defaultPinLock        = 30 * time.Minute
+)
+
+// Причины, по которым требуется подтверждение
+const (
+	StepUpReasonAmount       = "amount_above_threshold"
+	StepUpReasonNewRecipient = "new_recipient"
+	StepUpReasonSecurity     = "security_settings"
+	StepUpReasonInvalidToken = "invalid_step_up_token"
+)
+
+type stepUpTokenKey struct{}
+
+// WithStepUpToken кладет в контекст токен подтверждения из заголовка X-Step-Up-Token
+func WithStepUpToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, stepUpTokenKey{}, token)
+}
+
+func stepUpTokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(stepUpTokenKey{}).(string)
+	return token
+}
+
+// StepUpOperation описывает операцию, для которой проверяется необходимость подтверждения.
+// Reference — прочие реквизиты, которые нельзя подменить после подтверждения (лицевой счет абонента).
+type StepUpOperation struct {
+	Action        string
+	Amount        float64
+	FromAccountID int
+	ToAccountID   int
+	Reference     string
+}
+
+// fingerprint — отпечаток операции пользователя: токен подтверждения выдается под него и годится только
+// для операции с теми же суммой и реквизитами. Настройки безопасности реквизитов не имеют, их токен
+// привязан только к действию.
+func (op StepUpOperation) fingerprint(userID int) string {
+	if op.Action == models.StepUpActionSecurity {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%.2f|%d|%d|%s",
+		userID, op.Action, op.Amount, op.FromAccountID, op.ToAccountID, op.Reference)))
+	return hex.EncodeToString(sum[:])
+}
+
+// StepUpService решает, нужно ли операции дополнительное подтверждение (PIN-код операций или SMS),
+// и выдает одноразовые токены подтверждения. Украденного access-токена недостаточно,
+// чтобы перевести крупную сумму, отправить деньги новому получателю или поменять настройки безопасности.
+type StepUpService struct {
+	Users        repository.UserRepository
+	Transactions repository.TransactionRepository
+	OTP          *OTPService
+	Tokens       *TokenService
+}
+
+func NewStepUpService(users repository.UserRepository, transactions repository.TransactionRepository, otp *OTPService, tokens *TokenService) *StepUpService {
+	return &StepUpService{
+		Users:        users,
+		Transactions: transactions,
+		OTP:          otp,
+		Tokens:       tokens,
+	}
+}
+
+func stepUpPurpose(action string) string {
+	return "step_up_" + action
+}
+
+// stepUpTokenPurpose — purpose токена подтверждения: действие и отпечаток операции
+func stepUpTokenPurpose(action, operation string) string {
+	if operation == "" {
+		return stepUpPurpose(action)
+	}
+	return stepUpPurpose(action) + ":" + operation
+}
+
+// validStepUpOperation проверяет отпечаток операции из запроса подтверждения: переводы и платежи
+// подтверждаются только вместе с отпечатком из ответа 403
+func validStepUpOperation(action, operation string) error {
+	if action == models.StepUpActionSecurity {
+		if operation != "" {
+			return fmt.Errorf("%w: security confirmation has no operation", errs.ErrValidationFailed)
+		}
+		return nil
+	}
+	if _, err := hex.DecodeString(operation); err != nil || len(operation) != 2*sha256.Size {
+		return fmt.Errorf("%w: operation is required", errs.ErrValidationFailed)
+	}
+	return nil
+}
+
+func validStepUpAction(action string) bool {
+	switch action {
+	case models.StepUpActionTransfer, models.StepUpActionPayment, models.StepUpActionSecurity:
+		return true
+	}
+	return false
+}
+
+func stepUpSettings() models.StepUp {
+	cfg := config.AppSettings.AuthParams.StepUp
+	if cfg.PinLength <= 0 {
+		cfg.PinLength = defaultPinLength
+	}
+	if cfg.MaxPinAttempts <= 0 {
+		cfg.MaxPinAttempts = defaultMaxPinAttempts
+	}
+	return cfg
+}
+
+func pinLockDuration(cfg models.StepUp) time.Duration {
+	if cfg.PinLockMinutes <= 0 {
+		return defaultPinLock
+	}
+	return time.Duration(cfg.PinLockMinutes) * time.Minute
+}
+
+// reason возвращает причину, по которой операции нужно подтверждение, или пустую строку
+func (s *StepUpService) reason(ctx context.Context, op StepUpOperation) (string, error) {
+	if op.Action == models.StepUpActionSecurity {
+		return StepUpReasonSecurity, nil
+	}
+
+	cfg := stepUpSettings()
+	if cfg.AmountThreshold > 0 && op.Amount >= cfg.AmountThreshold {
+		return StepUpReasonAmount, nil
+	}
+
+	if op.Action == models.StepUpActionTransfer && cfg.NewRecipient && op.ToAccountID != 0 {
+		known, err := s.Transactions.HasTransfer(ctx, op.FromAccountID, op.ToAccountID)
+		if err != nil {
+			return "", err
+		}
+		if !known {
+			return StepUpReasonNewRecipient, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *StepUpService) methods(userID int) ([]string, error) {
+	pinHash, err := s.Users.GetTransactionPIN(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pinHash == "" {
+		return []string{models.StepUpMethodSMS}, nil
+	}
+	return []string{models.StepUpMethodPIN, models.StepUpMethodSMS}, nil
+}
+
+// Authorize пропускает операцию, если подтверждение не требуется или в контексте есть
+// действующий токен подтверждения именно этой операции. Токен одноразовый; токен другой операции
+// (другая сумма или получатель) не подходит и не расходуется. Вызывается после проверок самой
+// операции, чтобы заведомо неверный запрос не сжигал токен.
+func (s *StepUpService) Authorize(ctx context.Context, userID int, op StepUpOperation) error {
+	reason, err := s.reason(ctx, op)
+	if err != nil || reason == "" {
+		return err
+	}
+
+	operation := op.fingerprint(userID)
+	if token := stepUpTokenFrom(ctx); token != "" {
+		owner, err := s.Tokens.ConsumeOneTimeToken(ctx, stepUpTokenPurpose(op.Action, operation), token)
+		if err != nil && !errors.Is(err, errs.ErrInvalidOneTimeToken) {
+			return err
+		}
+		if err == nil && owner == userID {
+			logger.Info.Printf("[StepUpService] %s confirmed for userID=%d (%s)", op.Action, userID, reason)
+			return nil
+		}
+		reason = StepUpReasonInvalidToken
+	}
+
+	methods, err := s.methods(userID)
+	if err != nil {
+		return err
+	}
+	logger.Warn.Printf("[StepUpService] %s for userID=%d requires confirmation: %s", op.Action, userID, reason)
+	return &errs.StepUpRequiredError{Reason: reason, Methods: methods, Operation: operation}
+}
+
+func (s *StepUpService) issue(ctx context.Context, userID int, action, operation string) (models.StepUpTokenResponse, error) {
+	token, err := s.Tokens.IssueOneTimeToken(ctx, stepUpTokenPurpose(action, operation), userID, stepUpTokenTTL)
+	if err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+	return models.StepUpTokenResponse{StepUpToken: token, ExpiresIn: int(stepUpTokenTTL.Seconds())}, nil
+}
+
+// ConfirmWithPIN проверяет PIN-код операций и выдает токен для операции с отпечатком operation.
+// После max_pin_attempts ошибок подряд подтверждение PIN-кодом недоступно pin_lock_minutes.
+func (s *StepUpService) ConfirmWithPIN(ctx context.Context, userID int, action, operation, pin string) (models.StepUpTokenResponse, error) {
+	if !validStepUpAction(action) {
+		return models.StepUpTokenResponse{}, fmt.Errorf("%w: unknown action", errs.ErrValidationFailed)
+	}
+	if err := validStepUpOperation(action, operation); err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+
+	pinHash, err := s.Users.GetTransactionPIN(userID)
+	if err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+	if pinHash == "" {
+		return models.StepUpTokenResponse{}, errs.ErrPINNotSet
+	}
+
+	cfg := stepUpSettings()
+	subject := strconv.Itoa(userID)
+	attempts, err := s.OTP.Repo.IncrementAttempts(ctx, pinAttemptsPurpose, subject, pinLockDuration(cfg))
+	if err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+	if attempts > cfg.MaxPinAttempts {
+		logger.Warn.Printf("[StepUpService] Too many PIN attempts for userID=%d", userID)
+		return models.StepUpTokenResponse{}, errs.ErrTooManyAttempts
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(pinHash), []byte(pin)) != nil {
+		logger.Warn.Printf("[StepUpService] Wrong PIN for userID=%d (attempt %d)", userID, attempts)
+		return models.StepUpTokenResponse{}, errs.ErrInvalidPIN
+	}
+
+	if err := s.OTP.Repo.Delete(ctx, pinAttemptsPurpose, subject); err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+	return s.issue(ctx, userID, action, operation)
+}
+
+// SendSMS отправляет код подтверждения действия на телефон пользователя
+func (s *StepUpService) SendSMS(ctx context.Context, userID int, action string) error {
+	if !validStepUpAction(action) {
+		return fmt.Errorf("%w: unknown action", errs.ErrValidationFailed)
+	}
+
+	user, err := s.Users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.OTP.Send(ctx, stepUpPurpose(action), strconv.Itoa(userID), user.Phone,
+		"WalletX: confirmation code %s. Never share it, WalletX staff will never ask for it.")
+}
+
+// ConfirmWithSMS обменивает SMS-код на токен подтверждения операции с отпечатком operation
+func (s *StepUpService) ConfirmWithSMS(ctx context.Context, userID int, action, operation, code string) (models.StepUpTokenResponse, error) {
+	if !validStepUpAction(action) {
+		return models.StepUpTokenResponse{}, fmt.Errorf("%w: unknown action", errs.ErrValidationFailed)
+	}
+	if err := validStepUpOperation(action, operation); err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+
+	if err := s.OTP.Verify(ctx, stepUpPurpose(action), strconv.Itoa(userID), code); err != nil {
+		return models.StepUpTokenResponse{}, err
+	}
+	return s.issue(ctx, userID, action, operation)
+}
+
+// SetPIN задает или меняет PIN-код операций. Это настройка безопасности, поэтому нужен
+// токен подтверждения действия security (при первой установке — через SMS).
+func (s *StepUpService) SetPIN(ctx context.Context, userID int, pin string) error {
+	cfg := stepUpSettings()
+	policy := passwordpolicy.New(models.PasswordPolicy{PinMode: true, PinLength: cfg.PinLength, RejectCommon: true})
+	if err := policy.Validate(pin); err != nil {
+		return err
+	}
+
+	if err := s.Authorize(ctx, userID, StepUpOperation{Action: models.StepUpActionSecurity}); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error.Printf("[StepUpService] Failed to hash PIN: %v", err)
+		return errs.ErrInternal
+	}
+	if err := s.Users.SetTransactionPIN(userID, string(hash)); err != nil {
+		return err
+	}
+	if err := s.OTP.Repo.Delete(ctx, pinAttemptsPurpose, strconv.Itoa(userID)); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[StepUpService] Transaction PIN set for userID=%d", userID)
+	return nil
+}
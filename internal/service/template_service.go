@@ -0,0 +1,130 @@
+package service
+
+import (
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"context"
+	"fmt"
+	"strings"
+)
+
+const recentRecipientsLimit = 10
+
+type TemplateService struct {
+	Repo     repository.TemplateRepository
+	Transfer *TransferService
+	Payment  *PaymentService
+}
+
+func NewTemplateService(repo repository.TemplateRepository, transferService *TransferService, paymentService *PaymentService) *TemplateService {
+	return &TemplateService{
+		Repo:     repo,
+		Transfer: transferService,
+		Payment:  paymentService,
+	}
+}
+
+func (s *TemplateService) Create(ctx context.Context, userID int, req models.CreateTemplateRequest) (models.PaymentTemplate, error) {
+	template := models.PaymentTemplate{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Type:        req.Type,
+		ServiceType: req.ServiceType,
+		Target:      strings.TrimSpace(req.Target),
+		Amount:      req.Amount,
+	}
+
+	if template.Name == "" || template.Target == "" {
+		logger.Warn.Printf("[TemplateService] Required fields missing, userID=%d", userID)
+		return models.PaymentTemplate{}, errs.ErrRequiredFields
+	}
+	if template.Amount < 0 {
+		logger.Warn.Printf("[TemplateService] Negative default amount, userID=%d", userID)
+		return models.PaymentTemplate{}, errs.ErrInvalidAmount
+	}
+
+	switch template.Type {
+	case models.TemplateTypeTransfer:
+		if !phoneRegex.MatchString(template.Target) {
+			logger.Warn.Printf("[TemplateService] Invalid recipient phone in template, userID=%d", userID)
+			return models.PaymentTemplate{}, errs.ErrInvalidPhone
+		}
+		template.ServiceType = ""
+	case models.TemplateTypePayment:
+		if _, err := s.Payment.ServiceRepo.GetServiceIDByType(template.ServiceType); err != nil {
+			logger.Warn.Printf("[TemplateService] Unknown service type %q, userID=%d", template.ServiceType, userID)
+			return models.PaymentTemplate{}, fmt.Errorf("%w: unknown service type %q", errs.ErrInvalidTemplate, template.ServiceType)
+		}
+	default:
+		logger.Warn.Printf("[TemplateService] Unknown template type %q, userID=%d", template.Type, userID)
+		return models.PaymentTemplate{}, fmt.Errorf("%w: unknown type %q", errs.ErrInvalidTemplate, template.Type)
+	}
+
+	return s.Repo.Create(ctx, template)
+}
+
+func (s *TemplateService) List(ctx context.Context, userID int) ([]models.PaymentTemplate, error) {
+	return s.Repo.GetByUserID(ctx, userID)
+}
+
+func (s *TemplateService) Delete(ctx context.Context, userID, templateID int) error {
+	return s.Repo.Delete(ctx, userID, templateID)
+}
+
+// Execute выполняет перевод или платеж по шаблону. Если amount > 0, он заменяет сумму из шаблона.
+func (s *TemplateService) Execute(ctx context.Context, userID, templateID int, amount float64) (models.Receipt, error) {
+	template, err := s.Repo.GetByID(ctx, userID, templateID)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	if amount <= 0 {
+		amount = template.Amount
+	}
+	if amount <= 0 {
+		logger.Warn.Printf("[TemplateService] No amount for template id=%d", templateID)
+		return models.Receipt{}, errs.ErrInvalidAmount
+	}
+
+	var receipt models.Receipt
+	switch template.Type {
+	case models.TemplateTypeTransfer:
+		fromAcc, err := s.Transfer.AccountRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return models.Receipt{}, err
+		}
+		toAcc, err := s.Transfer.AccountRepo.GetByPhone(ctx, template.Target)
+		if err != nil {
+			return models.Receipt{}, err
+		}
+		receipt, err = s.Transfer.Transfer(ctx, fromAcc.ID, toAcc.ID, amount)
+		if err != nil {
+			return models.Receipt{}, err
+		}
+	case models.TemplateTypePayment:
+		toID, err := s.Payment.ServiceRepo.GetServiceIDByType(template.ServiceType)
+		if err != nil {
+			return models.Receipt{}, fmt.Errorf("%w: %v", errs.ErrInvalidTemplate, err)
+		}
+		receipt, err = s.Payment.Pay(ctx, userID, toID, amount, template.ServiceType, template.Target)
+		if err != nil {
+			return models.Receipt{}, err
+		}
+	default:
+		return models.Receipt{}, errs.ErrInvalidTemplate
+	}
+
+	logger.Info.Printf("[TemplateService] Executed template id=%d type=%s for userID=%d amount=%.2f",
+		template.ID, template.Type, userID, amount)
+	return receipt, nil
+}
+
+func (s *TemplateService) RecentRecipients(ctx context.Context, userID int) ([]models.RecentRecipient, error) {
+	account, err := s.Transfer.AccountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.GetRecentRecipients(ctx, account.ID, recentRecipientsLimit)
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"WalletX/config"
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const defaultRefreshTTL = 30 * 24 * time.Hour
+
+type TokenService struct {
+	Repo    repository.TokenRepository
+	Devices repository.DeviceRepository
+	Users   repository.UserRepository
+}
+
+func NewTokenService(repo repository.TokenRepository, devices repository.DeviceRepository, users repository.UserRepository) *TokenService {
+	return &TokenService{Repo: repo, Devices: devices, Users: users}
+}
+
+func refreshTTL() time.Duration {
+	hours := config.AppSettings.AuthParams.RefreshTtlHours
+	if hours <= 0 {
+		return defaultRefreshTTL
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// revocationTTL — сколько хранить отметку об отзыве: пока живы выпущенные до нее токены
+func revocationTTL() time.Duration {
+	ttl := refreshTTL()
+	if access := utils.AccessTokenTTL(); access > ttl {
+		ttl = access
+	}
+	return ttl
+}
+
+// IssuePair начинает новую сессию на устройстве: access-токен и refresh-токен новой цепочки ротации
+func (s *TokenService) IssuePair(ctx context.Context, userID, deviceID int) (models.TokenPair, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return models.TokenPair{}, errs.ErrInternal
+	}
+	return s.issue(ctx, models.RefreshSession{UserID: userID, DeviceID: deviceID, FamilyID: familyID})
+}
+
+// IssueClientPair начинает сессию партнерского приложения clientID от имени пользователя с областями scope
+func (s *TokenService) IssueClientPair(ctx context.Context, userID int, clientID, scope string) (models.TokenPair, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return models.TokenPair{}, errs.ErrInternal
+	}
+	return s.issue(ctx, models.RefreshSession{UserID: userID, FamilyID: familyID, ClientID: clientID, Scope: scope})
+}
+
+// IssueClientToken выпускает access-токен самого приложения (client_credentials), без refresh-токена
+func (s *TokenService) IssueClientToken(clientID, scope string) (models.TokenPair, error) {
+	access, err := utils.GenerateClientToken(0, clientID, scope)
+	if err != nil {
+		logger.Error.Printf("[TokenService] Failed to generate access token for client %s: %v", clientID, err)
+		return models.TokenPair{}, errs.ErrInternal
+	}
+	return models.TokenPair{Token: access, ExpiresIn: int(utils.AccessTokenTTL().Seconds()), Scope: scope}, nil
+}
+
+// issue выпускает пару токенов для сессии. Пользователь читается из базы при каждом выпуске: после
+// блокировки сессии не продлеваются, а новая роль попадает в токен не позже следующего обновления.
+// Токены приложений роли не несут.
+func (s *TokenService) issue(ctx context.Context, session models.RefreshSession) (models.TokenPair, error) {
+	user, err := s.Users.GetByID(session.UserID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if err := checkLoginAllowed(user, time.Now()); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	var access string
+	if session.ClientID != "" {
+		token, err := utils.GenerateClientToken(session.UserID, session.ClientID, session.Scope)
+		if err != nil {
+			logger.Error.Printf("[TokenService] Failed to generate access token for client %s: %v", session.ClientID, err)
+			return models.TokenPair{}, errs.ErrInternal
+		}
+		access = token
+	} else {
+		token, err := utils.GenerateToken(session.UserID, models.NormalizeRole(user.Role), session.DeviceID)
+		if err != nil {
+			logger.Error.Printf("[TokenService] Failed to generate access token for userID=%d: %v", session.UserID, err)
+			return models.TokenPair{}, errs.ErrInternal
+		}
+		access = token
+	}
+
+	refresh, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return models.TokenPair{}, errs.ErrInternal
+	}
+
+	session.IssuedAt = time.Now()
+	if err := s.Repo.SaveRefresh(ctx, hashToken(refresh), session, refreshTTL()); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+		Scope:        session.Scope,
+	}, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару. Повторное предъявление уже использованного
+// токена считается признаком кражи: вся сессия и все токены пользователя отзываются.
+// ip — адрес клиента, он сохраняется как последний адрес устройства.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken, ip string) (models.TokenPair, error) {
+	session, err := s.rotate(ctx, refreshToken, "")
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	if session.DeviceID != 0 {
+		if err := s.Devices.Touch(ctx, session.DeviceID, ip); err != nil {
+			logger.Warn.Printf("[TokenService] Failed to update last seen for deviceID=%d: %v", session.DeviceID, err)
+		}
+	}
+
+	return s.issue(ctx, session)
+}
+
+// RefreshClient — то же для сессии приложения clientID. Повторное предъявление отзывает
+// все токены этого приложения от имени пользователя.
+func (s *TokenService) RefreshClient(ctx context.Context, refreshToken, clientID string) (models.TokenPair, error) {
+	session, err := s.rotate(ctx, refreshToken, clientID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return s.issue(ctx, session)
+}
+
+// rotate проверяет refresh-токен и помечает его использованным. Токен принимается, только если
+// выдан тому же клиенту: clientID == "" — собственные приложения кошелька.
+func (s *TokenService) rotate(ctx context.Context, refreshToken, clientID string) (models.RefreshSession, error) {
+	if refreshToken == "" {
+		return models.RefreshSession{}, errs.ErrInvalidRefreshToken
+	}
+	tokenHash := hashToken(refreshToken)
+
+	session, err := s.Repo.GetRefresh(ctx, tokenHash)
+	if err != nil {
+		return models.RefreshSession{}, err
+	}
+	if session.ClientID != clientID {
+		return models.RefreshSession{}, errs.ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.Repo.IsFamilyRevoked(ctx, session.FamilyID)
+	if err != nil {
+		return models.RefreshSession{}, err
+	}
+	if revoked {
+		logger.Warn.Printf("[TokenService] Refresh with revoked session for userID=%d", session.UserID)
+		return models.RefreshSession{}, errs.ErrTokenRevoked
+	}
+
+	revokedBefore, err := s.Repo.GetUserRevokedBefore(ctx, session.UserID)
+	if err != nil {
+		return models.RefreshSession{}, err
+	}
+	if !session.IssuedAt.After(revokedBefore) {
+		return models.RefreshSession{}, errs.ErrTokenRevoked
+	}
+
+	if session.DeviceID != 0 {
+		deviceRevokedBefore, err := s.Repo.GetDeviceRevokedBefore(ctx, session.DeviceID)
+		if err != nil {
+			return models.RefreshSession{}, err
+		}
+		if !session.IssuedAt.After(deviceRevokedBefore) {
+			return models.RefreshSession{}, errs.ErrTokenRevoked
+		}
+	}
+
+	if session.ClientID != "" {
+		consentRevokedBefore, err := s.Repo.GetConsentRevokedBefore(ctx, session.UserID, session.ClientID)
+		if err != nil {
+			return models.RefreshSession{}, err
+		}
+		if !session.IssuedAt.After(consentRevokedBefore) {
+			return models.RefreshSession{}, errs.ErrTokenRevoked
+		}
+	}
+
+	firstUse, err := s.Repo.MarkRefreshUsed(ctx, tokenHash, refreshTTL())
+	if err != nil {
+		return models.RefreshSession{}, err
+	}
+	if !firstUse {
+		logger.Warn.Printf("[TokenService] Refresh token reuse detected for userID=%d client=%q, revoking sessions", session.UserID, session.ClientID)
+		if err := s.Repo.RevokeFamily(ctx, session.FamilyID, refreshTTL()); err != nil {
+			return models.RefreshSession{}, err
+		}
+		if session.ClientID != "" {
+			err = s.RevokeConsent(ctx, session.UserID, session.ClientID)
+		} else {
+			err = s.LogoutAll(ctx, session.UserID)
+		}
+		if err != nil {
+			return models.RefreshSession{}, err
+		}
+		return models.RefreshSession{}, errs.ErrTokenRevoked
+	}
+
+	return session, nil
+}
+
+// Logout отзывает текущий access-токен и, если передан, refresh-токен его сессии
+func (s *TokenService) Logout(ctx context.Context, claims *utils.CustomClaims, refreshToken string) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if err := s.Repo.RevokeAccessToken(ctx, claims.Id, ttl); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	session, err := s.Repo.GetRefresh(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if session.UserID != claims.UserID {
+		return errs.ErrInvalidRefreshToken
+	}
+
+	logger.Info.Printf("[TokenService] User %d logged out", claims.UserID)
+	return s.Repo.RevokeFamily(ctx, session.FamilyID, refreshTTL())
+}
+
+// LogoutAll делает недействительными все уже выданные токены пользователя
+func (s *TokenService) LogoutAll(ctx context.Context, userID int) error {
+	if err := s.Repo.RevokeUserTokens(ctx, userID, time.Now(), revocationTTL()); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[TokenService] All sessions revoked for userID=%d", userID)
+	return nil
+}
+
+// RevokeDevice делает недействительными все токены, выпущенные устройству
+func (s *TokenService) RevokeDevice(ctx context.Context, deviceID int) error {
+	if err := s.Repo.RevokeDeviceTokens(ctx, deviceID, time.Now(), revocationTTL()); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[TokenService] Sessions revoked for deviceID=%d", deviceID)
+	return nil
+}
+
+// RevokeConsent делает недействительными все токены приложения clientID от имени пользователя
+func (s *TokenService) RevokeConsent(ctx context.Context, userID int, clientID string) error {
+	if err := s.Repo.RevokeConsentTokens(ctx, userID, clientID, time.Now(), revocationTTL()); err != nil {
+		return err
+	}
+
+	logger.Info.Printf("[TokenService] Tokens of client %s revoked for userID=%d", clientID, userID)
+	return nil
+}
+
+// IssueOneTimeToken выдает одноразовый токен для шага purpose (сброс пароля и т.п.)
+func (s *TokenService) IssueOneTimeToken(ctx context.Context, purpose string, userID int, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", errs.ErrInternal
+	}
+	if err := s.Repo.SaveOneTimeToken(ctx, purpose, hashToken(token), userID, ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumeOneTimeToken проверяет одноразовый токен и возвращает пользователя, которому он выдан
+func (s *TokenService) ConsumeOneTimeToken(ctx context.Context, purpose, token string) (int, error) {
+	if token == "" {
+		return 0, errs.ErrInvalidOneTimeToken
+	}
+	return s.Repo.ConsumeOneTimeToken(ctx, purpose, hashToken(token))
+}
+
+// IsRevoked проверяет access-токен по списку отзыва
+func (s *TokenService) IsRevoked(ctx context.Context, claims *utils.CustomClaims) (bool, error) {
+	if claims.Id != "" {
+		revoked, err := s.Repo.IsAccessTokenRevoked(ctx, claims.Id)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	revokedBefore, err := s.Repo.GetUserRevokedBefore(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if issuedBefore(claims, revokedBefore) {
+		return true, nil
+	}
+
+	if claims.ClientID != "" && claims.UserID != 0 {
+		consentRevokedBefore, err := s.Repo.GetConsentRevokedBefore(ctx, claims.UserID, claims.ClientID)
+		if err != nil {
+			return false, err
+		}
+		if issuedBefore(claims, consentRevokedBefore) {
+			return true, nil
+		}
+	}
+
+	if claims.DeviceID == 0 {
+		return false, nil
+	}
+	deviceRevokedBefore, err := s.Repo.GetDeviceRevokedBefore(ctx, claims.DeviceID)
+	if err != nil {
+		return false, err
+	}
+	return issuedBefore(claims, deviceRevokedBefore), nil
+}
+
+// issuedBefore сравнивает время выпуска токена с отметкой об отзыве по iat_ms, строго: токен,
+// выпущенный сразу после отзыва (вход после смены пароля), остается действительным. У токенов без iat_ms
+// есть только iat в секундах, и выпущенные в ту же секунду, что и отметка, считаются отозванными.
+func issuedBefore(claims *utils.CustomClaims, cutoff time.Time) bool {
+	if cutoff.IsZero() {
+		return false
+	}
+	if claims.IssuedAtMs != 0 {
+		return claims.IssuedAtMs < cutoff.UnixMilli()
+	}
+	return claims.IssuedAt <= cutoff.Unix()
+}
@@ -6,31 +6,91 @@ import (
 	"WalletX/models"
 	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"WalletX/pkg/tracing"
+	"WalletX/pkg/utils"
 	"context"
+	"errors"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TransferService struct {
 	AccountRepo     repository.AccountRepository
 	TransactionRepo repository.TransactionRepository
+	ReceiptRepo     repository.ReceiptRepository
 	TM              transaction.TransactionManager
+	StepUp          *StepUpService
 }
 
-func NewTransferService(accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, tm transaction.TransactionManager) *TransferService {
+func NewTransferService(accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, receiptRepo repository.ReceiptRepository, tm transaction.TransactionManager, stepUp *StepUpService) *TransferService {
 	return &TransferService{
 		AccountRepo:     accountRepo,
 		TransactionRepo: transactionRepo,
+		ReceiptRepo:     receiptRepo,
 		TM:              tm,
+		StepUp:          stepUp,
 	}
 }
 
-func (s *TransferService) Transfer(ctx context.Context, fromAccountID, toAccountID int, amount float64) error {
+func (s *TransferService) Transfer(ctx context.Context, fromAccountID, toAccountID int, amount float64) (models.Receipt, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransferService.Transfer", trace.WithAttributes(
+		attribute.Int("account.from", fromAccountID),
+		attribute.Int("account.to", toAccountID),
+		attribute.Float64("amount", amount),
+	))
+	receipt, err := s.transfer(ctx, fromAccountID, toAccountID, amount)
+	outcome := moneyOutcome(err)
+	span.SetAttributes(attribute.String("outcome", outcome))
+	tracing.End(span, err)
+
+	metrics.ObserveMoneyOperation("transfer", "transfer", outcome, amount)
+	return receipt, err
+}
+
+// moneyOutcome переводит результат перевода или платежа в метку outcome метрик
+func moneyOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, errs.ErrInsufficientFunds), errors.Is(err, errs.ErrInsufficientBalance), errors.Is(err, errInsufficientBalance):
+		return metrics.OutcomeInsufficientFunds
+	case errors.Is(err, errs.ErrAccountFrozen):
+		return metrics.OutcomeFrozen
+	case errors.Is(err, errs.ErrStepUpRequired):
+		return metrics.OutcomeStepUpRequired
+	case errors.Is(err, errs.ErrInvalidAmount), errors.Is(err, errs.ErrSelfTransfer), errors.Is(err, errs.ErrUserNotFound):
+		return metrics.OutcomeRejected
+	default:
+		return metrics.OutcomeFailed
+	}
+}
+
+func (s *TransferService) transfer(ctx context.Context, fromAccountID, toAccountID int, amount float64) (models.Receipt, error) {
 	if amount <= 0 {
 		logger.Warn.Printf("[TransferService] Invalid transfer amount: %.2f", amount)
-		return errs.ErrInvalidAmount
+		return models.Receipt{}, errs.ErrInvalidAmount
+	}
+
+	if fromAccountID == toAccountID {
+		logger.Warn.Printf("[TransferService] Attempt to transfer to self: accountID=%d", fromAccountID)
+		return models.Receipt{}, errs.ErrSelfTransfer
 	}
 
-	return s.TM.WithinTransaction(ctx, func(txCtx context.Context) error {
+	sender, err := s.AccountRepo.GetByID(ctx, fromAccountID)
+	if err != nil {
+		logger.Warn.Printf("[TransferService] Sender account not found: %v", err)
+		return models.Receipt{}, errs.ErrUserNotFound
+	}
+	op := StepUpOperation{Action: models.StepUpActionTransfer, Amount: amount, FromAccountID: fromAccountID, ToAccountID: toAccountID}
+	if err := s.StepUp.Authorize(ctx, sender.UserID, op); err != nil {
+		return models.Receipt{}, err
+	}
+
+	var receipt models.Receipt
+	err = s.TM.WithinTransaction(ctx, func(txCtx context.Context) error {
 		fromAcc, err := s.AccountRepo.GetByID(txCtx, fromAccountID)
 		if err != nil {
 			logger.Warn.Printf("[TransferService] Sender account not found: %v", err)
@@ -48,13 +108,24 @@ func (s *TransferService) Transfer(ctx context.Context, fromAccountID, toAccount
 			return errs.ErrSelfTransfer
 		}
 
+		if fromAcc.IsFrozen {
+			logger.Warn.Printf("[TransferService] Sender account is frozen: accountID=%d", fromAcc.ID)
+			return errs.ErrAccountFrozen
+		}
+
 		if fromAcc.Balance < amount {
 			logger.Warn.Printf("[TransferService] Insufficient funds: fromAccountID=%d, balance=%.2f, requested=%.2f",
 				fromAcc.ID, fromAcc.Balance, amount)
 			return errs.ErrInsufficientFunds
 		}
 
+		// повторная проверка под блокировкой строки: счет могли заморозить или списать с него
+		// параллельно, пока шли проверки выше, — такие отказы отдаем клиенту как есть
 		if err := s.AccountRepo.DecreaseBalance(txCtx, fromAcc.ID, amount); err != nil {
+			if errors.Is(err, errs.ErrAccountFrozen) || errors.Is(err, errs.ErrInsufficientBalance) {
+				logger.Warn.Printf("[TransferService] Debit rejected: fromAccountID=%d, err=%v", fromAcc.ID, err)
+				return err
+			}
 			logger.Error.Printf("[TransferService] Failed to decrease balance: %v", err)
 			return errs.ErrInternal
 		}
@@ -72,13 +143,63 @@ func (s *TransferService) Transfer(ctx context.Context, fromAccountID, toAccount
 			Type:        "transfer",
 			CreatedAt:   time.Now(),
 		}
-		if _, err := s.TransactionRepo.CreateTransaction(tx); err != nil {
+		created, err := s.TransactionRepo.CreateTransaction(txCtx, tx)
+		if err != nil {
 			logger.Error.Printf("[TransferService] Failed to create transaction: %v", err)
 			return errs.ErrInternal
 		}
 
+		receipt, err = issueReceipt(txCtx, s.ReceiptRepo, created, nil, "")
+		if err != nil {
+			return errs.ErrInternal
+		}
+
 		logger.Info.Printf("[TransferService] Transfer success: fromID=%d, toID=%d, amount=%.2f",
 			fromAcc.ID, toAcc.ID, amount)
 		return nil
 	})
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	return receipt, nil
+}
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// History возвращает страницу истории операций пользователя в обоих направлениях
+func (s *TransferService) History(ctx context.Context, userID int, filter models.HistoryFilter) (models.TransactionHistoryPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+	if filter.Limit > maxHistoryLimit {
+		filter.Limit = maxHistoryLimit
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return models.TransactionHistoryPage{}, errs.ErrValidationFailed
+	}
+
+	account, err := s.AccountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return models.TransactionHistoryPage{}, err
+	}
+
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	items, err := s.AccountRepo.GetTransactions(ctx, account.ID, filter)
+	if err != nil {
+		return models.TransactionHistoryPage{}, err
+	}
+
+	page := models.TransactionHistoryPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
 }
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"WalletX/config"
+	"WalletX/internal/repository"
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"WalletX/pkg/logger"
+	"WalletX/pkg/metrics"
+	"WalletX/pkg/totp"
+	"context"
+	"crypto/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultTOTPIssuer        = "WalletX"
+	defaultRecoveryCodeCount = 10
+	// purpose счетчика неверных кодов 2FA в OTPRepository
+	totpAttemptsPurpose = "totp"
+	totpMaxAttempts     = 5
+	totpAttemptsWindow  = 5 * time.Minute
+
+	// без похожих символов 0/O и 1/I/L
+	recoveryAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+// TwoFactorService — двухфакторная аутентификация через приложение-аутентификатор (TOTP)
+// и одноразовые коды восстановления на случай потери телефона
+type TwoFactorService struct {
+	Repo     repository.TwoFactorRepository
+	Users    repository.UserRepository
+	Attempts repository.OTPRepository
+}
+
+func NewTwoFactorService(repo repository.TwoFactorRepository, users repository.UserRepository, attempts repository.OTPRepository) *TwoFactorService {
+	return &TwoFactorService{Repo: repo, Users: users, Attempts: attempts}
+}
+
+func twoFactorSettings() models.TwoFactor {
+	cfg := config.AppSettings.AuthParams.TwoFactor
+	if cfg.Issuer == "" {
+		cfg.Issuer = defaultTOTPIssuer
+	}
+	if cfg.SkewSteps < 0 {
+		cfg.SkewSteps = 0
+	}
+	if cfg.RecoveryCodeCount <= 0 {
+		cfg.RecoveryCodeCount = defaultRecoveryCodeCount
+	}
+	return cfg
+}
+
+// IsEnabled сообщает, нужен ли пользователю второй фактор при входе
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	state, err := s.Repo.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return state.Enabled, nil
+}
+
+// Setup начинает подключение: генерирует секрет, который пользователь добавляет в приложение.
+// 2FA включается только после подтверждения кодом в Enable.
+func (s *TwoFactorService) Setup(ctx context.Context, userID int) (models.TwoFactorSetupResponse, error) {
+	user, err := s.Users.GetByID(userID)
+	if err != nil {
+		return models.TwoFactorSetupResponse{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Error.Printf("[TwoFactorService] Failed to generate secret: %v", err)
+		return models.TwoFactorSetupResponse{}, errs.ErrInternal
+	}
+	if err := s.Repo.SavePendingSecret(ctx, userID, secret); err != nil {
+		return models.TwoFactorSetupResponse{}, err
+	}
+
+	logger.Info.Printf("[TwoFactorService] TOTP setup started for userID=%d", userID)
+	return models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(twoFactorSettings().Issuer, user.Phone, secret),
+	}, nil
+}
+
+// Enable подтверждает подключение первым кодом из приложения и выдает коды восстановления
+func (s *TwoFactorService) Enable(ctx context.Context, userID int, code string) (models.RecoveryCodesResponse, error) {
+	state, err := s.Repo.Get(ctx, userID)
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+	if state.Enabled {
+		return models.RecoveryCodesResponse{}, errs.ErrTwoFactorEnabled
+	}
+	if state.Secret == "" {
+		return models.RecoveryCodesResponse{}, errs.ErrTwoFactorNoSetup
+	}
+
+	if err := s.countAttempt(ctx, userID); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+	step, ok := totp.Validate(state.Secret, code, time.Now(), twoFactorSettings().SkewSteps)
+	if !ok {
+		return models.RecoveryCodesResponse{}, errs.ErrInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+	if err := s.Repo.Enable(ctx, userID, step, hashes); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+	s.resetAttempts(ctx, userID)
+
+	logger.Info.Printf("[TwoFactorService] TOTP enabled for userID=%d", userID)
+	return models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Verify проверяет второй фактор: код из приложения или, если передан, код восстановления.
+// Каждый код принимается один раз.
+func (s *TwoFactorService) Verify(ctx context.Context, userID int, code, recoveryCode string) error {
+	state, err := s.Repo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		return errs.ErrTwoFactorNotEnabled
+	}
+
+	if err := s.countAttempt(ctx, userID); err != nil {
+		return err
+	}
+
+	if recoveryCode != "" {
+		used, err := s.Repo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			logger.Warn.Printf("[TwoFactorService] Invalid recovery code for userID=%d", userID)
+			return errs.ErrInvalidCode
+		}
+		logger.Warn.Printf("[TwoFactorService] Recovery code used by userID=%d", userID)
+		s.resetAttempts(ctx, userID)
+		return nil
+	}
+
+	step, ok := totp.Validate(state.Secret, code, time.Now(), twoFactorSettings().SkewSteps)
+	if !ok {
+		logger.Warn.Printf("[TwoFactorService] Invalid TOTP code for userID=%d", userID)
+		return errs.ErrInvalidCode
+	}
+	fresh, err := s.Repo.AcceptStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		logger.Warn.Printf("[TwoFactorService] Replayed TOTP code for userID=%d", userID)
+		return errs.ErrInvalidCode
+	}
+
+	s.resetAttempts(ctx, userID)
+	return nil
+}
+
+// Disable отключает 2FA. Нужны текущий пароль и второй фактор, одного access-токена недостаточно.
+func (s *TwoFactorService) Disable(ctx context.Context, userID int, req models.TwoFactorDisableRequest) error {
+	user, err := s.Users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := checkLoginAllowed(user, now); err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		metrics.LoginFailures.WithLabelValues(metrics.LoginWrongPassword).Inc()
+		if err := registerFailedLogin(s.Users, user, now); err != nil {
+			return err
+		}
+		logger.Warn.Printf("[TwoFactorService] Wrong password on disable for userID=%d", userID)
+		return errs.ErrWrongPassword
+	}
+	if err := s.Verify(ctx, userID, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+
+	if err := s.Repo.Disable(ctx, userID); err != nil {
+		return err
+	}
+	logger.Info.Printf("[TwoFactorService] TOTP disabled for userID=%d", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления, старые перестают действовать
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) (models.RecoveryCodesResponse, error) {
+	if err := s.Verify(ctx, userID, code, ""); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+	if err := s.Repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return models.RecoveryCodesResponse{}, err
+	}
+
+	logger.Info.Printf("[TwoFactorService] Recovery codes regenerated for userID=%d", userID)
+	return models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *TwoFactorService) countAttempt(ctx context.Context, userID int) error {
+	attempts, err := s.Attempts.IncrementAttempts(ctx, totpAttemptsPurpose, strconv.Itoa(userID), totpAttemptsWindow)
+	if err != nil {
+		return err
+	}
+	if attempts > totpMaxAttempts {
+		logger.Warn.Printf("[TwoFactorService] Too many 2FA attempts for userID=%d", userID)
+		return errs.ErrTooManyAttempts
+	}
+	return nil
+}
+
+func (s *TwoFactorService) resetAttempts(ctx context.Context, userID int) {
+	if err := s.Attempts.Delete(ctx, totpAttemptsPurpose, strconv.Itoa(userID)); err != nil {
+		logger.Warn.Printf("[TwoFactorService] Failed to reset 2FA attempts for userID=%d: %v", userID, err)
+	}
+}
+
+// generateRecoveryCodes возвращает коды вида XXXX-XXXX и их хеши для хранения.
+// Коды случайные и длинные, поэтому для них достаточно SHA-256 без bcrypt.
+func generateRecoveryCodes() ([]string, []string, error) {
+	count := twoFactorSettings().RecoveryCodeCount
+	codes := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			logger.Error.Printf("[TwoFactorService] Failed to generate recovery code: %v", err)
+			return nil, nil, errs.ErrInternal
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func randomRecoveryCode() (string, error) {
+	// байты из хвоста, который не делится на размер алфавита, отбрасываются, чтобы символы были равновероятны
+	limit := byte(256 - 256%len(recoveryAlphabet))
+	code := make([]byte, 0, 9)
+	buf := make([]byte, 16)
+	for len(code) < 9 {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if len(code) == 9 {
+				break
+			}
+			if len(code) == 4 {
+				code = append(code, '-')
+			}
+			if b < limit {
+				code = append(code, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -5,6 +5,7 @@ import (
 	"WalletX/models"
 	"WalletX/pkg/errs"
 	"WalletX/pkg/logger"
+	"context"
 )
 
 type UserProfileService struct {
@@ -17,16 +18,16 @@ func NewUserProfileService(r repository.UserProfileRepository) *UserProfileServi
 	}
 }
 
-func (s *UserProfileService) GetProfileByID(id int) (*models.UserProfileResponse, error) {
+func (s *UserProfileService) GetProfileByID(ctx context.Context, id int) (*models.UserProfileResponse, error) {
 	logger.Info.Printf("[UserProfileService] GetProfileByID called with id=%d", id)
 
-	user, err := s.repo.GetProfileByID(id)
+	user, err := s.repo.GetProfileByID(ctx, id)
 	if err != nil {
 		logger.Error.Printf("[UserProfileService] Failed to get user by ID=%d: %v", id, err)
 		return nil, errs.ErrInternal
 	}
 
-	logger.Info.Printf("[UserProfileService] Successfully retrieved profile for userID=%d: %+v", id, user)
+	logger.Info.Printf("[UserProfileService] Successfully retrieved profile for userID=%d", id)
 	return &user, nil
 }
 
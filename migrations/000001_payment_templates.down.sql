@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_account_from_created_at;
+DROP TABLE IF EXISTS payment_templates;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS payment_templates (
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(100)   NOT NULL,
+    type         VARCHAR(20)    NOT NULL CHECK (type IN ('transfer', 'payment')),
+    service_type VARCHAR(100)   NOT NULL DEFAULT '',
+    target       VARCHAR(100)   NOT NULL,
+    amount       NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    created_at   TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_templates_user_id ON payment_templates (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_account_from_created_at ON transactions (account_from, created_at DESC);
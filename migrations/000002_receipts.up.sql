@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS receipts (
+    id                  SERIAL PRIMARY KEY,
+    receipt_number      VARCHAR(32)    NOT NULL UNIQUE,
+    transaction_id      INTEGER        NOT NULL UNIQUE REFERENCES transactions (id),
+    type                VARCHAR(50)    NOT NULL,
+    status              VARCHAR(20)    NOT NULL DEFAULT 'completed',
+    payer_user_id       INTEGER        NOT NULL REFERENCES users (id),
+    payer_account_id    INTEGER        NOT NULL,
+    payer_phone         VARCHAR(20)    NOT NULL,
+    payee_user_id       INTEGER        REFERENCES users (id),
+    payee_account_id    INTEGER        NOT NULL,
+    payee_phone         VARCHAR(20),
+    service_name        VARCHAR(100),
+    service_description TEXT,
+    subscriber_account  VARCHAR(100),
+    amount              NUMERIC(14, 2) NOT NULL,
+    fee                 NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    total               NUMERIC(14, 2) NOT NULL,
+    created_at          TIMESTAMP      NOT NULL,
+    issued_at           TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipts_payer_user_id ON receipts (payer_user_id);
+CREATE INDEX IF NOT EXISTS idx_receipts_payee_user_id ON receipts (payee_user_id);
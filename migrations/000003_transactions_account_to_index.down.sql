@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_transactions_account_to_created_at;
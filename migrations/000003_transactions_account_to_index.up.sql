@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_transactions_account_to_created_at ON transactions (account_to, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS devices;
+ALTER TABLE users RENAME COLUMN is_blocked TO device_id;
@@ -0,0 +1,17 @@
+-- users.device_id на самом деле был флагом блокировки
+ALTER TABLE users RENAME COLUMN device_id TO is_blocked;
+
+CREATE TABLE IF NOT EXISTS devices (
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER      NOT NULL REFERENCES users (id),
+    device_id    VARCHAR(128) NOT NULL,
+    name         VARCHAR(100) NOT NULL DEFAULT '',
+    platform     VARCHAR(50)  NOT NULL DEFAULT '',
+    last_ip      VARCHAR(45)  NOT NULL DEFAULT '',
+    last_seen_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    created_at   TIMESTAMP    NOT NULL DEFAULT NOW(),
+    revoked_at   TIMESTAMP,
+    UNIQUE (user_id, device_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user_id ON devices (user_id);
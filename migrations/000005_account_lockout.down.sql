@@ -0,0 +1,6 @@
+UPDATE users SET is_blocked = true WHERE locked_until > NOW();
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS lock_count,
+    DROP COLUMN IF EXISTS last_failed_login_at;
@@ -0,0 +1,13 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS locked_until         TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS lock_count           INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMP;
+
+-- is_blocked до сих пор ставился только после трех неверных паролей. Переводим таких пользователей
+-- на временную блокировку, которую можно снять по SMS-коду, а is_blocked оставляем для блокировки администратором.
+UPDATE users
+SET is_blocked        = false,
+    password_attempts = 0,
+    locked_until      = NOW() + INTERVAL '15 minutes',
+    lock_count        = 1
+WHERE is_blocked;
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
+UPDATE users SET password_changed_at = NOW() WHERE password IS NOT NULL AND password_changed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER      NOT NULL REFERENCES users (id),
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id, created_at DESC);
+
+INSERT INTO password_history (user_id, password_hash)
+SELECT id, password FROM users WHERE password IS NOT NULL AND password <> '';
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS transaction_pin_hash VARCHAR(255);
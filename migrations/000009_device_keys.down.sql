@@ -0,0 +1,2 @@
+ALTER TABLE devices DROP COLUMN IF EXISTS key_registered_at;
+ALTER TABLE devices DROP COLUMN IF EXISTS public_key;
@@ -0,0 +1,2 @@
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS public_key VARCHAR(64);
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS key_registered_at TIMESTAMP;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_created_at;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS frozen_reason;
+ALTER TABLE accounts DROP COLUMN IF EXISTS frozen_at;
+ALTER TABLE accounts DROP COLUMN IF EXISTS is_frozen;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
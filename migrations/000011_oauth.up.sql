@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id            SERIAL PRIMARY KEY,
+    client_id     VARCHAR(64)  NOT NULL UNIQUE,
+    secret_hash   VARCHAR(64),
+    name          VARCHAR(100) NOT NULL,
+    redirect_uris TEXT[]       NOT NULL DEFAULT '{}',
+    scopes        TEXT[]       NOT NULL DEFAULT '{}',
+    grant_types   TEXT[]       NOT NULL DEFAULT '{}',
+    is_active     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER   NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    client_id  INTEGER   NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    scopes     TEXT[]    NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, client_id)
+);
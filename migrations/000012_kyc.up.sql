@@ -0,0 +1,33 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_tier SMALLINT NOT NULL DEFAULT 0;
+UPDATE users SET verification_tier = 1 WHERE is_verified;
+
+CREATE TABLE IF NOT EXISTS kyc_applications (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER      NOT NULL REFERENCES users (id),
+    status          VARCHAR(20)  NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    first_name      VARCHAR(100) NOT NULL,
+    last_name       VARCHAR(100) NOT NULL,
+    middle_name     VARCHAR(100) NOT NULL,
+    passport_number VARCHAR(50)  NOT NULL,
+    reject_reason   VARCHAR(255),
+    reviewer_id     INTEGER REFERENCES users (id),
+    submitted_at    TIMESTAMP    NOT NULL DEFAULT NOW(),
+    reviewed_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_applications_user_id ON kyc_applications (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_kyc_applications_pending ON kyc_applications (id) WHERE status = 'pending';
+-- у пользователя не больше одной заявки на проверке
+CREATE UNIQUE INDEX IF NOT EXISTS uq_kyc_applications_pending_user ON kyc_applications (user_id) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id             SERIAL PRIMARY KEY,
+    application_id INTEGER      NOT NULL REFERENCES kyc_applications (id) ON DELETE CASCADE,
+    kind           VARCHAR(20)  NOT NULL CHECK (kind IN ('passport_front', 'passport_back', 'selfie')),
+    storage_key    VARCHAR(255) NOT NULL,
+    content_type   VARCHAR(50)  NOT NULL,
+    size_bytes     BIGINT       NOT NULL,
+    created_at     TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_application_id ON kyc_documents (application_id);
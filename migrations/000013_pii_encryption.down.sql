@@ -0,0 +1,5 @@
+-- Значения остаются зашифрованными: перед откатом их нужно расшифровать.
+ALTER TABLE kyc_applications DROP COLUMN IF EXISTS passport_index;
+
+DROP INDEX IF EXISTS uq_users_verified_passport_index;
+ALTER TABLE users DROP COLUMN IF EXISTS passport_index;
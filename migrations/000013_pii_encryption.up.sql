@@ -0,0 +1,18 @@
+-- Имена и номер паспорта хранятся зашифрованными (pkg/pii), шифртекст длиннее прежних ограничений.
+-- Существующие открытые значения перешифровываются сервером при чтении или командой cmd/pii-rotate.
+ALTER TABLE users
+    ALTER COLUMN first_name TYPE TEXT,
+    ALTER COLUMN last_name TYPE TEXT,
+    ALTER COLUMN middle_name TYPE TEXT,
+    ALTER COLUMN passport_number TYPE TEXT,
+    ADD COLUMN IF NOT EXISTS passport_index CHAR(64);
+
+-- слепой индекс паспорта: один паспорт может быть подтвержден только у одного пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_verified_passport_index ON users (passport_index) WHERE is_verified;
+
+ALTER TABLE kyc_applications
+    ALTER COLUMN first_name TYPE TEXT,
+    ALTER COLUMN last_name TYPE TEXT,
+    ALTER COLUMN middle_name TYPE TEXT,
+    ALTER COLUMN passport_number TYPE TEXT,
+    ADD COLUMN IF NOT EXISTS passport_index CHAR(64);
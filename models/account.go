@@ -7,6 +7,7 @@ type Account struct {
 	UserID       int       `json:"user_id "`
 	Balance      float64   `json:"balance" `
 	BonusBalance float64   `json:"bonus_balance"`
+	IsFrozen     bool      `json:"is_frozen"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
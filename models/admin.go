@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// AdminUser — карточка пользователя для поддержки и администраторов. Персональные данные
+// в карточку не попадают, их видно только в заявке KYC.
+type AdminUser struct {
+	ID          int        `json:"id" example:"7"`
+	Phone       string     `json:"phone" example:"+992931753756"`
+	Role        string     `json:"role" example:"user"`
+	IsVerified  bool       `json:"is_verified" example:"true"`
+	IsBlocked   bool       `json:"is_blocked" example:"false"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	Accounts    []Account  `json:"accounts"`
+}
+
+// AdminUserFilter — поиск пользователей: по id, точному номеру или началу номера
+type AdminUserFilter struct {
+	UserID      int
+	Phone       string
+	PhonePrefix string
+	Limit       int
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" example:"support" enums:"user,support,admin,auditor"`
+}
+
+type FreezeAccountRequest struct {
+	Reason string `json:"reason" example:"suspicious activity, ticket #1842"`
+}
+
+// AdminTransaction — операция в административном поиске с телефонами обеих сторон
+type AdminTransaction struct {
+	ID          int       `json:"id" example:"42"`
+	AccountFrom int       `json:"account_from" example:"2"`
+	AccountTo   int       `json:"account_to" example:"3"`
+	FromPhone   *string   `json:"from_phone,omitempty" example:"+992931753756"`
+	ToPhone     *string   `json:"to_phone,omitempty" example:"+992931000001"`
+	Amount      float64   `json:"amount" example:"100"`
+	Type        string    `json:"type" example:"transfer"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AdminTransactionPage struct {
+	Items      []AdminTransaction `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty" example:"MTc2MjA4MDQwMDAwMDAwMDAwMDo0Mg"`
+}
+
+// TransactionSearchFilter — фильтр административного поиска. AccountID == 0 — по всем счетам.
+type TransactionSearchFilter struct {
+	HistoryFilter
+	AccountID int
+}
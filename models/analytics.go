@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	AnalyticsPeriodDay   = "day"
+	AnalyticsPeriodWeek  = "week"
+	AnalyticsPeriodMonth = "month"
+)
+
+type AnalyticsTotals struct {
+	In       float64 `json:"in" example:"500"`
+	Out      float64 `json:"out" example:"320.5"`
+	CountIn  int     `json:"count_in" example:"3"`
+	CountOut int     `json:"count_out" example:"7"`
+}
+
+type AnalyticsPeriodBucket struct {
+	PeriodStart time.Time `json:"period_start"`
+	AnalyticsTotals
+}
+
+type AnalyticsCategory struct {
+	Category string `json:"category" example:"internet"`
+	AnalyticsTotals
+}
+
+type AnalyticsCounterparty struct {
+	Phone string `json:"phone" example:"+992931753756"`
+	AnalyticsTotals
+}
+
+type AnalyticsComparison struct {
+	From             time.Time       `json:"from"`
+	To               time.Time       `json:"to"`
+	Totals           AnalyticsTotals `json:"totals"`
+	InChangePercent  *float64        `json:"in_change_percent,omitempty" example:"12.5"`
+	OutChangePercent *float64        `json:"out_change_percent,omitempty" example:"-4.2"`
+}
+
+type SpendingAnalytics struct {
+	From           time.Time               `json:"from"`
+	To             time.Time               `json:"to"`
+	Period         string                  `json:"period" example:"month"`
+	Totals         AnalyticsTotals         `json:"totals"`
+	Previous       AnalyticsComparison     `json:"previous"`
+	ByPeriod       []AnalyticsPeriodBucket `json:"by_period"`
+	ByCategory     []AnalyticsCategory     `json:"by_category"`
+	ByCounterparty []AnalyticsCounterparty `json:"by_counterparty"`
+}
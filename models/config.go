@@ -5,31 +5,162 @@ type Config struct {
 	LogParams      LogParams      `json:"log_params"`
 	AppParams      AppParams      `json:"app_params"`
 	PostgresParams PostgresParams `json:"postgres_params"`
+	KYCParams      KYCParams      `json:"kyc_params"`
+	PIIParams      PIIParams      `json:"pii_params"`
+	TracingParams  TracingParams  `json:"tracing_params"`
+	HealthParams   HealthParams   `json:"health_params"`
 }
 type AuthParams struct {
-	JwtSecretKey  string `json:"jwt_secret_key"`
-	JwtTtlMinutes int    `json:"jwt_ttl_minutes"`
+	JwtSecretKey    string         `json:"jwt_secret_key"`
+	JwtTtlMinutes   int            `json:"jwt_ttl_minutes"`
+	RefreshTtlHours int            `json:"refresh_ttl_hours"`
+	SigningKeys     []SigningKey   `json:"signing_keys"`
+	KeyOverlapHours int            `json:"key_overlap_hours"`
+	Lockout         Lockout        `json:"lockout"`
+	PasswordPolicy  PasswordPolicy `json:"password_policy"`
+	StepUp          StepUp         `json:"step_up"`
+	TwoFactor       TwoFactor      `json:"two_factor"`
+	DeviceKeys      DeviceKeys     `json:"device_keys"`
+}
+
+// DeviceKeys — вход по ключу устройства и подпись запросов. Если RequireSignedRequests == false,
+// подпись обязательна только для устройств с зарегистрированным ключом.
+type DeviceKeys struct {
+	ChallengeTTLSeconds   int  `json:"challenge_ttl_seconds"`
+	RequireSignedRequests bool `json:"require_signed_requests"`
+	MaxClockSkewSeconds   int  `json:"max_clock_skew_seconds"`
+}
+
+// TwoFactor — параметры TOTP. SkewSteps — сколько 30-секундных шагов в обе стороны допускается
+// на расхождение часов телефона и сервера.
+type TwoFactor struct {
+	Issuer            string `json:"issuer"`
+	SkewSteps         int    `json:"skew_steps"`
+	RecoveryCodeCount int    `json:"recovery_code_count"`
+}
+
+// StepUp — когда операция требует дополнительного подтверждения PIN-кодом или SMS.
+// AmountThreshold <= 0 отключает проверку по сумме.
+type StepUp struct {
+	AmountThreshold float64 `json:"amount_threshold"`
+	NewRecipient    bool    `json:"new_recipient"`
+	PinLength       int     `json:"pin_length"`
+	MaxPinAttempts  int     `json:"max_pin_attempts"`
+	PinLockMinutes  int     `json:"pin_lock_minutes"`
+}
+
+// PasswordPolicy — требования к паролю. В режиме PinMode пароль — это PIN из PinLength цифр,
+// а требования к длине и классам символов не применяются.
+type PasswordPolicy struct {
+	MinLength      int  `json:"min_length"`
+	MaxLength      int  `json:"max_length"`
+	RequireUpper   bool `json:"require_upper"`
+	RequireLower   bool `json:"require_lower"`
+	RequireDigit   bool `json:"require_digit"`
+	RequireSpecial bool `json:"require_special"`
+	PinMode        bool `json:"pin_mode"`
+	PinLength      int  `json:"pin_length"`
+	RejectCommon   bool `json:"reject_common"`
+	HistorySize    int  `json:"history_size"`
+	ExpiryDays     int  `json:"expiry_days"`
+}
+
+// Lockout — политика временной блокировки после неверных паролей. Каждая следующая блокировка
+// длится вдвое дольше предыдущей, но не больше MaxLockMinutes. Счетчик неверных попыток
+// сбрасывается, если с последней ошибки прошло больше AttemptWindowMinutes.
+type Lockout struct {
+	MaxAttempts          int `json:"max_attempts"`
+	AttemptWindowMinutes int `json:"attempt_window_minutes"`
+	BaseLockMinutes      int `json:"base_lock_minutes"`
+	MaxLockMinutes       int `json:"max_lock_minutes"`
+}
+
+// SigningKey описывает ключ подписи JWT. Ключ начинает подписывать токены с NotBefore;
+// предыдущий ключ после этого еще KeyOverlapHours принимается при проверке.
+// Если указан только PublicKeyFile, ключ используется только для проверки.
+type SigningKey struct {
+	KeyID          string `json:"kid"`
+	Algorithm      string `json:"algorithm"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+	NotBefore      string `json:"not_before"`
 }
 
 type LogParams struct {
-	LogDirectory     string `json:"log_directory"`
-	LogInfo          string `json:"log_info"`
-	LogError         string `json:"log_error"`
-	LogWarn          string `json:"log_warn"`
-	LogDebug         string `json:"log_debug"`
-	MaxSizeMegabytes int    `json:"max_size_megabytes"`
-	MaxBackups       int    `json:"max_backups"`
-	MaxAgeDays       int    `json:"max_age_days"`
-	Compress         bool   `json:"compress"`
-	LocalTime        bool   `json:"local_time"`
+	LogDirectory     string    `json:"log_directory"`
+	LogInfo          string    `json:"log_info"`
+	LogError         string    `json:"log_error"`
+	LogWarn          string    `json:"log_warn"`
+	LogDebug         string    `json:"log_debug"`
+	MaxSizeMegabytes int       `json:"max_size_megabytes"`
+	MaxBackups       int       `json:"max_backups"`
+	MaxAgeDays       int       `json:"max_age_days"`
+	Compress         bool      `json:"compress"`
+	LocalTime        bool      `json:"local_time"`
+	Redaction        Redaction `json:"redaction"`
+	// Level — минимальный уровень (debug, info, warn, error), PackageLevels переопределяет его
+	// для отдельных пакетов: ключ — путь пакета или его окончание, например "internal/repository"
+	Level         string            `json:"level"`
+	PackageLevels map[string]string `json:"package_levels"`
+}
+
+// Redaction — маскирование секретов и персональных данных в логах. По умолчанию включено;
+// Fields и Patterns дополняют встроенные имена полей и шаблоны. KeepPhones оставляет номера
+// телефонов открытыми (например, на стенде разработки), Disabled отключает маскирование целиком.
+type Redaction struct {
+	Disabled   bool     `json:"disabled"`
+	KeepPhones bool     `json:"keep_phones"`
+	Fields     []string `json:"fields"`
+	Patterns   []string `json:"patterns"`
 }
 
+// AppParams — параметры HTTP-сервера. TrustedProxies — адреса или подсети (CIDR) обратных прокси:
+// X-Forwarded-For и X-Real-IP учитываются только в запросах от них. MetricsAddr — адрес отдельного
+// внутреннего listener'а для /metrics (пустой — метрики не отдаются).
 type AppParams struct {
-	ServerURL  string `json:"server_url"`
-	ServerName string `json:"server_name"`
-	AppVersion string `json:"app_version"`
-	PortRun    string `json:"port_run"`
-	GinMode    string `json:"gin_mode"`
+	ServerURL      string   `json:"server_url"`
+	ServerName     string   `json:"server_name"`
+	AppVersion     string   `json:"app_version"`
+	PortRun        string   `json:"port_run"`
+	GinMode        string   `json:"gin_mode"`
+	Currency       string   `json:"currency"`
+	TrustedProxies []string `json:"trusted_proxies"`
+	MetricsAddr    string   `json:"metrics_addr"`
+}
+
+// KYCParams — хранение документов проверки личности. StorageDir — каталог локального хранилища,
+// MaxFileSizeMB — предельный размер одного файла.
+type KYCParams struct {
+	StorageDir    string `json:"storage_dir"`
+	MaxFileSizeMB int    `json:"max_file_mb"`
+}
+
+// PIIParams — шифрование персональных данных. KeyringFile — JSON-файл со связкой ключей
+// шифрования и ключом слепого индекса (формат описан в pkg/pii).
+type PIIParams struct {
+	KeyringFile string `json:"keyring_file"`
+}
+
+// TracingParams — трассировка OpenTelemetry. Exporter: "none" (по умолчанию, спаны не записываются,
+// но W3C-контекст передается дальше), "stdout" или "otlp" — OTLP/HTTP на OTLPEndpoint (host:port).
+// SampleRatio — доля новых трасс, 0 означает все; решение вызывающего сервиса соблюдается.
+type TracingParams struct {
+	Exporter     string            `json:"exporter"`
+	OTLPEndpoint string            `json:"otlp_endpoint"`
+	OTLPInsecure bool              `json:"otlp_insecure"`
+	OTLPHeaders  map[string]string `json:"otlp_headers"`
+	SampleRatio  float64           `json:"sample_ratio"`
+}
+
+// HealthParams — проверки готовности (/readyz). По последнему файлу в MigrationsDir определяется ожидаемая
+// версия схемы. TimeoutsMs — таймауты проверок по имени (postgres, redis), по умолчанию 2 с.
+// После сигнала остановки /readyz ShutdownDelaySeconds отвечает 503, чтобы балансировщик успел снять
+// сервис с трафика, затем сервер ждет завершения запросов не дольше ShutdownTimeoutSeconds.
+type HealthParams struct {
+	MigrationsDir          string         `json:"migrations_dir"`
+	TimeoutsMs             map[string]int `json:"timeouts_ms"`
+	ShutdownDelaySeconds   int            `json:"shutdown_delay_seconds"`
+	ShutdownTimeoutSeconds int            `json:"shutdown_timeout_seconds"`
 }
 
 type PostgresParams struct {
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// DeviceInfo передается клиентом при входе. DeviceID — постоянный идентификатор установки приложения.
+type DeviceInfo struct {
+	DeviceID   string `json:"device_id,omitempty" example:"3f1c2a9e-8b7d-4e21-9a55-0c1d2e3f4a5b"`
+	DeviceName string `json:"device_name,omitempty" example:"Pixel 8"`
+	Platform   string `json:"platform,omitempty" example:"android"`
+}
+
+// Device — устройство, с которого пользователь входил. Каждое активное устройство — отдельная сессия.
+type Device struct {
+	ID         int        `json:"id" example:"7"`
+	UserID     int        `json:"-"`
+	DeviceID   string     `json:"device_id" example:"3f1c2a9e-8b7d-4e21-9a55-0c1d2e3f4a5b"`
+	Name       string     `json:"name" example:"Pixel 8"`
+	Platform   string     `json:"platform" example:"android"`
+	LastIP     string     `json:"last_ip" example:"10.0.0.12"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"-"`
+	PublicKey  string     `json:"-"`
+	HasKey     bool       `json:"has_key" example:"true"`
+	Current    bool       `json:"current"`
+}
+
+// RegisterDeviceKeyRequest — открытый ключ Ed25519 устройства, 32 байта в base64
+type RegisterDeviceKeyRequest struct {
+	PublicKey string `json:"public_key" example:"11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="`
+}
+
+// DeviceChallengeRequest начинает вход по ключу устройства
+type DeviceChallengeRequest struct {
+	Phone    string `json:"phone" example:"+992931753756"`
+	DeviceID string `json:"device_id" example:"3f1c2a9e-8b7d-4e21-9a55-0c1d2e3f4a5b"`
+}
+
+// DeviceChallengeResponse — строка, которую устройство подписывает своим ключом
+type DeviceChallengeResponse struct {
+	Challenge string `json:"challenge" example:"b7c1d2e3f4a5..."`
+	ExpiresIn int    `json:"expires_in" example:"120"`
+}
+
+// DeviceLoginRequest завершает вход по ключу: signature — подпись Ed25519 строки
+// "walletx-login:" + challenge в base64
+type DeviceLoginRequest struct {
+	Phone     string `json:"phone" example:"+992931753756"`
+	Challenge string `json:"challenge" example:"b7c1d2e3f4a5..."`
+	Signature string `json:"signature" example:"MEUCIQD..."`
+	DeviceInfo
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Статусы проверки личности (KYC)
+const (
+	KYCStatusNotStarted = "not_started"
+	KYCStatusPending    = "pending"
+	KYCStatusApproved   = "approved"
+	KYCStatusRejected   = "rejected"
+)
+
+// Уровни верификации пользователя. Уровень повышается только после одобрения заявки сотрудником.
+const (
+	TierBasic    = 0
+	TierVerified = 1
+)
+
+// Виды документов заявки
+const (
+	KYCDocPassportFront = "passport_front"
+	KYCDocPassportBack  = "passport_back"
+	KYCDocSelfie        = "selfie"
+)
+
+// KYCDocument — загруженный файл заявки. Сам файл лежит в файловом хранилище под StorageKey.
+type KYCDocument struct {
+	ID            int       `json:"id" example:"12"`
+	ApplicationID int       `json:"-"`
+	Kind          string    `json:"kind" example:"passport_front" enums:"passport_front,passport_back,selfie"`
+	StorageKey    string    `json:"-"`
+	ContentType   string    `json:"content_type" example:"image/jpeg"`
+	Size          int64     `json:"size" example:"482133"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// KYCApplication — заявка на проверку личности
+type KYCApplication struct {
+	ID             int           `json:"id" example:"5"`
+	UserID         int           `json:"user_id" example:"7"`
+	Phone          string        `json:"phone,omitempty" example:"+992931753756"`
+	Status         string        `json:"status" example:"pending" enums:"pending,approved,rejected"`
+	FirstName      string        `json:"first_name" example:"Ali"`
+	LastName       string        `json:"last_name" example:"Valiev"`
+	MiddleName     string        `json:"middle_name" example:"Bobo"`
+	PassportNumber string        `json:"passport_number" example:"A1234567"`
+	RejectReason   *string       `json:"reject_reason,omitempty" example:"passport photo is blurred"`
+	ReviewerID     *int          `json:"reviewer_id,omitempty" example:"3"`
+	SubmittedAt    time.Time     `json:"submitted_at"`
+	ReviewedAt     *time.Time    `json:"reviewed_at,omitempty"`
+	Documents      []KYCDocument `json:"documents,omitempty"`
+}
+
+// KYCUpload — файл из multipart-запроса до сохранения в хранилище
+type KYCUpload struct {
+	Kind    string
+	Content []byte
+}
+
+// KYCSubmission — данные заявки, которые отправляет пользователь
+type KYCSubmission struct {
+	FirstName      string
+	LastName       string
+	MiddleName     string
+	PassportNumber string
+	Files          []KYCUpload
+}
+
+// KYCStatusResponse — статус проверки для пользователя
+type KYCStatusResponse struct {
+	Status        string     `json:"status" example:"rejected" enums:"not_started,pending,approved,rejected"`
+	Tier          int        `json:"tier" example:"0"`
+	ApplicationID int        `json:"application_id,omitempty" example:"5"`
+	RejectReason  *string    `json:"reject_reason,omitempty" example:"passport photo is blurred"`
+	SubmittedAt   *time.Time `json:"submitted_at,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type KYCRejectRequest struct {
+	Reason string `json:"reason" example:"passport photo is blurred"`
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Области доступа партнерских приложений
+const (
+	ScopeBalanceRead   = "balance:read"
+	ScopeHistoryRead   = "history:read"
+	ScopePaymentsWrite = "payments:write"
+	ScopeServicesRead  = "services:read"
+)
+
+// Типы грантов OAuth 2.0
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+// OAuthScope описывает область доступа для экрана согласия. UserData — область дает доступ
+// к данным пользователя и выдается только с его согласия, не по client_credentials.
+type OAuthScope struct {
+	Name        string `json:"name" example:"balance:read"`
+	Description string `json:"description" example:"View your wallet balance"`
+	UserData    bool   `json:"-"`
+}
+
+var oauthScopes = map[string]OAuthScope{
+	ScopeBalanceRead:   {Name: ScopeBalanceRead, Description: "View your wallet balance", UserData: true},
+	ScopeHistoryRead:   {Name: ScopeHistoryRead, Description: "View your transaction history", UserData: true},
+	ScopePaymentsWrite: {Name: ScopePaymentsWrite, Description: "Pay for services from your wallet", UserData: true},
+	ScopeServicesRead:  {Name: ScopeServicesRead, Description: "View the catalog of payable services"},
+}
+
+// LookupScope возвращает описание области доступа
+func LookupScope(name string) (OAuthScope, bool) {
+	scope, ok := oauthScopes[name]
+	return scope, ok
+}
+
+// ParseScopes разбирает строку областей через пробел (RFC 6749, раздел 3.3), убирая повторы
+func ParseScopes(raw string) []string {
+	seen := make(map[string]bool)
+	scopes := make([]string, 0)
+	for _, s := range strings.Fields(raw) {
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// HasScope проверяет, входит ли scope в строку областей токена
+func HasScope(granted, scope string) bool {
+	for _, s := range strings.Fields(granted) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthClient — зарегистрированное партнерское приложение. Клиент без секрета — публичный
+// (мобильное или браузерное приложение), для него обязателен PKCE.
+type OAuthClient struct {
+	ID           int       `json:"-"`
+	ClientID     string    `json:"client_id" example:"3f1c9a0b7d2e4c58"`
+	SecretHash   string    `json:"-"`
+	Name         string    `json:"name" example:"Budget Planner"`
+	RedirectURIs []string  `json:"redirect_uris" example:"https://planner.example.com/callback"`
+	Scopes       []string  `json:"scopes" example:"balance:read,history:read"`
+	GrantTypes   []string  `json:"grant_types" example:"authorization_code"`
+	Confidential bool      `json:"confidential" example:"true"`
+	IsActive     bool      `json:"is_active" example:"true"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" example:"Budget Planner"`
+	RedirectURIs []string `json:"redirect_uris" example:"https://planner.example.com/callback"`
+	Scopes       []string `json:"scopes" example:"balance:read,history:read"`
+	GrantTypes   []string `json:"grant_types" example:"authorization_code"`
+	Confidential bool     `json:"confidential" example:"true"`
+}
+
+// CreateOAuthClientResponse — секрет показывается один раз, хранится только его хеш
+type CreateOAuthClientResponse struct {
+	Client       OAuthClient `json:"client"`
+	ClientSecret string      `json:"client_secret,omitempty" example:"6b1d0c3f9e8a4b7c2d5e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c"`
+}
+
+// OAuthAuthorizeRequest — параметры запроса авторизации (RFC 6749, раздел 4.1.1; RFC 7636)
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `json:"response_type" example:"code"`
+	ClientID            string `json:"client_id" example:"3f1c9a0b7d2e4c58"`
+	RedirectURI         string `json:"redirect_uri" example:"https://planner.example.com/callback"`
+	Scope               string `json:"scope" example:"balance:read history:read"`
+	State               string `json:"state" example:"af0ifjsldkj"`
+	CodeChallenge       string `json:"code_challenge" example:"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"`
+	CodeChallengeMethod string `json:"code_challenge_method" example:"S256"`
+	Approve             bool   `json:"approve" example:"true"`
+}
+
+// OAuthConsentScreen — данные для экрана согласия
+type OAuthConsentScreen struct {
+	ClientID       string       `json:"client_id" example:"3f1c9a0b7d2e4c58"`
+	ClientName     string       `json:"client_name" example:"Budget Planner"`
+	Scopes         []OAuthScope `json:"scopes"`
+	AlreadyGranted bool         `json:"already_granted" example:"false"`
+}
+
+type OAuthAuthorizeResponse struct {
+	RedirectTo string `json:"redirect_to" example:"https://planner.example.com/callback?code=Zx7...&state=af0ifjsldkj"`
+}
+
+// OAuthAuthorizationCode — то, что сервер помнит о выданном коде авторизации
+type OAuthAuthorizationCode struct {
+	UserID        int    `json:"user_id"`
+	ClientID      string `json:"client_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	CodeChallenge string `json:"code_challenge"`
+}
+
+// OAuthTokenRequest — параметры POST /api/oauth/token (application/x-www-form-urlencoded)
+type OAuthTokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	ClientID     string
+	ClientSecret string
+}
+
+// OAuthTokenResponse — ответ точки выдачи токенов (RFC 6749, раздел 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+	RefreshToken string `json:"refresh_token,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Scope        string `json:"scope" example:"balance:read history:read"`
+}
+
+// OAuthErrorResponse — ошибка протокола OAuth (RFC 6749, раздел 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error" example:"invalid_grant"`
+	ErrorDescription string `json:"error_description" example:"invalid authorization code"`
+}
+
+// OAuthConsent — выданное пользователем согласие
+type OAuthConsent struct {
+	ClientID   string    `json:"client_id" example:"3f1c9a0b7d2e4c58"`
+	ClientName string    `json:"client_name" example:"Budget Planner"`
+	Scopes     []string  `json:"scopes" example:"balance:read,history:read"`
+	GrantedAt  time.Time `json:"granted_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
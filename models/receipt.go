@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Receipt struct {
+	ReceiptNumber      string    `json:"receipt_number" example:"WX-20251102-00000042"`
+	TransactionID      int       `json:"transaction_id" example:"42"`
+	Type               string    `json:"type" example:"transfer"`
+	Status             string    `json:"status" example:"completed"`
+	PayerUserID        int       `json:"-"`
+	PayerAccountID     int       `json:"payer_account_id" example:"3"`
+	PayerPhone         string    `json:"payer_phone" example:"+992931062345"`
+	PayeeUserID        *int      `json:"-"`
+	PayeeAccountID     int       `json:"payee_account_id" example:"5"`
+	PayeePhone         *string   `json:"payee_phone,omitempty" example:"+992931753756"`
+	ServiceName        *string   `json:"service_name,omitempty" example:"internet"`
+	ServiceDescription *string   `json:"service_description,omitempty" example:"internet services"`
+	SubscriberAccount  *string   `json:"subscriber_account,omitempty" example:"100200300"`
+	Amount             float64   `json:"amount" example:"100"`
+	Fee                float64   `json:"fee" example:"0"`
+	Total              float64   `json:"total" example:"100"`
+	CreatedAt          time.Time `json:"created_at"`
+	IssuedAt           time.Time `json:"issued_at"`
+}
@@ -10,9 +10,12 @@ type SignUpRequest struct {
 }
 
 // RegisterResponse возвращается после успешной регистрации
+// RegistrationToken нужен для установки пароля и действует ExpiresIn секунд.
 type RegisterResponse struct {
-	Message string `json:"message" example:"user registered"`
-	UserID  int    `json:"user_id" example:"6"`
+	Message           string `json:"message" example:"user registered"`
+	UserID            int    `json:"user_id" example:"6"`
+	RegistrationToken string `json:"registration_token" example:"1b4f0e9c2d7a..."`
+	ExpiresIn         int    `json:"expires_in" example:"900"`
 }
 
 // MessageResponse возвращается после отправки кода
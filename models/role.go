@@ -0,0 +1,53 @@
+package models
+
+// Роли пользователей
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+	RoleAuditor = "auditor"
+)
+
+// Разрешения административного API
+const (
+	PermUsersRead        = "users:read"
+	PermUsersUnblock     = "users:unblock"
+	PermAccountsFreeze   = "accounts:freeze"
+	PermTransactionsRead = "transactions:read"
+	PermRolesManage      = "roles:manage"
+	PermClientsManage    = "clients:manage"
+	PermKYCReview        = "kyc:review"
+)
+
+// rolePermissions — что разрешено каждой роли. Обычному пользователю административный API недоступен,
+// аудитор только читает.
+var rolePermissions = map[string][]string{
+	RoleUser:    {},
+	RoleSupport: {PermUsersRead, PermUsersUnblock, PermAccountsFreeze, PermTransactionsRead, PermKYCReview},
+	RoleAuditor: {PermUsersRead, PermTransactionsRead},
+	RoleAdmin:   {PermUsersRead, PermUsersUnblock, PermAccountsFreeze, PermTransactionsRead, PermRolesManage, PermClientsManage, PermKYCReview},
+}
+
+// ValidRole сообщает, существует ли роль
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// NormalizeRole возвращает роль по умолчанию для пустого значения (токены, выпущенные до появления ролей)
+func NormalizeRole(role string) string {
+	if role == "" {
+		return RoleUser
+	}
+	return role
+}
+
+// HasPermission проверяет, есть ли у роли разрешение
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[NormalizeRole(role)] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
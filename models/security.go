@@ -0,0 +1,39 @@
+package models
+
+// Операции, для которых может потребоваться дополнительное подтверждение
+const (
+	StepUpActionTransfer = "transfer"
+	StepUpActionPayment  = "payment"
+	StepUpActionSecurity = "security"
+)
+
+// Способы подтверждения
+const (
+	StepUpMethodPIN = "pin"
+	StepUpMethodSMS = "sms"
+)
+
+// StepUpRequest начинает подтверждение: с PIN токен выдается сразу, с SMS отправляется код.
+// Operation — отпечаток операции из ответа 403, обязателен для transfer и payment.
+type StepUpRequest struct {
+	Action    string `json:"action" example:"transfer" enums:"transfer,payment,security"`
+	Operation string `json:"operation,omitempty" example:"5d41402abc4b2a76b9719d911017c5925d41402abc4b2a76b9719d911017c592"`
+	Method    string `json:"method" example:"pin" enums:"pin,sms"`
+	PIN       string `json:"pin,omitempty" example:"4821"`
+}
+
+type StepUpConfirmRequest struct {
+	Action    string `json:"action" example:"transfer" enums:"transfer,payment,security"`
+	Operation string `json:"operation,omitempty" example:"5d41402abc4b2a76b9719d911017c5925d41402abc4b2a76b9719d911017c592"`
+	Code      string `json:"code" example:"123456"`
+}
+
+// StepUpTokenResponse — одноразовый токен подтверждения, передается в заголовке X-Step-Up-Token
+type StepUpTokenResponse struct {
+	StepUpToken string `json:"step_up_token" example:"c1d2e3f4..."`
+	ExpiresIn   int    `json:"expires_in" example:"300"`
+}
+
+type SetPINRequest struct {
+	PIN string `json:"pin" example:"4821"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+// Statement — шапка выписки по счету за период
+type Statement struct {
+	AccountID      int       `json:"account_id"`
+	Phone          string    `json:"phone"`
+	Currency       string    `json:"currency"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance float64   `json:"opening_balance"`
+	ClosingBalance float64   `json:"closing_balance"`
+	TotalIn        float64   `json:"total_in"`
+	TotalOut       float64   `json:"total_out"`
+	CountIn        int       `json:"count_in"`
+	CountOut       int       `json:"count_out"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// StatementEntry — одна операция выписки
+type StatementEntry struct {
+	TransactionID     int       `json:"transaction_id"`
+	Direction         string    `json:"direction"`
+	Type              string    `json:"type"`
+	Amount            float64   `json:"amount"`
+	CounterpartyPhone *string   `json:"counterparty_phone,omitempty"`
+	BalanceAfter      float64   `json:"balance_after"`
+	CreatedAt         time.Time `json:"created_at"`
+}
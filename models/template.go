@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	TemplateTypeTransfer = "transfer"
+	TemplateTypePayment  = "payment"
+)
+
+type PaymentTemplate struct {
+	ID          int       `json:"id" example:"1"`
+	UserID      int       `json:"-"`
+	Name        string    `json:"name" example:"Mom"`
+	Type        string    `json:"type" example:"transfer"`
+	ServiceType string    `json:"service_type,omitempty" example:"internet"`
+	Target      string    `json:"target" example:"+992931753756"`
+	Amount      float64   `json:"amount" example:"100"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateTemplateRequest struct {
+	Name        string  `json:"name" example:"Mom"`
+	Type        string  `json:"type" example:"transfer"`
+	ServiceType string  `json:"service_type,omitempty" example:"internet"`
+	Target      string  `json:"target" example:"+992931753756"`
+	Amount      float64 `json:"amount" example:"100"`
+}
+
+// ExecuteTemplateRequest позволяет переопределить сумму шаблона при выполнении
+type ExecuteTemplateRequest struct {
+	Amount float64 `json:"amount,omitempty" example:"150"`
+}
+
+type RecentRecipient struct {
+	Phone          string    `json:"phone" example:"+992931753756"`
+	LastAmount     float64   `json:"last_amount" example:"100"`
+	TransfersCount int       `json:"transfers_count" example:"3"`
+	LastTransferAt time.Time `json:"last_transfer_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// TokenPair выдается при входе и обновлении сессии. token — короткоживущий access-токен.
+type TokenPair struct {
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+	Scope        string `json:"scope,omitempty" example:"balance:read history:read"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// RefreshSession — запись о refresh-токене. Все токены одной цепочки ротации имеют общий FamilyID
+// и привязаны к устройству, на котором был выполнен вход. Сессии партнерских приложений (OAuth)
+// хранят также client_id и выданные области доступа.
+type RefreshSession struct {
+	UserID   int       `json:"user_id"`
+	DeviceID int       `json:"device_id"`
+	FamilyID string    `json:"family_id"`
+	ClientID string    `json:"client_id,omitempty"`
+	Scope    string    `json:"scope,omitempty"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty" example:"RSA"`
+	KeyID     string `json:"kid" example:"2025-11"`
+	Use       string `json:"use" example:"sig"`
+	Algorithm string `json:"alg" example:"RS256"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty" example:"AQAB"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
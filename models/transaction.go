@@ -15,9 +15,34 @@ type TransferRequest struct {
 	Amount  float64 `json:"amount" example:"100.34"`
 }
 type TransactionHistory struct {
-	AccountTo int       `json:"account_to" example:"3"`
-	ToPhone   *string   `json:"to_phone,omitempty" example:"+992931753756"`
-	Amount    float64   `json:"amount" example:"100"`
-	Type      string    `json:"type" example:"transfer"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                int       `json:"id" example:"42"`
+	Direction         string    `json:"direction" example:"out"`
+	AccountFrom       int       `json:"account_from" example:"2"`
+	AccountTo         int       `json:"account_to" example:"3"`
+	ToPhone           *string   `json:"to_phone,omitempty" example:"+992931753756"`
+	CounterpartyPhone *string   `json:"counterparty_phone,omitempty" example:"+992931753756"`
+	Amount            float64   `json:"amount" example:"100"`
+	Type              string    `json:"type" example:"transfer"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TransactionHistoryPage — страница истории; next_cursor пуст, если записей больше нет
+type TransactionHistoryPage struct {
+	Items      []TransactionHistory `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty" example:"MTc2MjA4MDQwMDAwMDAwMDAwMDo0Mg"`
+}
+
+// HistoryFilter — параметры выборки истории. CursorTime/CursorID задают позицию,
+// после которой (в порядке убывания даты) начинается страница.
+type HistoryFilter struct {
+	Start        time.Time
+	End          time.Time
+	Direction    string
+	Types        []string
+	MinAmount    *float64
+	MaxAmount    *float64
+	Counterparty string
+	CursorTime   *time.Time
+	CursorID     int
+	Limit        int
 }
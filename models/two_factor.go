@@ -0,0 +1,45 @@
+package models
+
+// TwoFactorState — настройки TOTP пользователя. Secret задан и при незавершенном подключении (Enabled == false).
+type TwoFactorState struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// TwoFactorSetupResponse — секрет для приложения-аутентификатора; provisioning_uri показывается QR-кодом
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/WalletX:%2B992931753756?algorithm=SHA1&digits=6&issuer=WalletX&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" example:"492039"`
+}
+
+// RecoveryCodesResponse — коды восстановления показываются один раз, каждый действует однократно
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"K7QF-2MXD,9TZB-H4RW"`
+}
+
+// TwoFactorDisableRequest — отключение требует текущий пароль и код из приложения или код восстановления
+type TwoFactorDisableRequest struct {
+	Password     string `json:"password" example:"12345678"`
+	Code         string `json:"code,omitempty" example:"492039"`
+	RecoveryCode string `json:"recovery_code,omitempty" example:"K7QF-2MXD"`
+}
+
+// TwoFactorChallenge возвращается при входе вместо токенов, если у пользователя включена 2FA
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required" example:"true"`
+	MFAToken          string `json:"mfa_token" example:"7b1e0c9d..."`
+	ExpiresIn         int    `json:"expires_in" example:"300"`
+}
+
+// TwoFactorLoginRequest завершает вход: mfa_token из первого шага и код из приложения или код восстановления
+type TwoFactorLoginRequest struct {
+	MFAToken     string `json:"mfa_token" example:"7b1e0c9d..."`
+	Code         string `json:"code,omitempty" example:"492039"`
+	RecoveryCode string `json:"recovery_code,omitempty" example:"K7QF-2MXD"`
+	DeviceInfo
+}
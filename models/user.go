@@ -1,40 +1,76 @@
 package models
 
+import "time"
+
 type User struct {
-	ID               int     `json:"id"`
-	Phone            string  `json:"phone"`
-	Password         string  `json:"-"`
-	FirstName        *string `json:"first_name,omitempty"`
-	LastName         *string `json:"last_name,omitempty"`
-	MiddleName       *string `json:"middle_name,omitempty"`
-	PassportNumber   *string `json:"passport_number,omitempty"`
-	IsVerified       bool    `json:"is_verified"`
-	DeviceID         bool    `json:"device_id,omitempty"`
-	PasswordAttempts int     `json:"password_attempts" db:"password_attempts"`
+	ID                int        `json:"id"`
+	Phone             string     `json:"phone"`
+	Password          string     `json:"-"`
+	Role              string     `json:"role"`
+	IsVerified        bool       `json:"is_verified"`
+	IsBlocked         bool       `json:"-"`
+	PasswordAttempts  int        `json:"password_attempts" db:"password_attempts"`
+	LockedUntil       *time.Time `json:"-"`
+	LockCount         int        `json:"-"`
+	PasswordChangedAt *time.Time `json:"-"`
 }
+
+// SetPasswordRequest завершает регистрацию: registration_token выдается после подтверждения SMS-кода
 type SetPasswordRequest struct {
-	UserID   int    `json:"user_id"`
-	Password string `json:"password"`
+	RegistrationToken string `json:"registration_token" example:"1b4f0e9c2d7a..."`
+	Password          string `json:"password" example:"12345678"`
+	DeviceInfo
 }
 type LoginRequest struct {
 	Phone    string `json:"phone" example:"+992931753756"`
 	Password string `json:"password" example:"12345678"`
+	DeviceInfo
+}
+
+// UnlockRequest запрашивает SMS-код для снятия временной блокировки
+type UnlockRequest struct {
+	Phone string `json:"phone" example:"+992931753756"`
+}
+
+type UnlockConfirmRequest struct {
+	Phone string `json:"phone" example:"+992931753756"`
+	Code  string `json:"code" example:"123456"`
+}
+
+type ForgotPasswordRequest struct {
+	Phone string `json:"phone" example:"+992931753756"`
 }
-type VerifyIdentityRequest struct {
-	UserID         int    `json:"user_id"`
-	FirstName      string `json:"first_name"`
-	LastName       string `json:"last_name"`
-	MiddleName     string `json:"middle_name"`
-	PassportNumber string `json:"passport_number"`
+
+type VerifyResetCodeRequest struct {
+	Phone string `json:"phone" example:"+992931753756"`
+	Code  string `json:"code" example:"123456"`
+}
+
+// PasswordResetTokenResponse — одноразовый токен, по которому можно один раз задать новый пароль
+type PasswordResetTokenResponse struct {
+	ResetToken string `json:"reset_token" example:"4f9c1e0a7b3d..."`
+	ExpiresIn  int    `json:"expires_in" example:"600"`
+}
+
+type ResetPasswordRequest struct {
+	ResetToken string `json:"reset_token" example:"4f9c1e0a7b3d..."`
+	Password   string `json:"password" example:"87654321"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" example:"12345678"`
+	NewPassword string `json:"new_password" example:"87654321"`
 }
 
 type UserProfileResponse struct {
 	ID         int    `json:"id" example:"1"`
 	Phone      string `json:"phone" example:"+992931753756"`
-	FirstName  string `json:"first_name" example: "Ali"`
-	LastName   string `json:"last_name" example: "Bob"`
-	MiddleName string `json:"middle_name" example: "Bob"`
+	FirstName  string `json:"first_name" example:"Ali"`
+	LastName   string `json:"last_name" example:"Bob"`
+	MiddleName string `json:"middle_name" example:"Bob"`
 	IsVerified bool   `json:"is_verified" example:"true"`
+	// VerificationTier повышается только после одобрения заявки KYC
+	VerificationTier int `json:"verification_tier" example:"1"`
 }
 
 type UserBalanceResponse struct {
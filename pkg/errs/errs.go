@@ -1,11 +1,15 @@
 package errs
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrInvalidPhone        = errors.New("invalid phone number format")
 	ErrUserExists          = errors.New("user already exists")
-	ErrWeakPassword        = errors.New("password must be exactly 8 characters long")
+	ErrWeakPassword        = errors.New("password does not meet the password policy")
 	ErrUnauthorized        = errors.New("unauthorized")
 	ErrUserNotFound        = errors.New("user not found")
 	ErrRequiredFields      = errors.New("all required fields must be filled")
@@ -18,4 +22,94 @@ var (
 	ErrInvalidAmount       = errors.New("invalid amount")
 	ErrInsufficientBalance = errors.New("insufficient balance or account not found")
 	ErrAccountNotFound     = errors.New("account not found")
+	ErrTemplateNotFound    = errors.New("template not found")
+	ErrInvalidTemplate     = errors.New("invalid template")
+	ErrReceiptNotFound     = errors.New("receipt not found")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+	ErrDeviceNotFound      = errors.New("device not found")
+	ErrAccountLocked       = errors.New("account is temporarily locked")
+	ErrInvalidCode         = errors.New("invalid code")
+	ErrCodeExpired         = errors.New("code expired or not found")
+	ErrTooManyAttempts     = errors.New("too many attempts")
+	ErrCodeRecentlySent    = errors.New("code was sent recently, try again later")
+	ErrInvalidOneTimeToken = errors.New("invalid or expired token")
+	ErrSamePassword        = errors.New("new password must differ from the current one")
+	ErrPasswordReused      = errors.New("password was used recently, choose another one")
+	ErrPasswordExpired     = errors.New("password has expired, reset it to continue")
+	ErrStepUpRequired      = errors.New("additional confirmation required")
+	ErrPINNotSet           = errors.New("transaction PIN is not set")
+	ErrInvalidPIN          = errors.New("invalid transaction PIN")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNoSetup    = errors.New("two-factor setup has not been started")
+	ErrInvalidDeviceKey    = errors.New("invalid device public key")
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrForbidden           = errors.New("forbidden")
+	ErrAccountFrozen       = errors.New("account is frozen")
+	ErrClientNotFound      = errors.New("oauth client not found")
+	ErrConsentNotFound     = errors.New("consent not found")
+	ErrKYCNotFound         = errors.New("kyc application not found")
+	ErrKYCPending          = errors.New("kyc application is already under review")
+	ErrKYCNotPending       = errors.New("kyc application has already been reviewed")
+	ErrAlreadyVerified     = errors.New("user is already verified")
+	ErrPassportInUse       = errors.New("passport is already used by another user")
+	ErrInvalidDocument     = errors.New("invalid document")
+	ErrStatementTooLarge   = errors.New("too many transactions for this statement format, narrow the period or use csv")
+)
+
+// StepUpRequiredError сообщает, почему операции нужно подтверждение и какими способами его можно дать.
+// Operation — отпечаток операции, под который выдается токен подтверждения (пусто для настроек
+// безопасности). errors.Is(err, ErrStepUpRequired) == true.
+type StepUpRequiredError struct {
+	Reason    string
+	Methods   []string
+	Operation string
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrStepUpRequired, e.Reason)
+}
+
+func (e *StepUpRequiredError) Unwrap() error {
+	return ErrStepUpRequired
+}
+
+// LockedError сообщает, до какого момента учетная запись заблокирована. errors.Is(err, ErrAccountLocked) == true.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s until %s", ErrAccountLocked, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// Коды ошибок OAuth 2.0 (RFC 6749, раздел 5.2)
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthAccessDenied            = "access_denied"
 )
+
+// OAuthError — ошибка протокола OAuth, отдается клиенту в виде {"error", "error_description"}
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
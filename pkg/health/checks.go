@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_.+\.up\.sql$`)
+
+// LatestMigration возвращает номер последней миграции в каталоге dir (формат golang-migrate)
+func LatestMigration(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, e := range entries {
+		m := migrationFileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", dir)
+	}
+	return latest, nil
+}
+
+// Postgres проверяет соединение и версию схемы в schema_migrations. expected == 0 — версия
+// не сравнивается, но «грязная» (недокатанная) миграция все равно считается ошибкой.
+func Postgres(db *sql.DB, expected int64) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return nil, err
+		}
+
+		var version int64
+		var dirty bool
+		err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("no migrations applied")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read migration version: %w", err)
+		}
+
+		stats := db.Stats()
+		details := map[string]any{
+			"migration_version": version,
+			"dirty":             dirty,
+			"open_connections":  stats.OpenConnections,
+			"in_use":            stats.InUse,
+		}
+		if expected > 0 {
+			details["expected_version"] = expected
+		}
+
+		switch {
+		case dirty:
+			return details, fmt.Errorf("migration %d is dirty", version)
+		case expected > 0 && version < expected:
+			return details, fmt.Errorf("database schema version %d is behind %d", version, expected)
+		}
+		return details, nil
+	}
+}
+
+// Redis проверяет Redis командой PING
+func Redis(client *redis.Client) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		if err := client.Ping(ctx).Err(); err != nil {
+			return nil, err
+		}
+		stats := client.PoolStats()
+		return map[string]any{
+			"total_connections": stats.TotalConns,
+			"idle_connections":  stats.IdleConns,
+		}, nil
+	}
+}
@@ -0,0 +1,137 @@
+// Package health — проверки живости и готовности сервиса для /healthz и /readyz.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout — таймаут проверки, для которой он не задан
+const DefaultTimeout = 2 * time.Second
+
+// CheckFunc проверяет одну зависимость и возвращает подробности для ответа /readyz
+type CheckFunc func(ctx context.Context) (map[string]any, error)
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+// CheckResult — итог одной проверки
+type CheckResult struct {
+	Status     string         `json:"status" example:"ok"`
+	DurationMs int64          `json:"duration_ms" example:"3"`
+	Error      string         `json:"error,omitempty" example:"context deadline exceeded"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// Report — ответ /readyz: общий статус и результат каждой проверки
+type Report struct {
+	Status       string                 `json:"status" example:"ok"`
+	ShuttingDown bool                   `json:"shutting_down,omitempty"`
+	Checks       map[string]CheckResult `json:"checks"`
+}
+
+// Checker выполняет проверки готовности. После SetShuttingDown сервис сообщает, что не готов,
+// чтобы балансировщик перестал присылать запросы до остановки сервера.
+type Checker struct {
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add добавляет проверку; timeout <= 0 означает DefaultTimeout
+func (c *Checker) Add(name string, timeout time.Duration, fn CheckFunc) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c.checks = append(c.checks, check{name: name, timeout: timeout, fn: fn})
+}
+
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Run выполняет все проверки параллельно, каждую со своим таймаутом
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:       StatusOK,
+		ShuttingDown: c.ShuttingDown(),
+		Checks:       make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			result := run(ctx, ch)
+
+			mu.Lock()
+			report.Checks[ch.name] = result
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+
+	if report.ShuttingDown {
+		report.Status = StatusFail
+	}
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, ch check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, ch.timeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]any
+		err     error
+	}
+	// проверка, не уважающая ctx (например, зависший драйвер), не должна задерживать ответ
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := ch.fn(ctx)
+		done <- outcome{details, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		Details:    out.details,
+	}
+	if out.err != nil {
+		result.Status = StatusFail
+		result.Error = out.err.Error()
+	}
+	return result
+}
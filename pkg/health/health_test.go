@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckTimeoutDoesNotBlockOthers(t *testing.T) {
+	c := NewChecker()
+	c.Add("slow", 20*time.Millisecond, func(ctx context.Context) (map[string]any, error) {
+		time.Sleep(time.Second) // не смотрит на ctx
+		return nil, nil
+	})
+	c.Add("fast", time.Second, func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"ok": true}, nil
+	})
+
+	start := time.Now()
+	report := c.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Run took %v, slow check was not cut off", elapsed)
+	}
+
+	if report.Status != StatusFail {
+		t.Errorf("status = %s, want fail", report.Status)
+	}
+	if slow := report.Checks["slow"]; slow.Status != StatusFail || slow.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("slow = %+v", slow)
+	}
+	if fast := report.Checks["fast"]; fast.Status != StatusOK || fast.Details["ok"] != true {
+		t.Errorf("fast = %+v", fast)
+	}
+}
+
+func TestShuttingDownFailsReadiness(t *testing.T) {
+	c := NewChecker()
+	if report := c.Run(context.Background()); report.Status != StatusOK {
+		t.Fatalf("status = %s, want ok", report.Status)
+	}
+
+	c.SetShuttingDown()
+	if report := c.Run(context.Background()); report.Status != StatusFail || !report.ShuttingDown {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestLatestMigration(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"000001_init.up.sql", "000001_init.down.sql", "000013_pii.up.sql", "000014_next.down.sql", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	version, err := LatestMigration(dir)
+	if err != nil || version != 13 {
+		t.Fatalf("LatestMigration = %d, %v; want 13", version, err)
+	}
+
+	if _, err := LatestMigration(t.TempDir()); err == nil {
+		t.Error("empty directory accepted")
+	}
+}
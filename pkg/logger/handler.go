@@ -0,0 +1,228 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// levelFileHandler пишет запись в JSON в файл ее уровня: info.log, warn.log, error.log, debug.log
+type levelFileHandler struct {
+	handlers map[slog.Level]slog.Handler
+}
+
+func newLevelFileHandler(writers map[slog.Level]io.Writer) *levelFileHandler {
+	opts := &slog.HandlerOptions{
+		Level:     slog.LevelDebug,
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// вместо полного пути и имени функции — файл:строка, как раньше с log.Lshortfile
+			if a.Key == slog.SourceKey && len(groups) == 0 {
+				if src, ok := a.Value.Any().(*slog.Source); ok {
+					return slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", filepath.Base(src.File), src.Line))
+				}
+			}
+			return a
+		},
+	}
+
+	h := &levelFileHandler{handlers: make(map[slog.Level]slog.Handler, len(writers))}
+	for level, w := range writers {
+		h.handlers[level] = slog.NewJSONHandler(w, opts)
+	}
+	return h
+}
+
+func (h *levelFileHandler) target(level slog.Level) slog.Handler {
+	switch {
+	case level >= slog.LevelError:
+		return h.handlers[slog.LevelError]
+	case level >= slog.LevelWarn:
+		return h.handlers[slog.LevelWarn]
+	case level >= slog.LevelInfo:
+		return h.handlers[slog.LevelInfo]
+	default:
+		return h.handlers[slog.LevelDebug]
+	}
+}
+
+func (h *levelFileHandler) Enabled(ctx context.Context, level slog.Level) bool { return true }
+
+func (h *levelFileHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.target(r.Level).Handle(ctx, r)
+}
+
+func (h *levelFileHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *levelFileHandler) WithGroup(name string) slog.Handler {
+	return h.derive(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *levelFileHandler) derive(fn func(slog.Handler) slog.Handler) slog.Handler {
+	derived := &levelFileHandler{handlers: make(map[slog.Level]slog.Handler, len(h.handlers))}
+	for level, next := range h.handlers {
+		derived.handlers[level] = fn(next)
+	}
+	return derived
+}
+
+// redactHandler маскирует сообщение и строковые атрибуты до того, как они попадут в JSON
+type redactHandler struct {
+	next slog.Handler
+	r    *Redactor
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.r.Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// correlationKeys — идентификаторы запроса и трассировки. Это 32-символьные hex-строки, которые
+// иначе попали бы под маскирование длинных hex-токенов, а без них записи не связать между собой.
+var correlationKeys = map[string]bool{
+	"request_id": true,
+	"trace_id":   true,
+	"span_id":    true,
+}
+
+func (h *redactHandler) attr(a slog.Attr) slog.Attr {
+	if h.r.SensitiveKey(a.Key) {
+		return slog.String(a.Key, mask)
+	}
+	if correlationKeys[a.Key] && a.Value.Kind() == slog.KindString {
+		return a
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.r.Redact(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]any, 0, len(group))
+		for _, g := range group {
+			attrs = append(attrs, h.attr(g))
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny, slog.KindLogValuer:
+		return slog.String(a.Key, h.r.Redact(fmt.Sprint(a.Value.Resolve().Any())))
+	}
+	return a
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		redacted = append(redacted, h.attr(a))
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), r: h.r}
+}
+
+// packageLevels — минимальный уровень по умолчанию и уровни отдельных пакетов
+type packageLevels struct {
+	def      slog.Level
+	min      slog.Level
+	packages map[string]slog.Level
+}
+
+func parseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+func newPackageLevels(def string, packages map[string]string) (packageLevels, error) {
+	level, err := parseLevel(def)
+	if err != nil {
+		return packageLevels{}, err
+	}
+
+	pl := packageLevels{def: level, min: level, packages: make(map[string]slog.Level, len(packages))}
+	for pkg, name := range packages {
+		level, err := parseLevel(name)
+		if err != nil {
+			return packageLevels{}, fmt.Errorf("package %s: %w", pkg, err)
+		}
+		pl.packages[strings.Trim(pkg, "/")] = level
+		if level < pl.min {
+			pl.min = level
+		}
+	}
+	return pl, nil
+}
+
+// levelFor возвращает уровень пакета. Пакет задается полным путем импорта или его окончанием
+// ("internal/repository"); при нескольких совпадениях выигрывает самое длинное.
+func (pl packageLevels) levelFor(pkg string) slog.Level {
+	level, matched := pl.def, ""
+	for name, l := range pl.packages {
+		if (pkg == name || strings.HasSuffix(pkg, "/"+name)) && len(name) > len(matched) {
+			level, matched = l, name
+		}
+	}
+	return level
+}
+
+// packageOf возвращает путь пакета функции, записавшей сообщение
+func packageOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	// FuncForPC вернул бы для встроенного вызова функцию slog, а не вызывающую
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	// WalletX/internal/service.(*KYCService).Submit -> WalletX/internal/service
+	name := frame.Function
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
+
+// packageLevelHandler отбрасывает записи ниже уровня пакета, из которого они записаны
+type packageLevelHandler struct {
+	next   slog.Handler
+	levels packageLevels
+}
+
+func (h *packageLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.min
+}
+
+func (h *packageLevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if len(h.levels.packages) > 0 || r.Level < h.levels.def {
+		if r.Level < h.levels.levelFor(packageOf(r.PC)) {
+			return nil
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *packageLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &packageLevelHandler{next: h.next.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *packageLevelHandler) WithGroup(name string) slog.Handler {
+	return &packageLevelHandler{next: h.next.WithGroup(name), levels: h.levels}
+}
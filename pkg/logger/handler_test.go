@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"WalletX/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestPackageLevels(t *testing.T) {
+	levels, err := newPackageLevels("info", map[string]string{
+		"internal/repository": "warn",
+		"pkg/logger":          "debug",
+		"WalletX/pkg/logger":  "error",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]slog.Level{
+		"WalletX/internal/repository": slog.LevelWarn,
+		"WalletX/internal/service":    slog.LevelInfo,
+		"WalletX/pkg/logger":          slog.LevelError, // полный путь длиннее окончания
+		"WalletX/pkg/xlogger":         slog.LevelInfo,
+	}
+	for pkg, want := range cases {
+		if got := levels.levelFor(pkg); got != want {
+			t.Errorf("levelFor(%s) = %v, want %v", pkg, got, want)
+		}
+	}
+
+	if _, err := newPackageLevels("verbose", nil); err == nil {
+		t.Error("invalid level accepted")
+	}
+}
+
+func TestPackageLevelHandlerFiltersByCaller(t *testing.T) {
+	var buf bytes.Buffer
+	levels, err := newPackageLevels("info", map[string]string{"pkg/logger": "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(&packageLevelHandler{next: slog.NewJSONHandler(&buf, nil), levels: levels})
+
+	l.Info("dropped")
+	l.Warn("kept")
+
+	if strings.Contains(buf.String(), "dropped") || !strings.Contains(buf.String(), "kept") {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRedactor(models.Redaction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := slog.New(&redactHandler{next: slog.NewJSONHandler(&buf, nil), r: r})
+
+	ctx := WithContext(context.Background(), base.With("request_id", "req-1"))
+	ctx = With(ctx, "user_id", 7)
+	FromContext(ctx).Info("transfer", "otp_code", "123456")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["request_id"] != "req-1" || entry["user_id"] != float64(7) {
+		t.Errorf("request attributes missing: %v", entry)
+	}
+	if entry["otp_code"] != mask {
+		t.Errorf("otp_code = %v, want %q", entry["otp_code"], mask)
+	}
+
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("FromContext without logger must return slog.Default()")
+	}
+}
+
+func TestRedactKeepsCorrelationIDs(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRedactor(models.Redaction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(&redactHandler{next: slog.NewJSONHandler(&buf, nil), r: r})
+
+	const (
+		requestID = "0af7651916cd43dd8448eb211c80319c"
+		traceID   = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID    = "00f067aa0ba902b7"
+		secret    = "9f86d081884c7d659a2feaa0c55ad015"
+	)
+	l.With("request_id", requestID).Info("request", "trace_id", traceID, "span_id", spanID, "nonce", secret)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["request_id"] != requestID || entry["trace_id"] != traceID || entry["span_id"] != spanID {
+		t.Errorf("correlation ids redacted: %v", entry)
+	}
+	if entry["nonce"] != mask {
+		t.Errorf("nonce = %v, want %q", entry["nonce"], mask)
+	}
+}
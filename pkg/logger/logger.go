@@ -5,19 +5,25 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// Info, Error, Warn и Debug оставлены для существующего кода: каждая строка, записанная через них,
+// становится записью slog соответствующего уровня. В коде с контекстом запроса используйте FromContext.
+// До Init они пишут обычным текстом в stdout.
 var (
-	Info  *log.Logger
-	Error *log.Logger
-	Warn  *log.Logger
-	Debug *log.Logger
+	Info  = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Error = log.New(os.Stdout, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Warn  = log.New(os.Stdout, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Debug = log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
 )
 
+// Init настраивает JSON-логирование через slog: записи пишутся в stdout и в файл своего уровня
+// с ротацией lumberjack, проходят маскирование и фильтруются по уровню пакета.
 func Init() error {
 	logParams := config.AppSettings.LogParams
 
@@ -50,11 +56,35 @@ func Init() error {
 			LocalTime:  logParams.LocalTime,
 		}
 	}
+	output := func(filename string) io.Writer {
+		return io.MultiWriter(os.Stdout, newLumberjack(filename))
+	}
+
+	levels, err := newPackageLevels(logParams.Level, logParams.PackageLevels)
+	if err != nil {
+		return err
+	}
+
+	var handler slog.Handler = newLevelFileHandler(map[slog.Level]io.Writer{
+		slog.LevelDebug: output(logParams.LogDebug),
+		slog.LevelInfo:  output(logParams.LogInfo),
+		slog.LevelWarn:  output(logParams.LogWarn),
+		slog.LevelError: output(logParams.LogError),
+	})
+	if !logParams.Redaction.Disabled {
+		redactor, err := NewRedactor(logParams.Redaction)
+		if err != nil {
+			return err
+		}
+		handler = &redactHandler{next: handler, r: redactor}
+	}
+	handler = &packageLevelHandler{next: handler, levels: levels}
 
-	Info = log.New(io.MultiWriter(os.Stdout, newLumberjack(logParams.LogInfo)), "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	Error = log.New(io.MultiWriter(os.Stdout, newLumberjack(logParams.LogError)), "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	Warn = log.New(io.MultiWriter(os.Stdout, newLumberjack(logParams.LogWarn)), "WARN: ", log.Ldate|log.Ltime|log.Lshortfile)
-	Debug = log.New(io.MultiWriter(os.Stdout, newLumberjack(logParams.LogDebug)), "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+	slog.SetDefault(slog.New(handler))
+	Info = slog.NewLogLogger(handler, slog.LevelInfo)
+	Error = slog.NewLogLogger(handler, slog.LevelError)
+	Warn = slog.NewLogLogger(handler, slog.LevelWarn)
+	Debug = slog.NewLogLogger(handler, slog.LevelDebug)
 
 	return nil
 }
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"WalletX/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const mask = "***"
+
+// defaultRedactFields — имена полей, значения которых не должны попадать в лог. Совпадение ищется
+// без учета регистра по началу имени или его части после "_": "token" закрывает token, tokenHash
+// и refresh_token.
+var defaultRedactFields = []string{
+	"password", "passwd", "pin", "code", "otp", "token", "secret", "passport",
+	"authorization", "cookie", "signature", "recovery", "mfa", "first_name", "last_name", "middle_name",
+}
+
+type rule struct {
+	re      *regexp.Regexp
+	replace func(string) string
+}
+
+// Redactor маскирует в строках лога секреты и персональные данные: значения полей по имени
+// (key=value, key: value, "key":"value") и значения, узнаваемые по виду — JWT, Bearer-токены,
+// длинные hex-токены и номера телефонов.
+type Redactor struct {
+	rules []rule
+	keyRe *regexp.Regexp
+}
+
+var (
+	bearerRe = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`)
+	jwtRe    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	hexRe    = regexp.MustCompile(`\b[0-9a-fA-F]{32,}\b`)
+	phoneRe  = regexp.MustCompile(`\+\d{7,15}\b|\b992\d{9}\b`)
+	// одноразовые коды в текстах SMS: "confirmation code 12345", "your unlock code is 12345"
+	smsCodeRe = regexp.MustCompile(`(?i)(\bcode(?:\s+is)?\s+)\d{4,8}\b`)
+)
+
+// NewRedactor собирает правила из настроек. Поля и шаблоны из настроек добавляются к встроенным.
+func NewRedactor(cfg models.Redaction) (*Redactor, error) {
+	fields := append(append([]string{}, defaultRedactFields...), cfg.Fields...)
+	quoted := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			quoted = append(quoted, regexp.QuoteMeta(f))
+		}
+	}
+	names := `(?:[a-z0-9]*_)?(?:` + strings.Join(quoted, "|") + `)\w*`
+	// 1 — имя поля с разделителем, 2 — значение в кавычках или до пробела/разделителя
+	fieldRe, err := regexp.Compile(`(?i)(\b` + names + `"?\s*[:=]\s*)("[^"]*"|[^\s,;&}\])]+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Redactor{keyRe: regexp.MustCompile(`(?i)^` + names + `$`)}
+	r.rules = append(r.rules,
+		rule{re: bearerRe, replace: func(string) string { return "Bearer " + mask }},
+		rule{re: jwtRe, replace: func(string) string { return mask }},
+		rule{re: smsCodeRe, replace: func(m string) string { return smsCodeRe.FindStringSubmatch(m)[1] + mask }},
+		rule{re: fieldRe, replace: func(m string) string {
+			sub := fieldRe.FindStringSubmatch(m)
+			if strings.HasPrefix(sub[2], `"`) {
+				return sub[1] + `"` + mask + `"`
+			}
+			return sub[1] + mask
+		}},
+		rule{re: hexRe, replace: func(string) string { return mask }},
+	)
+	if !cfg.KeepPhones {
+		r.rules = append(r.rules, rule{re: phoneRe, replace: maskPhone})
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.rules = append(r.rules, rule{re: re, replace: func(string) string { return mask }})
+	}
+	return r, nil
+}
+
+// maskPhone оставляет код страны и две последние цифры: +992931753756 -> +992******56
+func maskPhone(phone string) string {
+	digits := strings.TrimPrefix(phone, "+")
+	keep := 3
+	if len(digits) <= keep+2 {
+		return mask
+	}
+	return phone[:len(phone)-len(digits)] + digits[:keep] + strings.Repeat("*", len(digits)-keep-2) + digits[len(digits)-2:]
+}
+
+// Redact возвращает строку с замаскированными значениями
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllStringFunc(s, rule.replace)
+	}
+	return s
+}
+
+// SensitiveKey сообщает, что значение атрибута с таким именем нужно скрыть целиком
+func (r *Redactor) SensitiveKey(key string) bool {
+	return r.keyRe.MatchString(key)
+}
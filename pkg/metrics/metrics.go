@@ -0,0 +1,119 @@
+// Package metrics — метрики Prometheus сервиса, отдаются на /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "walletx"
+
+// Registry — собственный реестр вместо глобального, чтобы на /metrics попадало только то, что регистрирует сервис
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	MoneyOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "money_operations_total",
+		Help:      "Transfers and payments by operation, transaction type and outcome.",
+	}, []string{"operation", "type", "outcome"})
+
+	MoneyAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "money_operations_amount_total",
+		Help:      "Sum of requested amounts of transfers and payments by operation, transaction type and outcome.",
+	}, []string{"operation", "type", "outcome"})
+
+	LoginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Failed login attempts by reason.",
+	}, []string{"reason"})
+
+	LoginLockouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_lockouts_total",
+		Help:      "Accounts temporarily locked after too many failed logins.",
+	})
+
+	SMSCodesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sms_codes_sent_total",
+		Help:      "One-time codes sent by SMS by purpose.",
+	}, []string{"purpose"})
+)
+
+// Исходы денежных операций для метки outcome
+const (
+	OutcomeSuccess           = "success"
+	OutcomeInsufficientFunds = "insufficient_funds"
+	OutcomeFrozen            = "account_frozen"
+	OutcomeStepUpRequired    = "step_up_required"
+	OutcomeRejected          = "rejected"
+	OutcomeFailed            = "failed"
+)
+
+// Причины неудачного входа для метки reason
+const (
+	LoginWrongPassword    = "wrong_password"
+	LoginUnknownUser      = "unknown_user"
+	LoginInvalidSignature = "invalid_device_signature"
+	LoginInvalidCode      = "invalid_2fa_code"
+	LoginBlocked          = "blocked"
+	LoginLocked           = "locked"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		MoneyOperations,
+		MoneyAmount,
+		LoginFailures,
+		LoginLockouts,
+		SMSCodesSent,
+	)
+}
+
+// ObserveMoneyOperation учитывает перевод или платеж и его сумму
+func ObserveMoneyOperation(operation, kind, outcome string, amount float64) {
+	MoneyOperations.WithLabelValues(operation, kind, outcome).Inc()
+	if amount > 0 {
+		MoneyAmount.WithLabelValues(operation, kind, outcome).Add(amount)
+	}
+}
+
+// RegisterDB добавляет статистику пула соединений sql.DB (go_sql_* с меткой db_name)
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedis добавляет статистику пула соединений Redis
+func RegisterRedis(client *redis.Client) error {
+	return Registry.Register(newRedisPoolCollector(client))
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
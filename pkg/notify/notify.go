@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"WalletX/pkg/logger"
+	"context"
+)
+
+// Sender доставляет пользователю уведомление (SMS/push) на номер телефона.
+// purpose — назначение сообщения (unlock, password_reset, new_device и т.д.) для логов и метрик шлюза.
+type Sender interface {
+	Send(ctx context.Context, phone, purpose, message string) error
+}
+
+// LogSender пишет уведомления в лог. Используется, пока не подключен SMS-шлюз.
+// Текст сообщения в лог не попадает: в нем бывают одноразовые коды.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, phone, purpose, message string) error {
+	logger.Info.Printf("[Notify] SMS to %s: purpose=%s", phone, purpose)
+	return nil
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"WalletX/pkg/logger"
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogSenderKeepsMessageOutOfLog(t *testing.T) {
+	var buf bytes.Buffer
+	saved := logger.Info
+	logger.Info = log.New(&buf, "", 0)
+	defer func() { logger.Info = saved }()
+
+	message := "WalletX: your password reset code is 482913. Do not share it with anyone."
+	if err := NewLogSender().Send(context.Background(), "+992930000007", "password_reset", message); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "482913") || strings.Contains(out, "reset code") {
+		t.Fatalf("message body was logged: %q", out)
+	}
+	if !strings.Contains(out, "password_reset") {
+		t.Fatalf("purpose was not logged: %q", out)
+	}
+}
@@ -0,0 +1,159 @@
+package passwordpolicy
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	defaultMinLength = 8
+	// bcrypt учитывает только первые 72 байта пароля
+	defaultMaxLength = 72
+	defaultPinLength = 6
+)
+
+//go:embed common.txt
+var commonList string
+
+var (
+	commonOnce sync.Once
+	common     map[string]struct{}
+)
+
+func loadCommon() {
+	common = make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonList))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		common[strings.ToLower(line)] = struct{}{}
+	}
+}
+
+// IsCommon сообщает, входит ли пароль во встроенный список распространенных и утекших паролей
+func IsCommon(password string) bool {
+	commonOnce.Do(loadCommon)
+	_, ok := common[strings.ToLower(password)]
+	return ok
+}
+
+// Policy проверяет пароль или PIN по правилам из конфигурации
+type Policy struct {
+	cfg models.PasswordPolicy
+}
+
+// New применяет значения по умолчанию к незаданным параметрам
+func New(cfg models.PasswordPolicy) Policy {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultMinLength
+	}
+	if cfg.MaxLength <= 0 || cfg.MaxLength > defaultMaxLength {
+		cfg.MaxLength = defaultMaxLength
+	}
+	if cfg.PinLength <= 0 {
+		cfg.PinLength = defaultPinLength
+	}
+	return Policy{cfg: cfg}
+}
+
+func (p Policy) HistorySize() int {
+	if p.cfg.HistorySize < 0 {
+		return 0
+	}
+	return p.cfg.HistorySize
+}
+
+// ExpiryDays — срок действия пароля в днях, 0 — без ограничения
+func (p Policy) ExpiryDays() int {
+	if p.cfg.ExpiryDays < 0 {
+		return 0
+	}
+	return p.cfg.ExpiryDays
+}
+
+func weak(reason string) error {
+	return fmt.Errorf("%w: %s", errs.ErrWeakPassword, reason)
+}
+
+// Validate возвращает ErrWeakPassword с причиной, если пароль не подходит
+func (p Policy) Validate(password string) error {
+	if p.cfg.PinMode {
+		return p.validatePIN(password)
+	}
+
+	length := len([]rune(password))
+	if length < p.cfg.MinLength {
+		return weak(fmt.Sprintf("must be at least %d characters long", p.cfg.MinLength))
+	}
+	if length > p.cfg.MaxLength || len(password) > defaultMaxLength {
+		return weak(fmt.Sprintf("must be at most %d characters long", p.cfg.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	switch {
+	case p.cfg.RequireUpper && !hasUpper:
+		return weak("must contain an uppercase letter")
+	case p.cfg.RequireLower && !hasLower:
+		return weak("must contain a lowercase letter")
+	case p.cfg.RequireDigit && !hasDigit:
+		return weak("must contain a digit")
+	case p.cfg.RequireSpecial && !hasSpecial:
+		return weak("must contain a special character")
+	}
+
+	if p.cfg.RejectCommon && IsCommon(password) {
+		return weak("is too common")
+	}
+	return nil
+}
+
+func (p Policy) validatePIN(pin string) error {
+	if len(pin) != p.cfg.PinLength {
+		return weak(fmt.Sprintf("PIN must be exactly %d digits", p.cfg.PinLength))
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return weak("PIN must contain only digits")
+		}
+	}
+
+	if isTrivialPIN(pin) {
+		return weak("PIN is too simple")
+	}
+	if p.cfg.RejectCommon && IsCommon(pin) {
+		return weak("PIN is too common")
+	}
+	return nil
+}
+
+// isTrivialPIN отсекает PIN из одной цифры (111111) и последовательности (123456, 987654)
+func isTrivialPIN(pin string) bool {
+	same, up, down := true, true, true
+	for i := 1; i < len(pin); i++ {
+		diff := int(pin[i]) - int(pin[i-1])
+		same = same && diff == 0
+		up = up && (diff == 1 || diff == -9)
+		down = down && (diff == -1 || diff == 9)
+	}
+	return same || up || down
+}
@@ -0,0 +1,54 @@
+package passwordpolicy
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	strict := New(models.PasswordPolicy{
+		MinLength:      10,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+		RejectCommon:   true,
+	})
+	pin := New(models.PasswordPolicy{PinMode: true, PinLength: 6, RejectCommon: true})
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		ok       bool
+	}{
+		{"strict ok", strict, "Dushanbe#2025", true},
+		{"too short", strict, "Ab#1", false},
+		{"no upper", strict, "dushanbe#2025", false},
+		{"no digit", strict, "Dushanbe#Tj", false},
+		{"no special", strict, "Dushanbe2025", false},
+		{"common", New(models.PasswordPolicy{RejectCommon: true}), "Password123", false},
+		{"too long for bcrypt", New(models.PasswordPolicy{MaxLength: 200}), string(make([]byte, 80)), false},
+		{"pin ok", pin, "480915", true},
+		{"pin wrong length", pin, "48091", false},
+		{"pin not digits", pin, "48a915", false},
+		{"pin same digits", pin, "222222", false},
+		{"pin ascending", pin, "345678", false},
+		{"pin descending", pin, "876543", false},
+		{"pin common", pin, "112233", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+			if tt.ok && err != nil {
+				t.Fatalf("Validate(%q) = %v, want nil", tt.password, err)
+			}
+			if !tt.ok && !errors.Is(err, errs.ErrWeakPassword) {
+				t.Fatalf("Validate(%q) = %v, want ErrWeakPassword", tt.password, err)
+			}
+		})
+	}
+}
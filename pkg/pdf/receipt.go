@@ -0,0 +1,62 @@
+package pdf
+
+import (
+	"WalletX/models"
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// Receipt рисует чек по операции в формате A5 и пишет PDF в w
+func Receipt(w io.Writer, serverName string, rc models.Receipt) error {
+	doc := fpdf.New("P", "mm", "A5", "")
+	tr := doc.UnicodeTranslatorFromDescriptor("")
+	doc.SetTitle(tr("Receipt "+rc.ReceiptNumber), false)
+	doc.AddPage()
+
+	doc.SetFont("Helvetica", "B", 16)
+	doc.CellFormat(0, 10, tr(serverName), "", 1, "C", false, 0, "")
+	doc.SetFont("Helvetica", "", 11)
+	doc.CellFormat(0, 7, tr("Receipt No. "+rc.ReceiptNumber), "", 1, "C", false, 0, "")
+	doc.Ln(4)
+
+	rows := [][2]string{
+		{"Operation", rc.Type},
+		{"Status", rc.Status},
+		{"Transaction ID", fmt.Sprintf("%d", rc.TransactionID)},
+		{"Payer", rc.PayerPhone},
+		{"Payer account", fmt.Sprintf("%d", rc.PayerAccountID)},
+	}
+	if rc.PayeePhone != nil {
+		rows = append(rows, [2]string{"Payee", *rc.PayeePhone})
+		rows = append(rows, [2]string{"Payee account", fmt.Sprintf("%d", rc.PayeeAccountID)})
+	}
+	if rc.ServiceName != nil {
+		rows = append(rows, [2]string{"Service", *rc.ServiceName})
+	}
+	if rc.ServiceDescription != nil && *rc.ServiceDescription != "" {
+		rows = append(rows, [2]string{"Service details", *rc.ServiceDescription})
+	}
+	if rc.SubscriberAccount != nil && *rc.SubscriberAccount != "" {
+		rows = append(rows, [2]string{"Subscriber account", *rc.SubscriberAccount})
+	}
+	rows = append(rows,
+		[2]string{"Amount", fmt.Sprintf("%.2f", rc.Amount)},
+		[2]string{"Fee", fmt.Sprintf("%.2f", rc.Fee)},
+		[2]string{"Total", fmt.Sprintf("%.2f", rc.Total)},
+		[2]string{"Performed at", rc.CreatedAt.Format(dateTimeLayout)},
+		[2]string{"Issued at", rc.IssuedAt.Format(dateTimeLayout)},
+	)
+
+	for _, row := range rows {
+		doc.SetFont("Helvetica", "B", 10)
+		doc.CellFormat(45, 7, tr(row[0]), "B", 0, "L", false, 0, "")
+		doc.SetFont("Helvetica", "", 10)
+		doc.CellFormat(0, 7, tr(row[1]), "B", 1, "R", false, 0, "")
+	}
+
+	return doc.Output(w)
+}
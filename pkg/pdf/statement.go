@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/errs"
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+var statementColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Date", 36, "L"},
+	{"ID", 16, "R"},
+	{"Type", 28, "L"},
+	{"Counterparty", 38, "L"},
+	{"Amount", 28, "R"},
+	{"Balance", 28, "R"},
+}
+
+// MaxStatementEntries — предел операций в PDF-выписке: документ целиком лежит в памяти до End
+const MaxStatementEntries = 5000
+
+// StatementWriter рисует выписку по счету. fpdf собирает документ в памяти,
+// поэтому PDF отдается целиком в End и не может быть больше MaxStatementEntries операций;
+// остальные форматы пишутся потоково.
+type StatementWriter struct {
+	w        io.Writer
+	bankName string
+	doc      *fpdf.Fpdf
+	tr       func(string) string
+	entries  int
+}
+
+func NewStatementWriter(w io.Writer, bankName string) *StatementWriter {
+	doc := fpdf.New("P", "mm", "A4", "")
+	return &StatementWriter{
+		w:        w,
+		bankName: bankName,
+		doc:      doc,
+		tr:       doc.UnicodeTranslatorFromDescriptor(""),
+	}
+}
+
+func (s *StatementWriter) header() {
+	s.doc.SetFont("Helvetica", "B", 9)
+	for _, col := range statementColumns {
+		s.doc.CellFormat(col.width, 7, col.title, "1", 0, "C", false, 0, "")
+	}
+	s.doc.Ln(-1)
+	s.doc.SetFont("Helvetica", "", 9)
+}
+
+func (s *StatementWriter) Begin(st models.Statement) error {
+	s.doc.SetTitle(s.tr(fmt.Sprintf("Statement for account %d", st.AccountID)), false)
+	s.doc.SetHeaderFunc(func() {
+		if s.doc.PageNo() > 1 {
+			s.header()
+		}
+	})
+	s.doc.AddPage()
+
+	s.doc.SetFont("Helvetica", "B", 16)
+	s.doc.CellFormat(0, 10, s.tr(s.bankName+" account statement"), "", 1, "C", false, 0, "")
+	s.doc.SetFont("Helvetica", "", 10)
+
+	lines := []string{
+		fmt.Sprintf("Account: %d (%s)", st.AccountID, st.Phone),
+		fmt.Sprintf("Period: %s - %s", st.From.Format(dateTimeLayout), st.To.Format(dateTimeLayout)),
+		fmt.Sprintf("Opening balance: %.2f %s", st.OpeningBalance, st.Currency),
+		fmt.Sprintf("Total in: %.2f (%d)   Total out: %.2f (%d)", st.TotalIn, st.CountIn, st.TotalOut, st.CountOut),
+		fmt.Sprintf("Closing balance: %.2f %s", st.ClosingBalance, st.Currency),
+		fmt.Sprintf("Generated at: %s", st.GeneratedAt.Format(dateTimeLayout)),
+	}
+	for _, line := range lines {
+		s.doc.CellFormat(0, 6, s.tr(line), "", 1, "L", false, 0, "")
+	}
+	s.doc.Ln(4)
+	s.header()
+
+	return s.doc.Error()
+}
+
+// MaxEntries сообщает предел операций, чтобы выписку можно было отклонить до начала выгрузки
+func (s *StatementWriter) MaxEntries() int { return MaxStatementEntries }
+
+func (s *StatementWriter) Entry(e models.StatementEntry) error {
+	// операции могли добавиться после подсчета итогов — предел проверяется и здесь
+	s.entries++
+	if s.entries > MaxStatementEntries {
+		return errs.ErrStatementTooLarge
+	}
+
+	amount := e.Amount
+	if e.Direction == models.DirectionOut {
+		amount = -amount
+	}
+	party := ""
+	if e.CounterpartyPhone != nil {
+		party = *e.CounterpartyPhone
+	}
+
+	values := []string{
+		e.CreatedAt.Format(dateTimeLayout),
+		fmt.Sprintf("%d", e.TransactionID),
+		e.Type,
+		party,
+		fmt.Sprintf("%+.2f", amount),
+		fmt.Sprintf("%.2f", e.BalanceAfter),
+	}
+	for i, col := range statementColumns {
+		s.doc.CellFormat(col.width, 6, s.tr(values[i]), "1", 0, col.align, false, 0, "")
+	}
+	s.doc.Ln(-1)
+
+	return s.doc.Error()
+}
+
+func (s *StatementWriter) End() error {
+	return s.doc.Output(s.w)
+}
@@ -0,0 +1,253 @@
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown pii key")
+	ErrMalformed  = errors.New("malformed pii ciphertext")
+)
+
+// prefix отличает зашифрованные значения от открытых, записанных до включения шифрования
+const prefix = "enc:v1:"
+
+const keySize = 32
+
+// keyringFile — формат файла ключей:
+//
+//	{"active": "2026-01", "keys": [{"id": "2026-01", "key": "<base64 32 байта>"}], "index_key": "<base64 32 байта>"}
+type keyringFile struct {
+	Active string `json:"active"`
+	Keys   []struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	} `json:"keys"`
+	IndexKey string `json:"index_key"`
+}
+
+// Cipher шифрует персональные данные конвертным способом: каждое значение шифруется своим
+// случайным ключом данных (AES-256-GCM), а ключ данных — ключом шифрования ключей из связки.
+// Новые значения шифруются активным ключом, расшифровать можно любым ключом связки.
+type Cipher struct {
+	active   string
+	keks     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// LoadKeyring читает связку ключей из файла
+func LoadKeyring(path string) (*Cipher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode keyring: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(file.Keys))
+	for _, k := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		if _, ok := keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		keys[k.ID] = key
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(file.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("index_key: %w", err)
+	}
+
+	return NewCipher(file.Active, keys, indexKey)
+}
+
+// NewCipher собирает связку из ключей по id. indexKey — отдельный ключ для слепого индекса.
+func NewCipher(active string, keys map[string][]byte, indexKey []byte) (*Cipher, error) {
+	c := &Cipher{active: active, keks: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %q must be %d bytes", id, keySize)
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		c.keks[id] = aead
+	}
+	if _, ok := c.keks[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", active)
+	}
+	if len(indexKey) != keySize {
+		return nil, fmt.Errorf("index key must be %d bytes", keySize)
+	}
+	c.indexKey = indexKey
+	return c, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
+
+// AAD — дополнительные данные шифрования, привязывающие шифртекст к владельцу и колонке
+// ("users.passport_number"). Значение, скопированное в строку другого пользователя или в другую
+// колонку, не расшифруется.
+func AAD(column string, userID int) []byte {
+	return []byte(column + ":" + strconv.Itoa(userID))
+}
+
+// Encrypt шифрует значение активным ключом. Результат имеет вид
+// enc:v1:<id ключа>:<обернутый ключ данных>:<шифртекст>.
+func (c *Cipher) Encrypt(plaintext string, aad []byte) (string, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	data, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(data, []byte(plaintext), aad)
+	if err != nil {
+		return "", err
+	}
+	return c.wrap(dek, ciphertext)
+}
+
+func (c *Cipher) wrap(dek, ciphertext []byte) (string, error) {
+	wrapped, err := seal(c.keks[c.active], dek, []byte(c.active))
+	if err != nil {
+		return "", err
+	}
+	return prefix + c.active + ":" + base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// unwrap разбирает зашифрованное значение и возвращает ключ данных и шифртекст
+func (c *Cipher) unwrap(value string) (dek, ciphertext []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return nil, nil, ErrMalformed
+	}
+	kek, ok := c.keks[parts[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, ErrMalformed
+	}
+	if ciphertext, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return nil, nil, ErrMalformed
+	}
+	if dek, err = open(kek, wrapped, []byte(parts[0])); err != nil {
+		return nil, nil, err
+	}
+	return dek, ciphertext, nil
+}
+
+// Decrypt расшифровывает значение. Значение без префикса считается открытым текстом,
+// записанным до включения шифрования, и возвращается как есть. aad должен совпадать с тем,
+// с которым значение зашифровано.
+func (c *Cipher) Decrypt(value string, aad []byte) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	dek, ciphertext, err := c.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, ciphertext, aad)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Stale сообщает, что значение нужно перешифровать: оно открытое или зашифровано не активным ключом
+func (c *Cipher) Stale(value string) bool {
+	return value != "" && !strings.HasPrefix(value, prefix+c.active+":")
+}
+
+// Rewrap переводит значение на активный ключ. Зашифрованное значение перешифровывается без
+// расшифровки данных — меняется только обертка ключа данных; открытое значение шифруется с aad.
+func (c *Cipher) Rewrap(value string, aad []byte) (string, error) {
+	if !c.Stale(value) {
+		return value, nil
+	}
+	if !IsEncrypted(value) {
+		return c.Encrypt(value, aad)
+	}
+	dek, ciphertext, err := c.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+	return c.wrap(dek, ciphertext)
+}
+
+// BlindIndex — HMAC-SHA256 нормализованного значения. Позволяет искать совпадения (например,
+// один паспорт у двух пользователей), не расшифровывая данные. Ключ индекса не ротируется:
+// при его смене индекс нужно пересчитать целиком.
+func (c *Cipher) BlindIndex(value string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, value)
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted сообщает, зашифровано ли значение
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
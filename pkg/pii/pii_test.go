@@ -0,0 +1,157 @@
+package pii
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testCipher(t *testing.T, active string, ids ...string) *Cipher {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, id := range ids {
+		keys[id] = bytes.Repeat([]byte(id[len(id)-1:]), keySize)
+	}
+	c, err := NewCipher(active, keys, bytes.Repeat([]byte{0xAA}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+var testAAD = AAD("users.passport_number", 7)
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := testCipher(t, "k1", "k1")
+
+	enc, err := c.Encrypt("A1234567", testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(enc, "A1234567") || !IsEncrypted(enc) {
+		t.Fatalf("value is not encrypted: %s", enc)
+	}
+	other, _ := c.Encrypt("A1234567", testAAD)
+	if enc == other {
+		t.Fatal("equal plaintexts produced equal ciphertexts")
+	}
+
+	got, err := c.Decrypt(enc, testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "A1234567" {
+		t.Fatalf("Decrypt = %q, want A1234567", got)
+	}
+}
+
+func TestDecryptRejectsTamperedValue(t *testing.T) {
+	c := testCipher(t, "k1", "k1")
+	enc, err := c.Encrypt("Valiev", testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := enc[:len(enc)-2] + "AA"
+	if tampered == enc {
+		tampered = enc[:len(enc)-2] + "BB"
+	}
+	if _, err := c.Decrypt(tampered, testAAD); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("Decrypt(tampered) error = %v, want ErrMalformed", err)
+	}
+}
+
+func TestDecryptRequiresSameOwnerAndColumn(t *testing.T) {
+	c := testCipher(t, "k1", "k1")
+	enc, err := c.Encrypt("A1234567", testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, aad := range [][]byte{AAD("users.passport_number", 8), AAD("users.first_name", 7), nil} {
+		if _, err := c.Decrypt(enc, aad); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decrypt with aad %q error = %v, want ErrMalformed", aad, err)
+		}
+	}
+
+	rotated := testCipher(t, "k2", "k1", "k2")
+	rewrapped, err := rotated.Rewrap(enc, testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.Decrypt(rewrapped, AAD("users.passport_number", 8)); !errors.Is(err, ErrMalformed) {
+		t.Errorf("rewrapped value lost its binding: %v", err)
+	}
+}
+
+func TestLegacyPlaintextIsStale(t *testing.T) {
+	c := testCipher(t, "k1", "k1")
+
+	got, err := c.Decrypt("Ali", testAAD)
+	if err != nil || got != "Ali" {
+		t.Fatalf("Decrypt(plaintext) = %q, %v", got, err)
+	}
+	if !c.Stale("Ali") {
+		t.Fatal("plaintext value is not reported as stale")
+	}
+	rewrapped, err := c.Rewrap("Ali", testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(rewrapped) || c.Stale(rewrapped) {
+		t.Fatalf("Rewrap(plaintext) = %q", rewrapped)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := testCipher(t, "k1", "k1")
+	enc, err := old.Encrypt("A1234567", testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := testCipher(t, "k2", "k1", "k2")
+	if !rotated.Stale(enc) {
+		t.Fatal("value of the previous key is not stale")
+	}
+	rewrapped, err := rotated.Rewrap(enc, testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rewrapped, prefix+"k2:") || rotated.Stale(rewrapped) {
+		t.Fatalf("Rewrap = %q, want active key k2", rewrapped)
+	}
+	if got, err := rotated.Decrypt(rewrapped, testAAD); err != nil || got != "A1234567" {
+		t.Fatalf("Decrypt(rewrapped) = %q, %v", got, err)
+	}
+
+	retired := testCipher(t, "k2", "k2")
+	if _, err := retired.Decrypt(enc, testAAD); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Decrypt with retired key error = %v, want ErrUnknownKey", err)
+	}
+	if got, err := retired.Decrypt(rewrapped, testAAD); err != nil || got != "A1234567" {
+		t.Fatalf("Decrypt(rewrapped) after retiring k1 = %q, %v", got, err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	c := testCipher(t, "k1", "k1")
+
+	if c.BlindIndex("a 1234567") != c.BlindIndex("A1234567") {
+		t.Fatal("blind index depends on case or spaces")
+	}
+	if c.BlindIndex("A1234567") == c.BlindIndex("A1234568") {
+		t.Fatal("different passports have equal blind index")
+	}
+	if strings.Contains(c.BlindIndex("A1234567"), "1234567") {
+		t.Fatal("blind index leaks the value")
+	}
+}
+
+func TestNewCipherRequiresActiveKey(t *testing.T) {
+	_, err := NewCipher("k2", map[string][]byte{"k1": bytes.Repeat([]byte{1}, keySize)}, bytes.Repeat([]byte{2}, keySize))
+	if err == nil {
+		t.Fatal("NewCipher accepted an active key missing from the keyring")
+	}
+}
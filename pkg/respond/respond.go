@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func JSON(w http.ResponseWriter, status int, data interface{}) {
@@ -14,11 +17,15 @@ func JSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// Error отвечает сообщением и подробностями ошибки; если err == nil, поле details не выводится
 func Error(w http.ResponseWriter, status int, message string, err error) {
-	JSON(w, status, map[string]interface{}{
-		"error":   message,
-		"details": err.Error(),
-	})
+	body := map[string]interface{}{
+		"error": message,
+	}
+	if err != nil {
+		body["details"] = err.Error()
+	}
+	JSON(w, status, body)
 }
 
 func HandleError(w http.ResponseWriter, err error) {
@@ -26,20 +33,101 @@ func HandleError(w http.ResponseWriter, err error) {
 		return
 	}
 
+	var locked *errs.LockedError
+	var stepUp *errs.StepUpRequiredError
+	var oauth *errs.OAuthError
+
 	switch {
+	case errors.As(err, &locked):
+		retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		JSON(w, http.StatusLocked, map[string]interface{}{
+			"error":        errs.ErrAccountLocked.Error(),
+			"locked_until": locked.Until.UTC().Format(time.RFC3339),
+		})
+
+	case errors.As(err, &stepUp):
+		body := map[string]interface{}{
+			"error":   errs.ErrStepUpRequired.Error(),
+			"reason":  stepUp.Reason,
+			"methods": stepUp.Methods,
+		}
+		if stepUp.Operation != "" {
+			body["operation"] = stepUp.Operation
+		}
+		JSON(w, http.StatusForbidden, body)
+
+	case errors.As(err, &oauth):
+		status := http.StatusBadRequest
+		if oauth.Code == errs.OAuthInvalidClient {
+			status = http.StatusUnauthorized
+		}
+		JSON(w, status, map[string]string{
+			"error":             oauth.Code,
+			"error_description": oauth.Description,
+		})
+
 	case errors.Is(err, errs.ErrInvalidPhone),
 		errors.Is(err, errs.ErrUserExists),
 		errors.Is(err, errs.ErrWeakPassword),
 		errors.Is(err, errs.ErrValidationFailed),
-		errors.Is(err, errs.ErrRequiredFields):
+		errors.Is(err, errs.ErrRequiredFields),
+		errors.Is(err, errs.ErrInvalidTemplate),
+		errors.Is(err, errs.ErrInvalidAmount),
+		errors.Is(err, errs.ErrSelfTransfer),
+		errors.Is(err, errs.ErrInsufficientFunds),
+		errors.Is(err, errs.ErrInsufficientBalance),
+		errors.Is(err, errs.ErrStatementTooLarge),
+		errors.Is(err, errs.ErrInvalidCode),
+		errors.Is(err, errs.ErrCodeExpired),
+		errors.Is(err, errs.ErrSamePassword),
+		errors.Is(err, errs.ErrPasswordReused),
+		errors.Is(err, errs.ErrPINNotSet),
+		errors.Is(err, errs.ErrInvalidPIN),
+		errors.Is(err, errs.ErrTwoFactorNotEnabled),
+		errors.Is(err, errs.ErrTwoFactorNoSetup),
+		errors.Is(err, errs.ErrInvalidDeviceKey),
+		errors.Is(err, errs.ErrInvalidDocument):
 		JSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 
-	case errors.Is(err, errs.ErrUserNotFound):
+	case errors.Is(err, errs.ErrUserNotFound),
+		errors.Is(err, errs.ErrAccountNotFound),
+		errors.Is(err, errs.ErrTemplateNotFound),
+		errors.Is(err, errs.ErrReceiptNotFound),
+		errors.Is(err, errs.ErrDeviceNotFound),
+		errors.Is(err, errs.ErrClientNotFound),
+		errors.Is(err, errs.ErrConsentNotFound),
+		errors.Is(err, errs.ErrKYCNotFound):
 		JSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 
-	case errors.Is(err, errs.ErrUnauthorized):
+	case errors.Is(err, errs.ErrUnauthorized),
+		errors.Is(err, errs.ErrInvalidRefreshToken),
+		errors.Is(err, errs.ErrTokenRevoked),
+		errors.Is(err, errs.ErrWrongPassword),
+		errors.Is(err, errs.ErrInvalidOneTimeToken),
+		errors.Is(err, errs.ErrInvalidSignature):
 		JSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
 
+	case errors.Is(err, errs.ErrUserBlocked),
+		errors.Is(err, errs.ErrPasswordExpired),
+		errors.Is(err, errs.ErrForbidden),
+		errors.Is(err, errs.ErrAccountFrozen):
+		JSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+
+	case errors.Is(err, errs.ErrTwoFactorEnabled),
+		errors.Is(err, errs.ErrKYCPending),
+		errors.Is(err, errs.ErrKYCNotPending),
+		errors.Is(err, errs.ErrAlreadyVerified),
+		errors.Is(err, errs.ErrPassportInUse):
+		JSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+
+	case errors.Is(err, errs.ErrTooManyAttempts),
+		errors.Is(err, errs.ErrCodeRecentlySent):
+		JSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+
 	default:
 		JSON(w, http.StatusInternalServerError, map[string]string{
 			"error": fmt.Sprintf("something went wrong: %s", err.Error()),
@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"WalletX/models"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053Writer пишет выписку ISO 20022 camt.053 (BankToCustomerStatement)
+type camt053Writer struct {
+	w        *bufio.Writer
+	bankName string
+	currency string
+}
+
+func newCamt053Writer(w io.Writer, bankName string) *camt053Writer {
+	return &camt053Writer{w: bufio.NewWriter(w), bankName: bankName}
+}
+
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func creditDebit(amount float64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func (c *camt053Writer) balance(code string, amount float64, date time.Time) {
+	fmt.Fprintf(c.w, `<Bal><Tp><CdOrPrtry><Cd>%s</Cd></CdOrPrtry></Tp><Amt Ccy="%s">%s</Amt><CdtDbtInd>%s</CdtDbtInd><Dt><Dt>%s</Dt></Dt></Bal>`,
+		code, xmlText(c.currency), money(math.Abs(amount)), creditDebit(amount), date.Format("2006-01-02"))
+}
+
+func (c *camt053Writer) Begin(st models.Statement) error {
+	c.currency = st.Currency
+	msgID := fmt.Sprintf("STMT-%d-%s", st.AccountID, st.GeneratedAt.Format("20060102150405"))
+
+	fmt.Fprint(c.w, xml.Header)
+	fmt.Fprintf(c.w, `<Document xmlns="%s"><BkToCstmrStmt>`, camt053Namespace)
+	fmt.Fprintf(c.w, `<GrpHdr><MsgId>%s</MsgId><CreDtTm>%s</CreDtTm></GrpHdr>`,
+		msgID, st.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(c.w, `<Stmt><Id>%s</Id><CreDtTm>%s</CreDtTm>`, msgID, st.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(c.w, `<FrToDt><FrDtTm>%s</FrDtTm><ToDtTm>%s</ToDtTm></FrToDt>`,
+		st.From.Format(time.RFC3339), st.To.Format(time.RFC3339))
+	fmt.Fprintf(c.w, `<Acct><Id><Othr><Id>%d</Id></Othr></Id><Ccy>%s</Ccy><Ownr><Id><PrvtId><Othr><Id>%s</Id></Othr></PrvtId></Id></Ownr><Svcr><FinInstnId><Nm>%s</Nm></FinInstnId></Svcr></Acct>`,
+		st.AccountID, xmlText(st.Currency), xmlText(st.Phone), xmlText(c.bankName))
+	c.balance("OPBD", st.OpeningBalance, st.From)
+	c.balance("CLBD", st.ClosingBalance, st.To)
+	_, err := fmt.Fprintf(c.w, `<TxsSummry><TtlCdtNtries><NbOfNtries>%d</NbOfNtries><Sum>%s</Sum></TtlCdtNtries><TtlDbtNtries><NbOfNtries>%d</NbOfNtries><Sum>%s</Sum></TtlDbtNtries></TxsSummry>`,
+		st.CountIn, money(st.TotalIn), st.CountOut, money(st.TotalOut))
+	return err
+}
+
+func (c *camt053Writer) Entry(e models.StatementEntry) error {
+	indicator := "CRDT"
+	partyTag := "Dbtr"
+	if e.Direction == models.DirectionOut {
+		indicator = "DBIT"
+		partyTag = "Cdtr"
+	}
+	booked := e.CreatedAt.Format(time.RFC3339)
+
+	fmt.Fprintf(c.w, `<Ntry><NtryRef>%d</NtryRef><Amt Ccy="%s">%s</Amt><CdtDbtInd>%s</CdtDbtInd><Sts>BOOK</Sts>`,
+		e.TransactionID, xmlText(c.currency), money(e.Amount), indicator)
+	fmt.Fprintf(c.w, `<BookgDt><DtTm>%s</DtTm></BookgDt><ValDt><DtTm>%s</DtTm></ValDt>`, booked, booked)
+	fmt.Fprintf(c.w, `<BkTxCd><Prtry><Cd>%s</Cd></Prtry></BkTxCd>`, xmlText(e.Type))
+	fmt.Fprintf(c.w, `<NtryDtls><TxDtls><Refs><TxId>%d</TxId></Refs>`, e.TransactionID)
+	if party := counterparty(e); party != "" {
+		fmt.Fprintf(c.w, `<RltdPties><%s><Nm>%s</Nm></%s></RltdPties>`, partyTag, xmlText(party), partyTag)
+	}
+	// ошибка записи в bufio.Writer «липкая», поэтому достаточно проверить последнюю запись
+	_, err := fmt.Fprintf(c.w, `<AddtlTxInf>%s</AddtlTxInf></TxDtls></NtryDtls></Ntry>`, xmlText(e.Type))
+	return err
+}
+
+func (c *camt053Writer) End() error {
+	fmt.Fprint(c.w, `</Stmt></BkToCstmrStmt></Document>`)
+	return c.w.Flush()
+}
@@ -0,0 +1,55 @@
+package statement
+
+import (
+	"WalletX/models"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (c *csvWriter) Begin(st models.Statement) error {
+	summary := [][]string{
+		{"account_id", strconv.Itoa(st.AccountID)},
+		{"phone", st.Phone},
+		{"currency", st.Currency},
+		{"period_from", st.From.Format(time.RFC3339)},
+		{"period_to", st.To.Format(time.RFC3339)},
+		{"opening_balance", money(st.OpeningBalance)},
+		{"total_in", money(st.TotalIn)},
+		{"total_out", money(st.TotalOut)},
+		{"closing_balance", money(st.ClosingBalance)},
+		{},
+		{"transaction_id", "created_at", "direction", "type", "counterparty", "amount", "balance_after"},
+	}
+	return c.w.WriteAll(summary)
+}
+
+func (c *csvWriter) Entry(e models.StatementEntry) error {
+	return c.w.Write([]string{
+		strconv.Itoa(e.TransactionID),
+		e.CreatedAt.Format(time.RFC3339),
+		e.Direction,
+		e.Type,
+		counterparty(e),
+		money(e.Amount),
+		money(e.BalanceAfter),
+	})
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,64 @@
+package statement
+
+import (
+	"WalletX/models"
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const ofxDateLayout = "20060102150405"
+
+// ofxWriter пишет выписку в формате OFX 2.2 (XML)
+type ofxWriter struct {
+	w        *bufio.Writer
+	bankName string
+	st       models.Statement
+}
+
+func newOFXWriter(w io.Writer, bankName string) *ofxWriter {
+	return &ofxWriter{w: bufio.NewWriter(w), bankName: bankName}
+}
+
+func (o *ofxWriter) Begin(st models.Statement) error {
+	o.st = st
+
+	fmt.Fprint(o.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`+"\n")
+	fmt.Fprint(o.w, `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n")
+	fmt.Fprint(o.w, "<OFX>")
+	fmt.Fprintf(o.w, `<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE><FI><ORG>%s</ORG></FI></SONRS></SIGNONMSGSRSV1>`,
+		st.GeneratedAt.Format(ofxDateLayout), xmlText(o.bankName))
+	fmt.Fprint(o.w, `<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>`)
+	fmt.Fprintf(o.w, `<STMTRS><CURDEF>%s</CURDEF><BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>`,
+		xmlText(st.Currency), xmlText(o.bankName), st.AccountID)
+	_, err := fmt.Fprintf(o.w, `<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>`,
+		st.From.Format(ofxDateLayout), st.To.Format(ofxDateLayout))
+	return err
+}
+
+func (o *ofxWriter) Entry(e models.StatementEntry) error {
+	trnType, amount := "CREDIT", e.Amount
+	if e.Direction == models.DirectionOut {
+		trnType, amount = "PAYMENT", -e.Amount
+	}
+	if e.Type == "transfer" {
+		trnType = "XFER"
+	}
+
+	name := counterparty(e)
+	if name == "" {
+		name = e.Type
+	}
+
+	_, err := fmt.Fprintf(o.w, `<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>`,
+		trnType, e.CreatedAt.Format(ofxDateLayout), money(amount), e.TransactionID, xmlText(name), xmlText(e.Type))
+	return err
+}
+
+func (o *ofxWriter) End() error {
+	fmt.Fprint(o.w, "</BANKTRANLIST>")
+	fmt.Fprintf(o.w, `<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>`,
+		money(o.st.ClosingBalance), o.st.To.Format(ofxDateLayout))
+	fmt.Fprint(o.w, "</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n")
+	return o.w.Flush()
+}
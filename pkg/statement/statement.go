@@ -0,0 +1,72 @@
+package statement
+
+import (
+	"WalletX/models"
+	"WalletX/pkg/pdf"
+	"errors"
+	"io"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatPDF     = "pdf"
+	FormatCamt053 = "camt053"
+	FormatOFX     = "ofx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported statement format")
+
+// Writer пишет выписку потоково: сначала шапка, затем операции по одной, затем окончание
+type Writer interface {
+	Begin(st models.Statement) error
+	Entry(e models.StatementEntry) error
+	End() error
+}
+
+// Limited реализуют форматы, которые собирают документ в памяти и потому ограничивают число операций
+type Limited interface {
+	MaxEntries() int
+}
+
+// Format описывает формат выгрузки для HTTP-ответа
+type Format struct {
+	ContentType string
+	Extension   string
+}
+
+var formats = map[string]Format{
+	FormatCSV:     {ContentType: "text/csv; charset=utf-8", Extension: "csv"},
+	FormatPDF:     {ContentType: "application/pdf", Extension: "pdf"},
+	FormatCamt053: {ContentType: "application/xml; charset=utf-8", Extension: "xml"},
+	FormatOFX:     {ContentType: "application/x-ofx", Extension: "ofx"},
+}
+
+func LookupFormat(name string) (Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return Format{}, ErrUnsupportedFormat
+	}
+	return f, nil
+}
+
+func NewWriter(format string, w io.Writer, bankName string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatPDF:
+		return pdf.NewStatementWriter(w, bankName), nil
+	case FormatCamt053:
+		return newCamt053Writer(w, bankName), nil
+	case FormatOFX:
+		return newOFXWriter(w, bankName), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func counterparty(e models.StatementEntry) string {
+	if e.CounterpartyPhone != nil {
+		return *e.CounterpartyPhone
+	}
+	return ""
+}
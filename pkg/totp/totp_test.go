@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Контрольные значения из приложения B RFC 6238 (SHA1), последние 6 цифр
+func TestCodeRFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	code, _ := Code(secret, now.Add(-Period*time.Second))
+	step, ok := Validate(secret, code, now, 1)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("previous step code rejected: ok=%t step=%d", ok, step)
+	}
+
+	if _, ok := Validate(secret, code, now, 0); ok {
+		t.Error("previous step code accepted without skew")
+	}
+
+	old, _ := Code(secret, now.Add(-3*Period*time.Second))
+	if _, ok := Validate(secret, old, now, 1); ok {
+		t.Error("code outside the skew window accepted")
+	}
+
+	if _, ok := Validate(secret, "", now, 1); ok {
+		t.Error("empty code accepted")
+	}
+	if _, ok := Validate("not base32!", "123456", now, 1); ok {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("WalletX", "+992931753756", "JBSWY3DPEHPK3PXP")
+	for _, part := range []string{"otpauth://totp/WalletX:+992931753756?", "secret=JBSWY3DPEHPK3PXP", "issuer=WalletX", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("%s does not contain %s", uri, part)
+		}
+	}
+}
@@ -5,13 +5,13 @@ import (
 )
 
 func GenerateCode() string {
-	const length = 5
+	const length = 6
 	const digits = "0123456789"
 
 	b := make([]byte, length)
 	_, err := rand.Read(b)
 	if err != nil {
-		return "00000"
+		return "000000"
 	}
 
 	code := ""
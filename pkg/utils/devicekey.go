@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidDeviceKey = errors.New("invalid Ed25519 public key")
+
+// DeviceLoginMessage — строка, которую устройство подписывает при входе по ключу
+func DeviceLoginMessage(challenge string) []byte {
+	return []byte("walletx-login:" + challenge)
+}
+
+// RequestSigningPayload — каноническое представление подписываемого запроса:
+// метод, путь с query-строкой, время, nonce и SHA-256 тела, каждое с новой строки
+func RequestSigningPayload(method, requestURI, timestamp, nonce string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return []byte(strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		timestamp,
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n"))
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// ParseDevicePublicKey разбирает открытый ключ Ed25519 (32 байта в base64 или base64url)
+func ParseDevicePublicKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := decodeBase64(encoded)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, ErrInvalidDeviceKey
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// VerifyDeviceSignature проверяет подпись message ключом устройства; подпись в base64 или base64url
+func VerifyDeviceSignature(publicKey string, message []byte, signature string) bool {
+	key, err := ParseDevicePublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	sig, err := decodeBase64(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(key, message, sig)
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPTrustsHeadersOnlyFromProxies(t *testing.T) {
+	if err := InitTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10"}); err != nil {
+		t.Fatal(err)
+	}
+	defer InitTrustedProxies(nil)
+
+	cases := []struct {
+		name, remote, forwarded, realIP, want string
+	}{
+		{"direct client spoofing headers", "203.0.113.7:5000", "198.51.100.1", "198.51.100.2", "203.0.113.7"},
+		{"proxy subnet", "10.1.2.3:443", "198.51.100.1", "", "198.51.100.1"},
+		{"single proxy address", "192.168.1.10:443", "", "198.51.100.2", "198.51.100.2"},
+		{"spoofed leftmost hop", "10.1.2.3:443", "1.2.3.4, 198.51.100.1, 10.9.9.9", "", "198.51.100.1"},
+		{"proxy without headers", "10.1.2.3:443", "", "", "10.1.2.3"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		if tc.realIP != "" {
+			r.Header.Set("X-Real-IP", tc.realIP)
+		}
+		if got := ClientIP(r); got != tc.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestInitTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	defer InitTrustedProxies(nil)
+	for _, p := range []string{"proxy.local", "10.0.0.0/33"} {
+		if err := InitTrustedProxies([]string{p}); err == nil {
+			t.Errorf("%q accepted", p)
+		}
+	}
+}
@@ -2,45 +2,106 @@ package utils
 
 import (
 	"WalletX/config"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+// CustomClaims — claims access-токена. IssuedAtMs дублирует iat с точностью до миллисекунд:
+// по нему токен сравнивается с отметками отзыва, которые тоже хранятся в миллисекундах.
 type CustomClaims struct {
-	UserID   int    ` json:"user_id"`
-	Username string `json:"username"`
+	UserID     int    ` json:"user_id"`
+	Role       string `json:"role,omitempty"`
+	DeviceID   int    `json:"device_id,omitempty"`
+	ClientID   string `json:"client_id,omitempty"`
+	Scope      string `json:"scope,omitempty"`
+	IssuedAtMs int64  `json:"iat_ms,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(userID int, username string) (string, error) {
-	auth := config.AppSettings.AuthParams
-
-	claims := CustomClaims{
-		UserID:   userID,
-		Username: username,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * time.Duration(auth.JwtTtlMinutes)).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
+// GenerateRandomToken возвращает криптостойкую случайную строку из n байт в hex
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+func AccessTokenTTL() time.Duration {
+	return time.Minute * time.Duration(config.AppSettings.AuthParams.JwtTtlMinutes)
+}
 
-	return token.SignedString([]byte(auth.JwtSecretKey))
+// GenerateToken выпускает access-токен с ролью пользователя, привязанный к устройству deviceID
+func GenerateToken(userID int, role string, deviceID int) (string, error) {
+	return signClaims(CustomClaims{UserID: userID, Role: role, DeviceID: deviceID})
 }
 
-func ParseToken(tokenString string) (*CustomClaims, error) {
+// GenerateClientToken выпускает access-токен партнерского приложения clientID с областями scope.
+// userID == 0 — токен самого приложения (client_credentials), без доступа к данным пользователей.
+func GenerateClientToken(userID int, clientID, scope string) (string, error) {
+	return signClaims(CustomClaims{UserID: userID, ClientID: clientID, Scope: scope})
+}
+
+func signClaims(claims CustomClaims) (string, error) {
 	auth := config.AppSettings.AuthParams
 
-	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        jti,
+		ExpiresAt: now.Add(AccessTokenTTL()).Unix(),
+		IssuedAt:  now.Unix(),
+	}
+	claims.IssuedAtMs = now.UnixMilli()
+
+	ring := ActiveKeyRing()
+	if ring == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(auth.JwtSecretKey))
+	}
+
+	key, err := ring.SigningKey(now)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// tokenKey выбирает ключ проверки: по kid из связки ключей или общий секрет в режиме HS256.
+// При настроенных асимметричных ключах токены HS256 не принимаются.
+func tokenKey(t *jwt.Token) (interface{}, error) {
+	ring := ActiveKeyRing()
+	if ring == nil {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(auth.JwtSecretKey), nil
-	})
+		return []byte(config.AppSettings.AuthParams.JwtSecretKey), nil
+	}
 
+	kid, _ := t.Header["kid"].(string)
+	key, err := ring.VerificationKey(kid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+func ParseToken(tokenString string) (*CustomClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, tokenKey)
 	if err != nil {
 		return nil, err
 	}
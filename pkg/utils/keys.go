@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"WalletX/models"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrUnknownKey      = errors.New("unknown signing key")
+	ErrNoActiveKey     = errors.New("no active signing key")
+	ErrUnsupportedAlgo = errors.New("unsupported signing algorithm")
+	ErrNoSigningSecret = errors.New("no signing_keys configured and jwt_secret_key is empty")
+)
+
+// JWTKey — ключ подписи из связки. Private == nil для ключей, которые только проверяют подпись.
+type JWTKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.PrivateKey
+	Public    crypto.PublicKey
+	NotBefore time.Time
+}
+
+// KeyRing хранит ключи, отсортированные по времени начала действия. После загрузки не меняется.
+type KeyRing struct {
+	keys    []JWTKey
+	overlap time.Duration
+}
+
+var (
+	keyRingMu sync.RWMutex
+	keyRing   *KeyRing
+)
+
+// InitKeys загружает асимметричные ключи подписи из конфигурации. Если ключи не заданы,
+// токены подписываются общим секретом HS256, как раньше; без секрета сервис не запускается,
+// иначе токены подписывались бы пустым ключом.
+func InitKeys(auth models.AuthParams) error {
+	if len(auth.SigningKeys) == 0 {
+		if strings.TrimSpace(auth.JwtSecretKey) == "" {
+			return ErrNoSigningSecret
+		}
+		setKeyRing(nil)
+		return nil
+	}
+
+	ring, err := LoadKeyRing(auth)
+	if err != nil {
+		return err
+	}
+	setKeyRing(ring)
+	return nil
+}
+
+func setKeyRing(ring *KeyRing) {
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing = ring
+}
+
+// ActiveKeyRing возвращает загруженную связку ключей или nil в режиме HS256
+func ActiveKeyRing() *KeyRing {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	return keyRing
+}
+
+func LoadKeyRing(auth models.AuthParams) (*KeyRing, error) {
+	overlap := time.Duration(auth.KeyOverlapHours) * time.Hour
+	if ttl := AccessTokenTTL(); overlap < ttl {
+		overlap = ttl
+	}
+
+	ring := &KeyRing{overlap: overlap}
+	seen := make(map[string]bool)
+	for _, cfg := range auth.SigningKeys {
+		if cfg.KeyID == "" {
+			return nil, errors.New("signing key without kid")
+		}
+		if seen[cfg.KeyID] {
+			return nil, fmt.Errorf("duplicate signing key kid %q", cfg.KeyID)
+		}
+		seen[cfg.KeyID] = true
+
+		key, err := loadJWTKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", cfg.KeyID, err)
+		}
+		ring.keys = append(ring.keys, key)
+	}
+
+	sort.SliceStable(ring.keys, func(i, j int) bool {
+		return ring.keys[i].NotBefore.Before(ring.keys[j].NotBefore)
+	})
+	return ring, nil
+}
+
+func loadJWTKey(cfg models.SigningKey) (JWTKey, error) {
+	key := JWTKey{ID: cfg.KeyID}
+
+	if cfg.NotBefore != "" {
+		notBefore, err := time.Parse(time.RFC3339, cfg.NotBefore)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("invalid not_before: %w", err)
+		}
+		key.NotBefore = notBefore
+	}
+
+	var privatePEM, publicPEM []byte
+	var err error
+	if cfg.PrivateKeyFile != "" {
+		if privatePEM, err = os.ReadFile(cfg.PrivateKeyFile); err != nil {
+			return JWTKey{}, err
+		}
+	}
+	if cfg.PublicKeyFile != "" {
+		if publicPEM, err = os.ReadFile(cfg.PublicKeyFile); err != nil {
+			return JWTKey{}, err
+		}
+	}
+	if privatePEM == nil && publicPEM == nil {
+		return JWTKey{}, errors.New("private_key_file or public_key_file is required")
+	}
+
+	switch cfg.Algorithm {
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if privatePEM != nil {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return JWTKey{}, err
+			}
+			key.Private, key.Public = private, &private.PublicKey
+		} else {
+			if key.Public, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+				return JWTKey{}, err
+			}
+		}
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if privatePEM != nil {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return JWTKey{}, err
+			}
+			edKey, ok := private.(ed25519.PrivateKey)
+			if !ok {
+				return JWTKey{}, ErrUnsupportedAlgo
+			}
+			key.Private, key.Public = edKey, edKey.Public()
+		} else {
+			if key.Public, err = jwt.ParseEdPublicKeyFromPEM(publicPEM); err != nil {
+				return JWTKey{}, err
+			}
+		}
+	default:
+		return JWTKey{}, fmt.Errorf("%w: %s", ErrUnsupportedAlgo, cfg.Algorithm)
+	}
+
+	return key, nil
+}
+
+// SigningKey возвращает ключ, которым нужно подписывать токены в момент now:
+// последний по времени начала ключ с приватной частью, чей NotBefore уже наступил
+func (k *KeyRing) SigningKey(now time.Time) (JWTKey, error) {
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		key := k.keys[i]
+		if key.Private != nil && !key.NotBefore.After(now) {
+			return key, nil
+		}
+	}
+	return JWTKey{}, ErrNoActiveKey
+}
+
+// retireAt — момент, после которого ключ больше не принимается: начало действия
+// следующего ключа плюс окно перекрытия. Нулевое время означает «не выведен».
+func (k *KeyRing) retireAt(i int) time.Time {
+	if i+1 >= len(k.keys) {
+		return time.Time{}
+	}
+	return k.keys[i+1].NotBefore.Add(k.overlap)
+}
+
+// VerificationKey ищет ключ по kid среди тех, что принимаются в момент now
+func (k *KeyRing) VerificationKey(kid string, now time.Time) (JWTKey, error) {
+	for i, key := range k.keys {
+		if key.ID != kid {
+			continue
+		}
+		if retire := k.retireAt(i); !retire.IsZero() && now.After(retire) {
+			return JWTKey{}, ErrUnknownKey
+		}
+		return key, nil
+	}
+	return JWTKey{}, ErrUnknownKey
+}
+
+// JWKS публикует открытые ключи, которые принимаются сейчас или вступят в силу позже,
+// чтобы другие сервисы могли заранее их закешировать
+func (k *KeyRing) JWKS(now time.Time) models.JWKS {
+	set := models.JWKS{Keys: make([]models.JWK, 0, len(k.keys))}
+	for i, key := range k.keys {
+		if retire := k.retireAt(i); !retire.IsZero() && now.After(retire) {
+			continue
+		}
+		jwk, ok := toJWK(key)
+		if ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func toJWK(key JWTKey) (models.JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := models.JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = b64(pub)
+	default:
+		return models.JWK{}, false
+	}
+	return jwk, true
+}
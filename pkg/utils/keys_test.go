@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"WalletX/models"
+	"errors"
+	"testing"
+)
+
+func TestInitKeysRequiresSecretWithoutSigningKeys(t *testing.T) {
+	defer setKeyRing(nil)
+
+	for _, secret := range []string{"", "   "} {
+		if err := InitKeys(models.AuthParams{JwtSecretKey: secret}); !errors.Is(err, ErrNoSigningSecret) {
+			t.Errorf("secret %q: err = %v, want %v", secret, err, ErrNoSigningSecret)
+		}
+	}
+	if err := InitKeys(models.AuthParams{JwtSecretKey: "secret"}); err != nil {
+		t.Fatalf("HS256 with a secret: %v", err)
+	}
+}